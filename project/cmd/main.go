@@ -3,71 +3,120 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// 実行イメージにタイムゾーンデータがなくても稼働カレンダーを計算できるよう埋め込む
+	_ "time/tzdata"
 
+	"slack-bot/project/domain"
 	"slack-bot/project/handler"
 	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/infrastructure/metrics"
 	"slack-bot/project/infrastructure/secret"
 	"slack-bot/project/infrastructure/slack"
 	"slack-bot/project/infrastructure/store"
+	"slack-bot/project/infrastructure/store/memory"
 	"slack-bot/project/infrastructure/tasks"
+	"slack-bot/project/infrastructure/tracing"
 	"slack-bot/project/service"
 )
 
 func main() {
 	ctx := context.Background()
 
-	// 1. 設定を読み込む（Secret Manager からセンシティブ情報を取得）
+	// 0. 構造化ログ（Cloud Logging の severity 付き JSON）を標準のロガーに設定
+	// 設定の読み込み失敗も構造化ログで出力するため、LOG_LEVEL は Config より先に読み込む
+	slog.SetDefault(logging.New(os.Stdout, os.Getenv("LOG_LEVEL")))
+
+	// 1. 設定を読み込む（Secret Manager、ローカル実装のみの場合は環境変数からセンシティブ情報を取得）
 	cfg, err := config.NewConfig(ctx)
 	if err != nil {
-		log.Fatalf("設定読み込み失敗: %v", err)
+		fatal("設定読み込み失敗", err)
 	}
 
+	// トレース（TRACES_EXPORTER により OTLP / 標準出力 / 出力なしを切り替え）
+	shutdownTracing, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		fatal("トレース初期化失敗", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// 2. 依存関係を初期化
-	// Secret Manager
-	secretMgr, err := secret.NewManager(ctx, cfg.GcpProject)
+	// シークレットストア（ストア・タスクスケジューラがともにローカル実装の場合は Secret Manager を使わない）
+	secretMgr, err := newSecretStore(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Secret Manager 初期化失敗: %v", err)
+		fatal("シークレットストア初期化失敗", err)
 	}
 	defer secretMgr.Close()
 
-	// Firestore リポジトリ
-	repo, err := store.NewFirestoreRepo(ctx, cfg)
+	// リポジトリ（STORE_BACKEND により Firestore / インメモリを切り替え）
+	repo, err := newRepository(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Firestore 初期化失敗: %v", err)
+		fatal("ストア初期化失敗", err)
 	}
 	defer repo.Close()
 
 	// Slack API ポート実装
-	slackClient := slack.NewSlackClient(secretMgr)
+	slackClient := slack.NewSlackClient(secretMgr, repo, cfg.SecretTokenPrefix)
 
-	// Cloud Tasks ポート実装
-	tasksClient, err := tasks.NewCloudTasksClient(ctx, cfg)
+	// タスクポート実装（TASKS_BACKEND により Cloud Tasks / ローカルスケジューラを切り替え）
+	tasksClient, err := newTaskScheduler(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Cloud Tasks クライアント初期化失敗: %v", err)
+		fatal("タスクスケジューラ初期化失敗", err)
 	}
 	defer tasksClient.Close()
 
 	// 3. サービス層を初期化
 	reminderService := service.NewReminderService(cfg, repo, repo, slackClient, tasksClient)
+	lifecycleService := service.NewLifecycleService(cfg, repo, repo, secretMgr, slackClient)
+	analyticsService := service.NewAnalyticsService(repo, repo, slackClient)
+	digestService := service.NewDigestService(repo, repo, slackClient)
+	retentionService := service.NewRetentionService(cfg, repo, repo)
 
 	// 4. HTTP ハンドラーを設定
 	mux := http.NewServeMux()
 
-	// Slack イベント受信
-	mux.Handle("/slack/events", handler.NewEventsHandler(cfg.SlackSigningSecret, reminderService))
+	// Slack イベント受信（検証後にタスクキューへ登録してすぐに応答し、処理は /tasks/event への配送で行う）
+	mux.Handle("/slack/events", handler.NewEventsHandler(cfg.SlackSigningSecret, tasksClient))
 
 	// Slack スラッシュコマンド
-	mux.Handle("/slack/commands", handler.NewCommandsHandler(cfg.SlackSigningSecret, repo, slackClient))
+	mux.Handle("/slack/commands", handler.NewCommandsHandler(cfg.SlackSigningSecret, repo, slackClient, reminderService, analyticsService, cfg.RetentionPeriod))
+
+	// Slack インタラクション（リマインドのボタン操作）
+	mux.Handle("/slack/interactions", handler.NewInteractionsHandler(cfg.SlackSigningSecret, reminderService))
+
+	// Cloud Tasks からのコールバック（TASKS_BACKEND に応じて OIDC トークン / HMAC 署名を検証）
+	// リマインド・エスカレーションのステップと、受信した Slack イベントの処理
+	taskVerifier, err := newTaskVerifier(ctx, cfg)
+	if err != nil {
+		fatal("タスクコールバック検証の初期化失敗", err)
+	}
+	mux.Handle("/check/remind", handler.NewRemindHandler(reminderService, taskVerifier))
+	mux.Handle("/check/escalate", handler.NewEscalateHandler(reminderService, taskVerifier))
+	mux.Handle("/tasks/event", handler.NewEventTaskHandler(reminderService, lifecycleService, repo, taskVerifier))
+
+	// 応答記録の CSV エクスポート（ADMIN_API_TOKEN 未設定時は無効）
+	mux.Handle("/admin/stats/export", handler.NewStatsExportHandler(cfg.AdminAPIToken, analyticsService))
+
+	// 上長向け週次ダイジェスト（Cloud Scheduler・ローカルスケジューラから定期実行。ADMIN_API_TOKEN 未設定時は無効）
+	mux.Handle("/cron/weekly-digest", handler.NewWeeklyDigestHandler(cfg.AdminAPIToken, digestService))
 
-	// Cloud Tasks からのコールバック
-	mux.Handle("/check/remind", handler.NewRemindHandler(reminderService))
-	mux.Handle("/check/escalate", handler.NewEscalateHandler(reminderService))
+	// 保持期間を過ぎたメンション記録の定期削除（Cloud Scheduler・ローカルスケジューラから定期実行。ADMIN_API_TOKEN 未設定時は無効）
+	mux.Handle("/cron/retention-sweep", handler.NewRetentionSweepHandler(cfg.AdminAPIToken, retentionService))
 
-	// OAuth コールバック
-	mux.Handle("/slack/oauth_redirect", handler.NewOAuthHandler(cfg, repo, secretMgr))
+	// Prometheus メトリクス（ADMIN_API_TOKEN 未設定時は無効）
+	mux.Handle("/metrics", handler.NewMetricsHandler(cfg.AdminAPIToken))
+
+	// OAuth インストール開始・コールバック
+	oauthHandler := handler.NewOAuthHandler(cfg, repo, secretMgr, slackClient)
+	mux.HandleFunc("/slack/install", oauthHandler.ServeInstall)
+	mux.Handle("/slack/oauth_redirect", oauthHandler)
 
 	// ヘルスチェック
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -88,9 +137,127 @@ func main() {
 	}
 
 	addr := fmt.Sprintf("0.0.0.0:%s", port)
-	log.Printf("サーバー起動: %s (PORT=%s)", addr, port)
+	server := &http.Server{
+		Addr:    addr,
+		Handler: tracing.Handler(metrics.InstrumentHandler(mux)),
+	}
+
+	// SIGTERM（Cloud Run のインスタンス停止・docker stop）で停止処理を開始する
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		slog.Info("サーバー起動", "addr", addr, "port", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fatal("サーバーエラー", err)
+		}
+	}()
+
+	<-sigCtx.Done()
+	stop()
+
+	// 6. 停止処理
+	// 処理中のリクエストを終えてから、defer でタスクスケジューラ・ストア・トレースを閉じる
+	// （受信したイベントはタスクキューに永続化済みのため、未処理のものは再起動後・別インスタンスで処理される）
+	slog.Info("停止処理を開始します")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP サーバーの停止失敗", "error", err)
+	}
+	slog.Info("停止しました")
+}
+
+// shutdownTimeout は停止処理（処理中のリクエストの完了待ち）の上限です
+// Cloud Run は SIGTERM から10秒後にインスタンスを強制終了するため、それより短くします
+const shutdownTimeout = 8 * time.Second
+
+// fatal はエラーを出力してプロセスを終了します
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// repository は MentionRepository・TenantRepository・EventRepository を兼ねるストア実装です
+type repository interface {
+	domain.MentionRepository
+	domain.TenantRepository
+	domain.EventRepository
+	Close() error
+}
+
+// newRepository は設定に応じてストア実装を初期化します
+func newRepository(ctx context.Context, cfg *config.Config) (repository, error) {
+	switch cfg.StoreBackend {
+	case config.StoreBackendMemory:
+		slog.Warn("インメモリストアを使用します（再起動でデータは消失します）")
+		return memory.NewRepo(), nil
+	default:
+		repo, err := store.NewFirestoreRepo(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Firestore 初期化失敗: %w", err)
+		}
+		// 旧形式（reminded・escalated フラグ）のメンションを現在の形式に書き換える
+		// 失敗しても読み取り時に読み替えるため起動は続ける（一覧・一括中止の対象には次回の起動で含まれる）
+		if upgraded, err := repo.BackfillLegacyMentions(ctx); err != nil {
+			slog.Warn("旧形式メンションの移行失敗", "error", err)
+		} else if upgraded > 0 {
+			slog.Info("旧形式メンションを移行しました", "count", upgraded)
+		}
+		return repo, nil
+	}
+}
+
+// newSecretStore は設定に応じてシークレットストアを初期化します
+// ストア・タスクスケジューラがともにローカル実装の場合は、SLACK_BOT_TOKEN を既定値とするインメモリのストアを使います
+func newSecretStore(ctx context.Context, cfg *config.Config) (secret.Store, error) {
+	if !cfg.UsesSecretManager() {
+		slog.Warn("Secret Manager を使わずにインメモリのシークレットストアを使用します（インストールで取得したトークンは再起動で消失します）",
+			"slack_bot_token_set", cfg.SlackBotToken != "")
+		return secret.NewLocalStore(cfg.SlackBotToken), nil
+	}
+
+	mgr, err := secret.NewManager(ctx, cfg.GcpProject)
+	if err != nil {
+		return nil, fmt.Errorf("Secret Manager 初期化失敗: %w", err)
+	}
+	return mgr, nil
+}
+
+// taskScheduler は service.TaskPort を実装するタスクスケジューラです
+type taskScheduler interface {
+	service.TaskPort
+	Close() error
+}
+
+// newTaskScheduler は設定に応じてタスクスケジューラを初期化します
+func newTaskScheduler(ctx context.Context, cfg *config.Config) (taskScheduler, error) {
+	switch cfg.TasksBackend {
+	case config.TasksBackendLocal:
+		scheduler, err := tasks.NewLocalScheduler(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("ローカルスケジューラ初期化失敗: %w", err)
+		}
+		slog.Info("ローカルスケジューラを使用します", "file", cfg.LocalTasksFile, "target", cfg.LocalTasksTarget)
+		scheduler.Start()
+		return scheduler, nil
+	default:
+		client, err := tasks.NewCloudTasksClient(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Cloud Tasks クライアント初期化失敗: %w", err)
+		}
+		return client, nil
+	}
+}
 
-	if err := http.ListenAndServe(addr, mux); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("サーバーエラー: %v", err)
+// newTaskVerifier は設定に応じて /check/*・/tasks/event のリクエストを認証する TaskVerifier を初期化します
+// Cloud Tasks はタスクに付与した OIDC トークン、ローカルスケジューラは HMAC 署名で認証します
+func newTaskVerifier(ctx context.Context, cfg *config.Config) (httpsec.TaskVerifier, error) {
+	switch cfg.TasksBackend {
+	case config.TasksBackendLocal:
+		return httpsec.NewHMACTaskVerifier(cfg.TasksCallbackSecret)
+	default:
+		return httpsec.NewOIDCTaskVerifier(ctx, cfg.TasksAudience, cfg.TasksServiceAccount)
 	}
 }
@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// calendarSearchDays は次の稼働時間を探す最大日数です（設定ミスによる無限ループ防止）
+const calendarSearchDays = 366 * 2
+
+// holidayLayout は休日リストの日付形式です
+const holidayLayout = "2006-01-02"
+
+// WorkingCalendar はテナントの稼働カレンダー（タイムゾーン・稼働曜日・稼働時間・休日）です
+// リマインド・エスカレーションの期限はこのカレンダーの稼働時間のみを数えて計算します
+type WorkingCalendar struct {
+	// TimeZone は IANA タイムゾーン名（例: Asia/Tokyo）
+	TimeZone string `firestore:"time_zone"`
+
+	// WorkingDays は稼働曜日（time.Weekday の値。0=日曜 〜 6=土曜）
+	WorkingDays []int `firestore:"working_days"`
+
+	// StartMinute は稼働開始時刻（0時からの分数）
+	StartMinute int `firestore:"start_minute"`
+
+	// EndMinute は稼働終了時刻（0時からの分数。StartMinute より大きい必要があります）
+	EndMinute int `firestore:"end_minute"`
+
+	// Holidays は個別の休日（YYYY-MM-DD 形式。年末年始休暇や創立記念日など）
+	Holidays []string `firestore:"holidays"`
+
+	// JapaneseHolidays が true の場合、日本の祝日（振替休日・国民の休日を含む）を休日として扱います
+	JapaneseHolidays bool `firestore:"japanese_holidays"`
+}
+
+// DefaultWorkingCalendar は日本の一般的な稼働カレンダー（平日 9:00〜18:00、祝日休み）を返します
+func DefaultWorkingCalendar() WorkingCalendar {
+	return WorkingCalendar{
+		TimeZone:         "Asia/Tokyo",
+		WorkingDays:      []int{int(time.Monday), int(time.Tuesday), int(time.Wednesday), int(time.Thursday), int(time.Friday)},
+		StartMinute:      9 * 60,
+		EndMinute:        18 * 60,
+		JapaneseHolidays: true,
+	}
+}
+
+// Validate は稼働カレンダーを検証します
+func (c WorkingCalendar) Validate() error {
+	if _, err := time.LoadLocation(c.TimeZone); c.TimeZone == "" || err != nil {
+		return fmt.Errorf("%w: タイムゾーンが不正です: %s", ErrInvalid, c.TimeZone)
+	}
+	if len(c.WorkingDays) == 0 {
+		return fmt.Errorf("%w: 稼働曜日が1つ以上必要です", ErrInvalid)
+	}
+	for _, d := range c.WorkingDays {
+		if d < int(time.Sunday) || d > int(time.Saturday) {
+			return fmt.Errorf("%w: 稼働曜日が不正です: %d", ErrInvalid, d)
+		}
+	}
+	if c.StartMinute < 0 || c.EndMinute > 24*60 || c.StartMinute >= c.EndMinute {
+		return fmt.Errorf("%w: 稼働時間が不正です: %d-%d", ErrInvalid, c.StartMinute, c.EndMinute)
+	}
+	for _, h := range c.Holidays {
+		if _, err := time.Parse(holidayLayout, h); err != nil {
+			return fmt.Errorf("%w: 休日の形式が不正です: %s", ErrInvalid, h)
+		}
+	}
+	return nil
+}
+
+// Location はカレンダーのタイムゾーンを返します（不正な場合は UTC）
+func (c WorkingCalendar) Location() *time.Location {
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// IsWorkingDay は指定日（カレンダーのタイムゾーンでの日付）が稼働日かどうかを返します
+func (c WorkingCalendar) IsWorkingDay(t time.Time) bool {
+	t = t.In(c.Location())
+
+	working := false
+	for _, d := range c.WorkingDays {
+		if time.Weekday(d) == t.Weekday() {
+			working = true
+			break
+		}
+	}
+	if !working {
+		return false
+	}
+
+	date := t.Format(holidayLayout)
+	for _, h := range c.Holidays {
+		if h == date {
+			return false
+		}
+	}
+
+	if c.JapaneseHolidays && IsJapaneseHoliday(t.Year(), t.Month(), t.Day()) {
+		return false
+	}
+
+	return true
+}
+
+// AddBusinessDuration は start から稼働時間のみを d だけ数えた時刻を返します
+// start が稼働時間外の場合は次の稼働開始時刻から数え始めます
+// 稼働時間が見つからない設定の場合は単純に d を加算した時刻を返します
+func (c WorkingCalendar) AddBusinessDuration(start time.Time, d time.Duration) time.Time {
+	t := start.In(c.Location())
+	remaining := d
+
+	for i := 0; i < calendarSearchDays; i++ {
+		windowStart, windowEnd, ok := c.nextWindow(t)
+		if !ok {
+			break
+		}
+		if t.Before(windowStart) {
+			t = windowStart
+		}
+
+		available := windowEnd.Sub(t)
+		if remaining <= available {
+			return t.Add(remaining)
+		}
+		remaining -= available
+		t = windowEnd
+	}
+
+	return start.Add(d)
+}
+
+// nextWindow は t 以降で最初に終了していない稼働時間帯 [開始, 終了) を返します
+func (c WorkingCalendar) nextWindow(t time.Time) (time.Time, time.Time, bool) {
+	loc := c.Location()
+	t = t.In(loc)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	for i := 0; i < calendarSearchDays; i++ {
+		if c.IsWorkingDay(day) {
+			// 夏時間の切り替え日でも壁時計の時刻になるよう time.Date で組み立てる
+			windowStart := time.Date(day.Year(), day.Month(), day.Day(), 0, c.StartMinute, 0, 0, loc)
+			windowEnd := time.Date(day.Year(), day.Month(), day.Day(), 0, c.EndMinute, 0, 0, loc)
+			if t.Before(windowEnd) {
+				return windowStart, windowEnd, true
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// Normalize は稼働曜日と休日を昇順・重複なしに整えたカレンダーを返します
+func (c WorkingCalendar) Normalize() WorkingCalendar {
+	days := make([]int, 0, len(c.WorkingDays))
+	seenDay := make(map[int]bool)
+	for _, d := range c.WorkingDays {
+		if !seenDay[d] {
+			seenDay[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Ints(days)
+
+	holidays := make([]string, 0, len(c.Holidays))
+	seenHoliday := make(map[string]bool)
+	for _, h := range c.Holidays {
+		if !seenHoliday[h] {
+			seenHoliday[h] = true
+			holidays = append(holidays, h)
+		}
+	}
+	sort.Strings(holidays)
+
+	c.WorkingDays = days
+	c.Holidays = holidays
+	return c
+}
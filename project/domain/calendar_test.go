@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWorkingCalendarAddBusinessDuration(t *testing.T) {
+	jst, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("タイムゾーン読み込み失敗: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, jst)
+	}
+
+	withoutHolidays := DefaultWorkingCalendar()
+	withoutHolidays.JapaneseHolidays = false
+
+	withCompanyHoliday := DefaultWorkingCalendar()
+	withCompanyHoliday.Holidays = []string{"2026-10-16"}
+
+	tests := []struct {
+		name     string
+		calendar WorkingCalendar
+		start    time.Time
+		d        time.Duration
+		want     time.Time
+	}{
+		{
+			name:     "稼働時間内で完結",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.October, 15, 10, 0),
+			d:        30 * time.Minute,
+			want:     at(2026, time.October, 15, 10, 30),
+		},
+		{
+			name:     "稼働開始前は開始時刻から数える",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.October, 15, 7, 0),
+			d:        10 * time.Minute,
+			want:     at(2026, time.October, 15, 9, 10),
+		},
+		{
+			name:     "終業をまたぐと翌稼働日に繰り越す",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.October, 15, 17, 50),
+			d:        20 * time.Minute,
+			want:     at(2026, time.October, 16, 9, 10),
+		},
+		{
+			name:     "金曜の終業後は週末を飛ばす",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.October, 16, 17, 30),
+			d:        time.Hour,
+			want:     at(2026, time.October, 19, 9, 30),
+		},
+		{
+			name:     "ゴールデンウィーク（振替休日を含む）を飛ばす",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.May, 1, 17, 30),
+			d:        time.Hour,
+			want:     at(2026, time.May, 7, 9, 30),
+		},
+		{
+			name:     "シルバーウィーク（国民の休日を含む）を飛ばす",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.September, 18, 17, 30),
+			d:        time.Hour,
+			want:     at(2026, time.September, 24, 9, 30),
+		},
+		{
+			name:     "元日を飛ばす",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.December, 31, 17, 30),
+			d:        time.Hour,
+			want:     at(2027, time.January, 4, 9, 30),
+		},
+		{
+			name:     "祝日を休みにしない設定では祝日も稼働日",
+			calendar: withoutHolidays,
+			start:    at(2026, time.May, 1, 17, 30),
+			d:        time.Hour,
+			want:     at(2026, time.May, 4, 9, 30),
+		},
+		{
+			name:     "個別の休日を飛ばす",
+			calendar: withCompanyHoliday,
+			start:    at(2026, time.October, 15, 17, 30),
+			d:        time.Hour,
+			want:     at(2026, time.October, 19, 9, 30),
+		},
+		{
+			name:     "複数日にわたる稼働時間を数える",
+			calendar: DefaultWorkingCalendar(),
+			start:    at(2026, time.October, 15, 9, 0),
+			d:        20 * time.Hour,
+			want:     at(2026, time.October, 19, 11, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.calendar.AddBusinessDuration(tt.start, tt.d)
+			if !got.Equal(tt.want) {
+				t.Errorf("AddBusinessDuration(%s, %s) = %s, want %s", tt.start, tt.d, got.In(jst), tt.want)
+			}
+		})
+	}
+}
+
+func TestIsJapaneseHoliday(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want bool
+	}{
+		{time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), true},    // 元日
+		{time.Date(2026, time.January, 12, 0, 0, 0, 0, time.UTC), true},   // 成人の日（第2月曜）
+		{time.Date(2026, time.May, 6, 0, 0, 0, 0, time.UTC), true},        // 振替休日（憲法記念日が日曜）
+		{time.Date(2026, time.September, 22, 0, 0, 0, 0, time.UTC), true}, // 国民の休日（敬老の日と秋分の日の間）
+		{time.Date(2026, time.September, 23, 0, 0, 0, 0, time.UTC), true}, // 秋分の日
+		{time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC), false},  // 平日
+		{time.Date(2026, time.May, 7, 0, 0, 0, 0, time.UTC), false},       // ゴールデンウィーク明け
+	}
+
+	for _, tt := range tests {
+		if got := IsJapaneseHoliday(tt.date.Year(), tt.date.Month(), tt.date.Day()); got != tt.want {
+			t.Errorf("IsJapaneseHoliday(%s) = %v, want %v", tt.date.Format(holidayLayout), got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package domain
 
 import (
+	"cmp"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -10,15 +12,46 @@ type Tenant struct {
 	// TeamID はSlackワークスペースのID
 	TeamID string `firestore:"team_id"`
 
-	// ManagerUserID は上長のSlackユーザーID。
-	// nilの場合は上長未設定を表す
+	// ManagerUserID はワークスペース全体の上長のSlackユーザーID。
+	// nilの場合は上長未設定を表す。個別ルールがない場合のフォールバック先
 	ManagerUserID *string `firestore:"manager_user_id"`
 
+	// UserManagers はメンバーごとの上長（メンバーのユーザーID -> 上長のユーザーID）
+	UserManagers map[string]string `firestore:"user_managers"`
+
+	// ChannelManagers はチャンネルごとの上長（チャンネルID -> 上長のユーザーID）
+	ChannelManagers map[string]string `firestore:"channel_managers"`
+
 	// BotTokenSecretName はSecret Managerに保存されたBotトークンのシークレット名
 	BotTokenSecretName string `firestore:"bot_token_secret_name"`
 
+	// EscalationPolicy はエスカレーション手順。nilの場合は環境変数に基づくデフォルト手順を使用
+	EscalationPolicy *EscalationPolicy `firestore:"escalation_policy"`
+
+	// WorkingCalendar は稼働カレンダー。nilの場合は時間帯を問わず経過時間で期限を計算
+	WorkingCalendar *WorkingCalendar `firestore:"working_calendar"`
+
+	// ReplyRule はワークスペース全体の返信判定ルール。nilの場合はメンション付き返信で判定（ReplyPolicyMention）
+	ReplyRule *ReplyRule `firestore:"reply_rule"`
+
+	// ChannelReplyRules はチャンネルごとの返信判定ルール（チャンネルID -> ルール）
+	ChannelReplyRules map[string]ReplyRule `firestore:"channel_reply_rules"`
+
+	// ReactionWorkflow はリアクションによる状態更新の絵文字設定。nilの場合はデフォルト（:eyes: / :white_check_mark:）
+	ReactionWorkflow *ReactionWorkflow `firestore:"reaction_workflow"`
+
+	// RetentionDays はメンション記録の保持期間（メンションからの日数）。0の場合は環境変数に基づくデフォルト
+	RetentionDays int `firestore:"retention_days"`
+
 	// CreatedAt はレコードの作成日時（Unix秒）
 	CreatedAt int64 `firestore:"created_at"`
+
+	// DeactivatedAt はアンインストール・トークン失効により無効化された日時（Unix秒）。有効な場合は0
+	// 再インストール（UpsertBotTokenSecret）で0に戻ります
+	DeactivatedAt int64 `firestore:"deactivated_at"`
+
+	// DigestSentAt は上長向け週次ダイジェストを最後に送信した日時（Unix秒）。未送信の場合は0
+	DigestSentAt int64 `firestore:"digest_sent_at"`
 }
 
 // 返信待ちの監視対象メンション構造体
@@ -32,17 +65,196 @@ type Mention struct {
 	// MessageTS はメンションを含む親メッセージのタイムスタンプ
 	MessageTS string `firestore:"message_ts"`
 
+	// ThreadTS はメンションがスレッド内の返信だった場合のスレッド親メッセージのタイムスタンプ
+	// 親メッセージ自体へのメンション（スレッド外の投稿）では空
+	ThreadTS string `firestore:"thread_ts"`
+
 	// MentionedUserID は返信を期待されているユーザーのID
 	MentionedUserID string `firestore:"mentioned_user_id"`
 
+	// ParentUserID はメンションを投稿したユーザーのID（メンション返信判定に使用）
+	ParentUserID string `firestore:"parent_user_id"`
+
 	// CreatedAt はレコードの作成日時（Unix秒）
 	CreatedAt int64 `firestore:"created_at"`
 
-	// Reminded は10分後の初回リマインドが完了したかどうか
-	Reminded bool `firestore:"reminded"`
+	// Step は次に実行するエスカレーションステップのインデックス（= 完了済みステップ数）
+	Step int `firestore:"step"`
+
+	// Status は監視状態。空文字は MentionStatusOpen と同じ扱い（旧レコード互換）
+	Status MentionStatus `firestore:"status"`
+
+	// RepliedAt は返信を検知した日時（Unix秒）。未返信の場合は0
+	RepliedAt int64 `firestore:"replied_at"`
+
+	// ResolvedAt は対象者がリマインドのボタンで確認済み・担当外と回答した日時（Unix秒）
+	ResolvedAt int64 `firestore:"resolved_at"`
+
+	// SnoozedUntil はスヌーズ期限（Unix秒）。この時刻まではステップを実行しません
+	SnoozedUntil int64 `firestore:"snoozed_until"`
+
+	// AcknowledgedAt は「確認中」のリアクションがつけられた日時（Unix秒）。0の場合は未確認
+	// 監視は継続し、上長DMのみを抑止します
+	AcknowledgedAt int64 `firestore:"acknowledged_at"`
+
+	// RemindedAt は最初のエスカレーションステップ（リマインド）を実行した日時（Unix秒）。未実行の場合は0
+	RemindedAt int64 `firestore:"reminded_at"`
+
+	// EscalatedAt は最初のエスカレーション（上長DM・チャンネル投稿）を実行した日時（Unix秒）。未実行の場合は0
+	EscalatedAt int64 `firestore:"escalated_at"`
+
+	// ClosedAt は監視を終了した日時（Unix秒）。返信の場合は返信メッセージの投稿日時。監視中は0
+	ClosedAt int64 `firestore:"closed_at"`
+
+	// Outcome は監視終了時の結果（応答までの段階・期限切れ）。監視中・中止の場合は空
+	Outcome MentionOutcome `firestore:"outcome"`
+
+	// RetainUntil は記録の保持期限（Unix秒）。この時刻を過ぎると TTL・定期削除の対象になります。0の場合は削除しない
+	RetainUntil int64 `firestore:"retain_until"`
+}
+
+// MentionStatus はメンション監視の状態を表します
+type MentionStatus string
+
+const (
+	// MentionStatusOpen は返信待ち（監視中）
+	MentionStatusOpen MentionStatus = "open"
+
+	// MentionStatusReplied は返信を検知して監視を終了した状態
+	MentionStatusReplied MentionStatus = "replied"
+
+	// MentionStatusAcknowledged は対象者が「確認しました」と回答して監視を終了した状態
+	MentionStatusAcknowledged MentionStatus = "acknowledged"
+
+	// MentionStatusDeclined は対象者が「担当外」と回答して監視を終了した状態
+	MentionStatusDeclined MentionStatus = "declined"
+
+	// MentionStatusDone は「対応済み」のリアクションにより監視を終了した状態
+	MentionStatusDone MentionStatus = "done"
+
+	// MentionStatusExpired は全ステップの実行後も返信がなく、期限切れで監視を終了した状態
+	MentionStatusExpired MentionStatus = "expired"
+
+	// MentionStatusCancelled はアプリのアンインストールなどにより監視を中止した状態
+	MentionStatusCancelled MentionStatus = "cancelled"
+)
+
+// IsOpen は返信待ち（リマインド・エスカレーション対象）かどうかを返します
+func (m Mention) IsOpen() bool {
+	return m.Status == "" || m.Status == MentionStatusOpen
+}
+
+// IsAcknowledged は「確認中」のリアクションにより上長DMを抑止する状態かどうかを返します
+func (m Mention) IsAcknowledged() bool {
+	return m.AcknowledgedAt > 0
+}
+
+// ThreadRootTS は返信確認・リマインド投稿に使うスレッド親メッセージのタイムスタンプを返します
+func (m Mention) ThreadRootTS() string {
+	if m.ThreadTS != "" {
+		return m.ThreadTS
+	}
+	return m.MessageTS
+}
+
+// MergeSaved は保存済みのレコード saved に m を重ねた、保存するレコードを返します
+// 同じメンションの再保存（Slack の再送による再処理など）で作成日時や進行状況を失わないよう、
+// 作成日時は保存済みの値を優先し、ステップは進んでいる方、状態は監視終了済みなら保存済みの値を使います
+// その他のフィールドは m で未設定（ゼロ値）の場合に保存済みの値を保持します
+func (m Mention) MergeSaved(saved Mention) Mention {
+	merged := m
+	merged.ThreadTS = cmp.Or(m.ThreadTS, saved.ThreadTS)
+	merged.ParentUserID = cmp.Or(m.ParentUserID, saved.ParentUserID)
+	merged.CreatedAt = cmp.Or(saved.CreatedAt, m.CreatedAt)
+	merged.Step = max(m.Step, saved.Step)
+	merged.Status = cmp.Or(m.Status, saved.Status)
+	if m.IsOpen() && !saved.IsOpen() {
+		merged.Status = saved.Status
+	}
+	merged.RepliedAt = cmp.Or(m.RepliedAt, saved.RepliedAt)
+	merged.ResolvedAt = cmp.Or(m.ResolvedAt, saved.ResolvedAt)
+	merged.SnoozedUntil = cmp.Or(m.SnoozedUntil, saved.SnoozedUntil)
+	merged.AcknowledgedAt = cmp.Or(m.AcknowledgedAt, saved.AcknowledgedAt)
+	merged.RemindedAt = cmp.Or(m.RemindedAt, saved.RemindedAt)
+	merged.EscalatedAt = cmp.Or(m.EscalatedAt, saved.EscalatedAt)
+	merged.ClosedAt = cmp.Or(m.ClosedAt, saved.ClosedAt)
+	merged.Outcome = cmp.Or(m.Outcome, saved.Outcome)
+	merged.RetainUntil = cmp.Or(m.RetainUntil, saved.RetainUntil)
+	return merged
+}
+
+// IsActive はテナントが有効（アンインストール・トークン失効されていない）かどうかを返します
+func (t Tenant) IsActive() bool {
+	return t.DeactivatedAt == 0
+}
+
+// IsSnoozed は now（Unix秒）の時点でスヌーズ中かどうかを返します
+func (m Mention) IsSnoozed(now int64) bool {
+	return m.SnoozedUntil > now
+}
+
+// IsManager は userID がワークスペース全体・メンバーごと・チャンネルごとのいずれかの上長に設定されているかを返します
+func (t Tenant) IsManager(userID string) bool {
+	if t.ManagerUserID != nil && *t.ManagerUserID == userID {
+		return true
+	}
+	for _, managerUserID := range t.UserManagers {
+		if managerUserID == userID {
+			return true
+		}
+	}
+	for _, managerUserID := range t.ChannelManagers {
+		if managerUserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Managers はワークスペース全体・メンバーごと・チャンネルごとに設定されている上長を重複なく ID 順で返します
+func (t Tenant) Managers() []string {
+	seen := make(map[string]bool)
+	if t.ManagerUserID != nil && *t.ManagerUserID != "" {
+		seen[*t.ManagerUserID] = true
+	}
+	for _, managerUserID := range t.UserManagers {
+		seen[managerUserID] = true
+	}
+	for _, managerUserID := range t.ChannelManagers {
+		seen[managerUserID] = true
+	}
+
+	managers := make([]string, 0, len(seen))
+	for managerUserID := range seen {
+		if managerUserID != "" {
+			managers = append(managers, managerUserID)
+		}
+	}
+	sort.Strings(managers)
+	return managers
+}
+
+// EscalationTargets は対象者とチャンネルに応じた上長DMの送信先を返します
+// メンバーごとの上長・チャンネルごとの上長を重複なく返し、どちらも未設定の場合はワークスペース全体の上長を返します
+// 対象者本人は送信先から除外します
+func (t Tenant) EscalationTargets(channelID, mentionedUserID string) []string {
+	var targets []string
+	seen := map[string]bool{mentionedUserID: true}
+	add := func(userID string) {
+		if userID != "" && !seen[userID] {
+			seen[userID] = true
+			targets = append(targets, userID)
+		}
+	}
+
+	add(t.UserManagers[mentionedUserID])
+	add(t.ChannelManagers[channelID])
+
+	if len(targets) == 0 && t.ManagerUserID != nil {
+		add(*t.ManagerUserID)
+	}
 
-	// Escalated は30分後の再リマインド＆上長通知が完了したかどうか
-	Escalated bool `firestore:"escalated"`
+	return targets
 }
 
 // MentionKey は監視対象メンションの一意キーを生成します
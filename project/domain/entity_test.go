@@ -0,0 +1,97 @@
+package domain
+
+import "testing"
+
+func TestMentionMergeSaved(t *testing.T) {
+	base := Mention{
+		TeamID:          "T1",
+		ChannelID:       "C1",
+		MessageTS:       "1700000000.000100",
+		MentionedUserID: "U1",
+		ParentUserID:    "U2",
+		CreatedAt:       1000,
+		Status:          MentionStatusOpen,
+		RetainUntil:     5000,
+	}
+
+	tests := []struct {
+		name  string
+		saved Mention
+		m     Mention
+		want  Mention
+	}{
+		{
+			name: "作成日時・進行状況を保持する",
+			saved: func() Mention {
+				s := base
+				s.Step = 2
+				s.SnoozedUntil = 1800
+				s.AcknowledgedAt = 1500
+				s.RemindedAt = 1600
+				s.EscalatedAt = 1700
+				return s
+			}(),
+			m: func() Mention {
+				m := base
+				m.CreatedAt = 1200
+				return m
+			}(),
+			want: func() Mention {
+				w := base
+				w.Step = 2
+				w.SnoozedUntil = 1800
+				w.AcknowledgedAt = 1500
+				w.RemindedAt = 1600
+				w.EscalatedAt = 1700
+				return w
+			}(),
+		},
+		{
+			name: "監視終了済みの状態は監視中で上書きしない",
+			saved: func() Mention {
+				s := base
+				s.Step = 1
+				s.RemindedAt = 1600
+				s.Close(MentionStatusReplied, 2000)
+				return s
+			}(),
+			m: base,
+			want: func() Mention {
+				w := base
+				w.Step = 1
+				w.RemindedAt = 1600
+				w.Close(MentionStatusReplied, 2000)
+				return w
+			}(),
+		},
+		{
+			name: "新しい値が設定されていれば上書きする",
+			saved: func() Mention {
+				s := base
+				s.Step = 1
+				s.RetainUntil = 4000
+				return s
+			}(),
+			m: func() Mention {
+				m := base
+				m.Step = 2
+				m.RetainUntil = 6000
+				return m
+			}(),
+			want: func() Mention {
+				w := base
+				w.Step = 2
+				w.RetainUntil = 6000
+				return w
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.MergeSaved(tt.saved); got != tt.want {
+				t.Errorf("MergeSaved() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -28,6 +28,9 @@ var (
 	// ErrSlackAPIFailed は Slack API 呼び出しが失敗した場合のエラー
 	ErrSlackAPIFailed = errors.New("ドメイン: Slack API 呼び出し失敗")
 
+	// ErrTemporary はレート制限・一時的な障害など、時間をおいて再試行すれば成功しうる場合のエラー
+	ErrTemporary = errors.New("ドメイン: 一時的なエラーです（再試行可能）")
+
 	// ErrUserNotFound はユーザーが見つからない場合のエラー
 	ErrUserNotFound = errors.New("ドメイン: ユーザーが見つかりません")
 
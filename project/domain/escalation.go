@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// EscalationAction はエスカレーションの各ステップで実行する通知の種類です
+type EscalationAction string
+
+const (
+	// EscalationActionThreadReminder は元スレッドに対象者へのリマインドを投稿します
+	EscalationActionThreadReminder EscalationAction = "thread_reminder"
+
+	// EscalationActionDMMentionee は対象者本人に DM を送信します
+	EscalationActionDMMentionee EscalationAction = "dm_mentionee"
+
+	// EscalationActionDMManager は上長に DM を送信します（上長未設定の場合はスキップ）
+	EscalationActionDMManager EscalationAction = "dm_manager"
+
+	// EscalationActionChannelPost はチャンネルに（スレッド外で）投稿します
+	EscalationActionChannelPost EscalationAction = "channel_post"
+)
+
+// IsEscalation は対象者以外（上長・チャンネル）に知らせるエスカレーションかどうかを返します
+func (a EscalationAction) IsEscalation() bool {
+	return a == EscalationActionDMManager || a == EscalationActionChannelPost
+}
+
+// デフォルトの通知文面
+// {mentionee} / {mentioner} はメンション形式、{thread_url} はスレッドの URL に置換されます
+const (
+	defaultRemindTemplate   = "{mentionee} さん、お手すきの際にご返信お願いします🙏（自動リマインド）"
+	defaultReRemindTemplate = "{mentionee} さん、まだ未返信のようです。目安だけでもご共有ください🙏（自動リマインド）"
+	defaultDMMentionee      = "{mentioner} さんからのメンションが未返信です。お手すきの際にご確認ください🙏 対象スレッド: {thread_url}"
+	defaultDMManager        = "【エスカレーション】{mentionee} さんが未返信です。対象スレッド: {thread_url}"
+	defaultChannelPost      = "{mentionee} さん、{mentioner} さんからのメンションが未返信です🙏 対象スレッド: {thread_url}"
+)
+
+// EscalationStep はエスカレーションの1ステップを表します
+type EscalationStep struct {
+	// DelaySeconds はメンション発生からこのステップを実行するまでの時間（秒）
+	DelaySeconds int64 `firestore:"delay_seconds"`
+
+	// Action は実行する通知の種類
+	Action EscalationAction `firestore:"action"`
+
+	// Template は通知文面。空の場合はアクションごとのデフォルト文面を使用します
+	Template string `firestore:"template"`
+}
+
+// Delay はステップの実行までの時間を返します
+func (s EscalationStep) Delay() time.Duration {
+	return time.Duration(s.DelaySeconds) * time.Second
+}
+
+// MessageTemplate は通知文面のテンプレートを返します（未設定ならデフォルト文面）
+func (s EscalationStep) MessageTemplate() string {
+	if s.Template != "" {
+		return s.Template
+	}
+	switch s.Action {
+	case EscalationActionDMMentionee:
+		return defaultDMMentionee
+	case EscalationActionDMManager:
+		return defaultDMManager
+	case EscalationActionChannelPost:
+		return defaultChannelPost
+	default:
+		return defaultRemindTemplate
+	}
+}
+
+// EscalationPolicy はテナントごとのエスカレーション手順（ステップの順序付きリスト）です
+type EscalationPolicy struct {
+	// Steps は実行順に並んだステップ。DelaySeconds は昇順（同値可）である必要があります
+	Steps []EscalationStep `firestore:"steps"`
+}
+
+// DefaultEscalationPolicy はテナント未設定時の手順を返します
+// remind 後にスレッドリマインド、escalate 後に再リマインドと上長 DM を行います
+func DefaultEscalationPolicy(remind, escalate time.Duration) EscalationPolicy {
+	return EscalationPolicy{
+		Steps: []EscalationStep{
+			{DelaySeconds: int64(remind / time.Second), Action: EscalationActionThreadReminder, Template: defaultRemindTemplate},
+			{DelaySeconds: int64(escalate / time.Second), Action: EscalationActionThreadReminder, Template: defaultReRemindTemplate},
+			{DelaySeconds: int64(escalate / time.Second), Action: EscalationActionDMManager},
+		},
+	}
+}
+
+// Validate はエスカレーション手順を検証します
+func (p EscalationPolicy) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("%w: ステップが1つ以上必要です", ErrInvalid)
+	}
+
+	var prev int64
+	for i, step := range p.Steps {
+		if step.DelaySeconds <= 0 {
+			return fmt.Errorf("%w: ステップ%dの遅延時間は0より大きい必要があります", ErrInvalid, i+1)
+		}
+		if step.DelaySeconds < prev {
+			return fmt.Errorf("%w: ステップ%dの遅延時間が前のステップより短くなっています", ErrInvalid, i+1)
+		}
+		if !step.Action.IsValid() {
+			return fmt.Errorf("%w: ステップ%dのアクションが不正です: %s", ErrInvalid, i+1, step.Action)
+		}
+		prev = step.DelaySeconds
+	}
+
+	return nil
+}
+
+// IsValid は既知のアクションかどうかを返します
+func (a EscalationAction) IsValid() bool {
+	switch a {
+	case EscalationActionThreadReminder, EscalationActionDMMentionee, EscalationActionDMManager, EscalationActionChannelPost:
+		return true
+	default:
+		return false
+	}
+}
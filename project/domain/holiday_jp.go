@@ -0,0 +1,88 @@
+package domain
+
+import "time"
+
+// IsJapaneseHoliday は指定日が日本の国民の祝日・振替休日・国民の休日かどうかを返します
+// 現行の祝日法（2020年以降の名称・日付）に基づいて計算します
+// 春分・秋分の日は 1980〜2099 年で有効な近似式で算出します（官報公示と一致）
+// 2020・2021 年の東京オリンピックに伴う祝日移動は考慮しません
+func IsJapaneseHoliday(year int, month time.Month, day int) bool {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	if isNationalHoliday(date) {
+		return true
+	}
+
+	// 振替休日: 祝日が日曜日に当たる場合、その日以降で最も近い祝日でない日を休日とする
+	if date.Weekday() != time.Sunday {
+		for prev := date.AddDate(0, 0, -1); isNationalHoliday(prev); prev = prev.AddDate(0, 0, -1) {
+			if prev.Weekday() == time.Sunday {
+				return true
+			}
+		}
+	}
+
+	// 国民の休日: 前日と翌日が祝日である平日（例: 敬老の日と秋分の日に挟まれた日）
+	if date.Weekday() != time.Sunday &&
+		isNationalHoliday(date.AddDate(0, 0, -1)) &&
+		isNationalHoliday(date.AddDate(0, 0, 1)) {
+		return true
+	}
+
+	return false
+}
+
+// isNationalHoliday は指定日が「国民の祝日に関する法律」第2条の祝日かどうかを返します
+func isNationalHoliday(date time.Time) bool {
+	year, month, day := date.Year(), date.Month(), date.Day()
+
+	switch month {
+	case time.January:
+		// 元日、成人の日（第2月曜日）
+		return day == 1 || isNthMonday(date, 2)
+	case time.February:
+		// 建国記念の日、天皇誕生日
+		return day == 11 || day == 23
+	case time.March:
+		// 春分の日
+		return day == vernalEquinoxDay(year)
+	case time.April:
+		// 昭和の日
+		return day == 29
+	case time.May:
+		// 憲法記念日、みどりの日、こどもの日
+		return day == 3 || day == 4 || day == 5
+	case time.July:
+		// 海の日（第3月曜日）
+		return isNthMonday(date, 3)
+	case time.August:
+		// 山の日
+		return day == 11
+	case time.September:
+		// 敬老の日（第3月曜日）、秋分の日
+		return isNthMonday(date, 3) || day == autumnalEquinoxDay(year)
+	case time.October:
+		// スポーツの日（第2月曜日）
+		return isNthMonday(date, 2)
+	case time.November:
+		// 文化の日、勤労感謝の日
+		return day == 3 || day == 23
+	default:
+		return false
+	}
+}
+
+// isNthMonday は指定日がその月の第 n 月曜日かどうかを返します
+func isNthMonday(date time.Time, n int) bool {
+	return date.Weekday() == time.Monday && (date.Day()-1)/7 == n-1
+}
+
+// vernalEquinoxDay は春分の日（3月の日付）を返します
+func vernalEquinoxDay(year int) int {
+	return int(20.8431+0.242194*float64(year-1980)) - (year-1980)/4
+}
+
+// autumnalEquinoxDay は秋分の日（9月の日付）を返します
+func autumnalEquinoxDay(year int) int {
+	return int(23.2488+0.242194*float64(year-1980)) - (year-1980)/4
+}
@@ -0,0 +1,93 @@
+package domain
+
+// MentionOutcome はメンションの監視終了時の結果です（応答時間の分析に使用）
+type MentionOutcome string
+
+const (
+	// MentionOutcomeBeforeRemind は最初のリマインドより前に応答した結果
+	MentionOutcomeBeforeRemind MentionOutcome = "before_remind"
+
+	// MentionOutcomeAfterRemind はリマインド後、エスカレーション（上長DM・チャンネル投稿）より前に応答した結果
+	MentionOutcomeAfterRemind MentionOutcome = "after_remind"
+
+	// MentionOutcomeAfterEscalation はエスカレーション後に応答した結果
+	MentionOutcomeAfterEscalation MentionOutcome = "after_escalation"
+
+	// MentionOutcomeExpired は全ステップの実行後も応答がなく期限切れになった結果
+	MentionOutcomeExpired MentionOutcome = "expired"
+)
+
+// IsResponse は対象者の応答（返信・確認済み・担当外・対応済み）による結果かどうかを返します
+func (o MentionOutcome) IsResponse() bool {
+	switch o {
+	case MentionOutcomeBeforeRemind, MentionOutcomeAfterRemind, MentionOutcomeAfterEscalation:
+		return true
+	}
+	return false
+}
+
+// outcomeFor は status・closedAt で監視を終了する場合の結果を返します
+// 応答の場合はリマインド・エスカレーションの実行日時と比べて段階を判定します。中止の場合は空です
+func (m Mention) outcomeFor(status MentionStatus, closedAt int64) MentionOutcome {
+	switch status {
+	case MentionStatusExpired:
+		return MentionOutcomeExpired
+	case MentionStatusCancelled:
+		return ""
+	}
+
+	switch {
+	case m.EscalatedAt > 0 && closedAt >= m.EscalatedAt:
+		return MentionOutcomeAfterEscalation
+	case m.RemindedAt > 0 && closedAt >= m.RemindedAt:
+		return MentionOutcomeAfterRemind
+	default:
+		return MentionOutcomeBeforeRemind
+	}
+}
+
+// Close は status で監視を終了し、終了日時と結果を記録します
+// 返信の場合は RepliedAt、それ以外は ResolvedAt にも closedAt を記録します
+func (m *Mention) Close(status MentionStatus, closedAt int64) {
+	m.Status = status
+	m.ClosedAt = closedAt
+	m.Outcome = m.outcomeFor(status, closedAt)
+	if status == MentionStatusReplied {
+		m.RepliedAt = closedAt
+	} else {
+		m.ResolvedAt = closedAt
+	}
+}
+
+// Reopen は監視中に戻し、終了日時と結果をクリアします
+func (m *Mention) Reopen() {
+	m.Status = MentionStatusOpen
+	m.ResolvedAt = 0
+	m.ClosedAt = 0
+	m.Outcome = ""
+}
+
+// ResponseSeconds はメンションから応答までの秒数を返します
+// 応答で監視を終了していない場合（監視中・期限切れ・中止）は false を返します
+func (m Mention) ResponseSeconds() (int64, bool) {
+	if !m.Outcome.IsResponse() || m.ClosedAt == 0 {
+		return 0, false
+	}
+	return max(m.ClosedAt-m.CreatedAt, 0), true
+}
+
+// RecordStep は step 番目のエスカレーションステップ（action）の実行を記録します
+// 最初のステップ・最初のエスカレーションの実行日時は一度だけ記録します
+// notified が false（送信先がない・確認中などで通知を送らなかった）の場合は、ステップを進めるだけで実行日時は記録しません
+func (m *Mention) RecordStep(step int, action EscalationAction, notified bool, doneAt int64) {
+	m.Step = step + 1
+	if !notified {
+		return
+	}
+	if m.RemindedAt == 0 {
+		m.RemindedAt = doneAt
+	}
+	if action.IsEscalation() && m.EscalatedAt == 0 {
+		m.EscalatedAt = doneAt
+	}
+}
@@ -0,0 +1,149 @@
+package domain
+
+import "testing"
+
+func TestMentionRecordStep(t *testing.T) {
+	tests := []struct {
+		name            string
+		before          Mention
+		step            int
+		action          EscalationAction
+		notified        bool
+		wantStep        int
+		wantRemindedAt  int64
+		wantEscalatedAt int64
+	}{
+		{
+			name:           "最初のリマインドで実行日時を記録",
+			step:           0,
+			action:         EscalationActionThreadReminder,
+			notified:       true,
+			wantStep:       1,
+			wantRemindedAt: 100,
+		},
+		{
+			name:            "最初のエスカレーションで実行日時を記録",
+			before:          Mention{Step: 1, RemindedAt: 50},
+			step:            1,
+			action:          EscalationActionDMManager,
+			notified:        true,
+			wantStep:        2,
+			wantRemindedAt:  50,
+			wantEscalatedAt: 100,
+		},
+		{
+			name:            "チャンネル投稿もエスカレーション",
+			before:          Mention{Step: 1, RemindedAt: 50},
+			step:            1,
+			action:          EscalationActionChannelPost,
+			notified:        true,
+			wantStep:        2,
+			wantRemindedAt:  50,
+			wantEscalatedAt: 100,
+		},
+		{
+			name:           "上長DMを送らなかった場合はエスカレーション日時を記録しない",
+			before:         Mention{Step: 2, RemindedAt: 50},
+			step:           2,
+			action:         EscalationActionDMManager,
+			notified:       false,
+			wantStep:       3,
+			wantRemindedAt: 50,
+		},
+		{
+			name:     "通知を送らなかった最初のステップはリマインド日時も記録しない",
+			step:     0,
+			action:   EscalationActionDMManager,
+			notified: false,
+			wantStep: 1,
+		},
+		{
+			name:            "実行日時は最初の1回だけ記録",
+			before:          Mention{Step: 3, RemindedAt: 50, EscalatedAt: 80},
+			step:            3,
+			action:          EscalationActionChannelPost,
+			notified:        true,
+			wantStep:        4,
+			wantRemindedAt:  50,
+			wantEscalatedAt: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.before
+			m.RecordStep(tt.step, tt.action, tt.notified, 100)
+
+			if m.Step != tt.wantStep || m.RemindedAt != tt.wantRemindedAt || m.EscalatedAt != tt.wantEscalatedAt {
+				t.Errorf("RecordStep() = step=%d reminded_at=%d escalated_at=%d, want step=%d reminded_at=%d escalated_at=%d",
+					m.Step, m.RemindedAt, m.EscalatedAt, tt.wantStep, tt.wantRemindedAt, tt.wantEscalatedAt)
+			}
+		})
+	}
+}
+
+func TestMentionClose(t *testing.T) {
+	reminded := Mention{CreatedAt: 1000, RemindedAt: 1600}
+	escalated := Mention{CreatedAt: 1000, RemindedAt: 1600, EscalatedAt: 2800}
+
+	tests := []struct {
+		name         string
+		m            Mention
+		status       MentionStatus
+		closedAt     int64
+		wantOutcome  MentionOutcome
+		wantResponse bool
+	}{
+		{"リマインド前の返信", Mention{CreatedAt: 1000}, MentionStatusReplied, 1300, MentionOutcomeBeforeRemind, true},
+		{"リマインド後の返信", reminded, MentionStatusReplied, 2000, MentionOutcomeAfterRemind, true},
+		{"リマインド日時ちょうどの回答はリマインド後", reminded, MentionStatusAcknowledged, 1600, MentionOutcomeAfterRemind, true},
+		{"エスカレーション後の対応済み", escalated, MentionStatusDone, 3000, MentionOutcomeAfterEscalation, true},
+		{"エスカレーション前の担当外", escalated, MentionStatusDeclined, 2500, MentionOutcomeAfterRemind, true},
+		{"期限切れ", escalated, MentionStatusExpired, 9000, MentionOutcomeExpired, false},
+		{"中止は結果なし", escalated, MentionStatusCancelled, 9000, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.m
+			m.Close(tt.status, tt.closedAt)
+
+			if m.Status != tt.status || m.ClosedAt != tt.closedAt {
+				t.Errorf("Close() = status=%s closed_at=%d, want status=%s closed_at=%d", m.Status, m.ClosedAt, tt.status, tt.closedAt)
+			}
+			if m.Outcome != tt.wantOutcome {
+				t.Errorf("Close() outcome = %q, want %q", m.Outcome, tt.wantOutcome)
+			}
+
+			seconds, ok := m.ResponseSeconds()
+			if ok != tt.wantResponse {
+				t.Fatalf("ResponseSeconds() ok = %v, want %v", ok, tt.wantResponse)
+			}
+			if ok && seconds != tt.closedAt-m.CreatedAt {
+				t.Errorf("ResponseSeconds() = %d, want %d", seconds, tt.closedAt-m.CreatedAt)
+			}
+
+			// 返信は RepliedAt、それ以外は ResolvedAt に終了日時を記録する
+			if tt.status == MentionStatusReplied {
+				if m.RepliedAt != tt.closedAt || m.ResolvedAt != 0 {
+					t.Errorf("Close() replied_at=%d resolved_at=%d, want replied_at=%d", m.RepliedAt, m.ResolvedAt, tt.closedAt)
+				}
+			} else if m.ResolvedAt != tt.closedAt || m.RepliedAt != 0 {
+				t.Errorf("Close() replied_at=%d resolved_at=%d, want resolved_at=%d", m.RepliedAt, m.ResolvedAt, tt.closedAt)
+			}
+		})
+	}
+}
+
+func TestMentionReopen(t *testing.T) {
+	m := Mention{CreatedAt: 1000, RemindedAt: 1600}
+	m.Close(MentionStatusDone, 2000)
+	m.Reopen()
+
+	if !m.IsOpen() || m.ClosedAt != 0 || m.ResolvedAt != 0 || m.Outcome != "" {
+		t.Errorf("Reopen() = %+v, want open without closed_at/resolved_at/outcome", m)
+	}
+	if m.RemindedAt != 1600 {
+		t.Errorf("Reopen() reminded_at = %d, want 1600 (保持)", m.RemindedAt)
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// リアクションによる状態更新のデフォルト絵文字
+const (
+	// DefaultAckReaction は「確認中」を表すリアクション（上長DMを抑止し、リマインドは継続）
+	DefaultAckReaction = "eyes"
+
+	// DefaultDoneReaction は「対応済み」を表すリアクション（監視を終了）
+	DefaultDoneReaction = "white_check_mark"
+)
+
+// ReactionWorkflow はメンションを含むメッセージへのリアクションで監視状態を更新する設定です
+type ReactionWorkflow struct {
+	// AckReaction は「確認中」とみなす絵文字名（コロンなし）。空の場合は DefaultAckReaction
+	AckReaction string `firestore:"ack_reaction"`
+
+	// DoneReaction は「対応済み」とみなす絵文字名（コロンなし）。空の場合は DefaultDoneReaction
+	DoneReaction string `firestore:"done_reaction"`
+}
+
+// AckName は「確認中」とみなす絵文字名を返します
+func (w ReactionWorkflow) AckName() string {
+	if w.AckReaction != "" {
+		return w.AckReaction
+	}
+	return DefaultAckReaction
+}
+
+// DoneName は「対応済み」とみなす絵文字名を返します
+func (w ReactionWorkflow) DoneName() string {
+	if w.DoneReaction != "" {
+		return w.DoneReaction
+	}
+	return DefaultDoneReaction
+}
+
+// Validate はリアクション設定の妥当性を検証します
+func (w ReactionWorkflow) Validate() error {
+	for _, name := range []string{w.AckReaction, w.DoneReaction} {
+		if strings.ContainsAny(name, ": \t\n") {
+			return fmt.Errorf("%w: リアクション名の形式が不正です: %s", ErrInvalid, name)
+		}
+	}
+	if SameReaction(w.AckName(), w.DoneName()) {
+		return fmt.Errorf("%w: 確認中と対応済みに同じリアクションは指定できません", ErrInvalid)
+	}
+	return nil
+}
+
+// ValidateReactions は返信判定（reaction 方針）のリアクションが「確認中」「対応済み」と重複していないかを検証します
+// 同じリアクションは「対応済み」→「確認中」→「返信判定」の順に最初の用途だけに使われ、返信判定として扱われないためです
+func (t Tenant) ValidateReactions() error {
+	workflow := t.ReactionWorkflowOrDefault()
+	check := func(scope string, rule ReplyRule) error {
+		if rule.Policy != ReplyPolicyReaction {
+			return nil
+		}
+		name := rule.ReactionName()
+		if SameReaction(name, workflow.DoneName()) || SameReaction(name, workflow.AckName()) {
+			return fmt.Errorf("%w: %sの返信判定のリアクション :%s: は確認中・対応済みのリアクションと同じです", ErrInvalid, scope, name)
+		}
+		return nil
+	}
+
+	if t.ReplyRule != nil {
+		if err := check("ワークスペース全体", *t.ReplyRule); err != nil {
+			return err
+		}
+	}
+
+	channelIDs := make([]string, 0, len(t.ChannelReplyRules))
+	for channelID := range t.ChannelReplyRules {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Strings(channelIDs)
+	for _, channelID := range channelIDs {
+		if err := check(fmt.Sprintf("<#%s> ", channelID), t.ChannelReplyRules[channelID]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReactionWorkflowOrDefault はテナントのリアクション設定を返します（未設定ならデフォルト）
+func (t Tenant) ReactionWorkflowOrDefault() ReactionWorkflow {
+	if t.ReactionWorkflow != nil {
+		return *t.ReactionWorkflow
+	}
+	return ReactionWorkflow{}
+}
+
+// SameReaction はリアクション名 name が reaction と同じ絵文字かどうかを返します
+// スキントーン付きの絵文字（例: "+1::skin-tone-2"）も同じリアクションとして扱います
+func SameReaction(name, reaction string) bool {
+	return name == reaction || strings.HasPrefix(name, reaction+"::")
+}
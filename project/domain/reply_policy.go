@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReplyPolicy は対象者の返信完了（監視終了）の判定方法です
+type ReplyPolicy string
+
+const (
+	// ReplyPolicyMention はスレッド内でメンション送信者へ @メンション をつけて返信したら返信完了とします（デフォルト）
+	ReplyPolicyMention ReplyPolicy = "mention"
+
+	// ReplyPolicyThreadReply はスレッド内に返信があれば（メンションの有無を問わず）返信完了とします
+	ReplyPolicyThreadReply ReplyPolicy = "thread_reply"
+
+	// ReplyPolicyChannel はメンション以降にスレッド内またはチャンネルに投稿があれば返信完了とします
+	ReplyPolicyChannel ReplyPolicy = "channel"
+
+	// ReplyPolicyReaction はメンションを含むメッセージに指定のリアクションをつけたら返信完了とします
+	ReplyPolicyReaction ReplyPolicy = "reaction"
+)
+
+// DefaultReplyReaction は ReplyPolicyReaction で絵文字の指定がない場合に使うリアクションです
+// 「確認中」「対応済み」のデフォルト（DefaultAckReaction・DefaultDoneReaction）と重ならないようにします
+const DefaultReplyReaction = "ok"
+
+// ReplyRule は返信完了の判定ルールです
+type ReplyRule struct {
+	// Policy は判定方法
+	Policy ReplyPolicy `firestore:"policy"`
+
+	// Reaction は ReplyPolicyReaction で返信完了とみなす絵文字名（コロンなし）。空の場合は DefaultReplyReaction
+	Reaction string `firestore:"reaction"`
+}
+
+// DefaultReplyRule はテナント・チャンネルとも未設定の場合の判定ルールを返します
+func DefaultReplyRule() ReplyRule {
+	return ReplyRule{Policy: ReplyPolicyMention}
+}
+
+// ReactionName は ReplyPolicyReaction で返信完了とみなす絵文字名を返します
+func (r ReplyRule) ReactionName() string {
+	if r.Reaction != "" {
+		return r.Reaction
+	}
+	return DefaultReplyReaction
+}
+
+// Validate は判定ルールの妥当性を検証します
+func (r ReplyRule) Validate() error {
+	switch r.Policy {
+	case ReplyPolicyMention, ReplyPolicyThreadReply, ReplyPolicyChannel:
+		if r.Reaction != "" {
+			return fmt.Errorf("%w: リアクションは reaction 方針でのみ指定できます", ErrInvalid)
+		}
+	case ReplyPolicyReaction:
+		if strings.ContainsAny(r.Reaction, ": \t\n") {
+			return fmt.Errorf("%w: リアクション名の形式が不正です: %s", ErrInvalid, r.Reaction)
+		}
+	default:
+		return fmt.Errorf("%w: 不明な返信判定方針: %s", ErrInvalid, r.Policy)
+	}
+	return nil
+}
+
+// ReplyRuleFor はチャンネルに適用する返信判定ルールを返します
+// チャンネルごとのルール → ワークスペース全体のルール → デフォルト（mention）の順に解決します
+func (t Tenant) ReplyRuleFor(channelID string) ReplyRule {
+	if rule, ok := t.ChannelReplyRules[channelID]; ok {
+		return rule
+	}
+	if t.ReplyRule != nil {
+		return *t.ReplyRule
+	}
+	return DefaultReplyRule()
+}
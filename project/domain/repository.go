@@ -4,11 +4,26 @@ import (
 	"context"
 )
 
+// OpenMentionQuery は監視中のメンション一覧の取得条件です
+type OpenMentionQuery struct {
+	// ChannelID はチャンネルでの絞り込み（空の場合は全チャンネル）
+	ChannelID string
+
+	// MentionedUserID は対象者での絞り込み（空の場合は全員）
+	MentionedUserID string
+
+	// Offset は読み飛ばす件数
+	Offset int
+
+	// Limit は取得する最大件数
+	Limit int
+}
+
 // MentionRepository は返信監視対象メンションの永続化を担当します
 type MentionRepository interface {
 	// Save はメンション監視対象を保存します
-	// 既存レコードがある場合でも成功し、CreatedAt は初回作成時のみ設定される実装を推奨します。
-	// 同一キー(team:channel:ts:user)の既存レコードがある場合は上書きします
+	// 既存レコードがある場合でも成功し、CreatedAt は初回作成時のみ設定されます
+	// 同一キー(team:channel:ts:user)の既存レコードがある場合は Mention.MergeSaved でマージし、進行状況を保持します
 	// バリデーションエラー時は domain.ErrInvalid を返します
 	Save(ctx context.Context, m *Mention) error
 
@@ -17,15 +32,70 @@ type MentionRepository interface {
 	// 存在しない場合は domain.ErrNotFound を返します
 	Find(ctx context.Context, teamID, channelID, messageTS, userID string) (*Mention, error)
 
-	// MarkReminded は10分後リマインド完了フラグを立てます
-	// すでにフラグが立っている場合は何もせずに成功を返します（冪等）
-	// 対象レコードが存在しない場合は domain.ErrNotFound を返します
-	MarkReminded(ctx context.Context, teamID, channelID, messageTS, userID string) error
+	// ListByMessage は指定メッセージに紐づくメンション監視対象を全て取得します
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListByMessage(ctx context.Context, teamID, channelID, messageTS string) ([]*Mention, error)
+
+	// ListByThread は threadTS を親とするスレッドに紐づくメンション監視対象を全て取得します
+	// 親メッセージ自体へのメンションと、スレッド内の返信で発生したメンションの両方を返します
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListByThread(ctx context.Context, teamID, channelID, threadTS string) ([]*Mention, error)
+
+	// ListOpenByMentionee は userID が返信を期待されている（対象者である）監視中のメンションを全て取得します
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListOpenByMentionee(ctx context.Context, teamID, userID string) ([]*Mention, error)
+
+	// ListOpenByMentioner は userID がメンションを送信し、返信を待っている監視中のメンションを全て取得します
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListOpenByMentioner(ctx context.Context, teamID, userID string) ([]*Mention, error)
+
+	// ListCreatedBetween は from 以上 to 未満（Unix秒）に作成されたメンションを状態を問わず全て取得します（応答時間の分析用）
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListCreatedBetween(ctx context.Context, teamID string, from, to int64) ([]*Mention, error)
+
+	// ListOpen はワークスペースの監視中のメンションを古い順（CreatedAt 昇順）に取得します
+	// q.Offset 件を読み飛ばし、最大 q.Limit 件を返します。q.ChannelID・q.MentionedUserID が空でなければ絞り込みます
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListOpen(ctx context.Context, teamID string, q OpenMentionQuery) ([]*Mention, error)
+
+	// MarkReplied は返信検知により監視を終了し、返信日時・終了日時・結果（応答までの段階）を記録します
+	// すでに監視終了している場合は何もせずに成功を返します（冪等）
+	// 対象レコードが存在しない場合は domain.ErrMentionNotFound を返します
+	MarkReplied(ctx context.Context, teamID, channelID, messageTS, userID string, repliedAt int64) error
+
+	// MarkStepDone は step 番目のエスカレーションステップ（action）を doneAt に完了したとして記録します
+	// notified が true の場合は、最初のステップ・最初のエスカレーション（上長DM・チャンネル投稿）の実行日時も記録します
+	// すでにそのステップ以降が完了している場合は何もせずに成功を返します（冪等）
+	// 対象レコードが存在しない場合は domain.ErrMentionNotFound を返します
+	MarkStepDone(ctx context.Context, teamID, channelID, messageTS, userID string, step int, action EscalationAction, notified bool, doneAt int64) error
+
+	// Resolve は対象者の回答（確認済み・担当外・対応済み）または期限切れにより監視を終了し、状態・回答日時・終了日時・結果を記録します
+	// すでに監視終了している場合は何もせずに成功を返します（冪等）
+	// 対象レコードが存在しない場合は domain.ErrMentionNotFound を返します
+	Resolve(ctx context.Context, teamID, channelID, messageTS, userID string, status MentionStatus, resolvedAt int64) error
+
+	// Snooze は until（Unix秒）までステップの実行を保留します
+	// すでに監視終了している場合は何もせずに成功を返します
+	// 対象レコードが存在しない場合は domain.ErrMentionNotFound を返します
+	Snooze(ctx context.Context, teamID, channelID, messageTS, userID string, until int64) error
+
+	// SetAcknowledged は「確認中」の日時を記録します。acknowledgedAt が0の場合は解除します
+	// すでに監視終了している場合は何もせずに成功を返します
+	// 対象レコードが存在しない場合は domain.ErrMentionNotFound を返します
+	SetAcknowledged(ctx context.Context, teamID, channelID, messageTS, userID string, acknowledgedAt int64) error
+
+	// Reopen は status で監視終了したメンションを監視中に戻し、終了日時・結果をクリアします（リアクションの取り消し用）
+	// 状態が status でない場合は何もせずに false を返します
+	// 対象レコードが存在しない場合は domain.ErrMentionNotFound を返します
+	Reopen(ctx context.Context, teamID, channelID, messageTS, userID string, status MentionStatus) (bool, error)
 
-	// MarkEscalated は30分後エスカレーション完了フラグを立てます
-	// すでにフラグが立っている場合は何もせずに成功を返します（冪等）
-	// 対象レコードが存在しない場合は domain.ErrNotFound を返します
-	MarkEscalated(ctx context.Context, teamID, channelID, messageTS, userID string) error
+	// CancelOpenByTeam は指定ワークスペースの監視中メンションを全て中止（cancelled）にします
+	// 中止した件数を返します。対象がない場合は 0 を返します（エラーにはしません）
+	CancelOpenByTeam(ctx context.Context, teamID string, cancelledAt int64) (int, error)
+
+	// DeleteClosedCreatedBefore は before（Unix秒）より前に作成され、監視を終了したメンションを最大 limit 件削除します
+	// 監視中のメンションは削除しません。削除した件数を返します（limit 未満なら対象は残っていません）
+	DeleteClosedCreatedBefore(ctx context.Context, teamID string, before int64, limit int) (int, error)
 }
 
 // TenantRepository はワークスペース設定の永続化を担当します
@@ -37,11 +107,78 @@ type TenantRepository interface {
 	// UpsertBotTokenSecret はBotトークンのシークレット名を保存します
 	// レコードが存在しない場合は新規作成し、ある場合は上書きします
 	// CreatedAtが未設定の場合は現在時刻で初期化されます
+	// 無効化されていたテナントは再有効化されます（DeactivatedAt を0に戻す）
 	// バリデーションエラー時は domain.ErrInvalid を返します
 	UpsertBotTokenSecret(ctx context.Context, teamID, secretName string) error
 
+	// ListActive は有効な（アンインストール・トークン失効されていない）ワークスペース設定を全て取得します
+	// 対象がない場合は空スライスを返します（エラーにはしません）
+	ListActive(ctx context.Context) ([]*Tenant, error)
+
+	// ClaimDigest は週次ダイジェストの送信権を取得し、送信日時 sentAt を記録します
+	// 前回の送信日時が notBefore 以降の場合は何もせずに false を返します（定期実行の重複・再試行で二重送信しないため）
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	ClaimDigest(ctx context.Context, teamID string, sentAt, notBefore int64) (bool, error)
+
+	// Deactivate はアンインストール・トークン失効によりテナントを無効化します
+	// すでに無効化されている場合は無効化日時を更新しません（冪等）
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	Deactivate(ctx context.Context, teamID string, deactivatedAt int64) error
+
 	// SetManager は上長のSlackユーザーIDを設定します
 	// managerUserIDがnilの場合は上長設定を解除します
 	// レコードが存在しない場合は domain.ErrNotFound を返します
 	SetManager(ctx context.Context, teamID string, managerUserID *string) error
+
+	// SetUserManager はメンバーごとの上長を設定します
+	// managerUserIDがnilの場合はそのメンバーの設定を解除します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetUserManager(ctx context.Context, teamID, userID string, managerUserID *string) error
+
+	// SetChannelManager はチャンネルごとの上長を設定します
+	// managerUserIDがnilの場合はそのチャンネルの設定を解除します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetChannelManager(ctx context.Context, teamID, channelID string, managerUserID *string) error
+
+	// SetEscalationPolicy はエスカレーション手順を設定します
+	// policyがnilの場合は設定を解除し、デフォルト手順に戻します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetEscalationPolicy(ctx context.Context, teamID string, policy *EscalationPolicy) error
+
+	// SetWorkingCalendar は稼働カレンダーを設定します
+	// calendarがnilの場合は設定を解除し、経過時間のみで期限を計算します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetWorkingCalendar(ctx context.Context, teamID string, calendar *WorkingCalendar) error
+
+	// SetReplyRule はワークスペース全体の返信判定ルールを設定します
+	// ruleがnilの場合は設定を解除し、メンション付き返信での判定に戻します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetReplyRule(ctx context.Context, teamID string, rule *ReplyRule) error
+
+	// SetChannelReplyRule はチャンネルごとの返信判定ルールを設定します
+	// ruleがnilの場合はそのチャンネルのルールを削除します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetChannelReplyRule(ctx context.Context, teamID, channelID string, rule *ReplyRule) error
+
+	// SetReactionWorkflow はリアクションによる状態更新の絵文字を設定します
+	// workflowがnilの場合は設定を解除し、デフォルトの絵文字に戻します
+	// レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetReactionWorkflow(ctx context.Context, teamID string, workflow *ReactionWorkflow) error
+
+	// SetRetentionDays はメンション記録の保持期間（日）を設定します
+	// days が0の場合は設定を解除し、デフォルトの保持期間に戻します
+	// 範囲外の場合は domain.ErrInvalid、レコードが存在しない場合は domain.ErrTenantNotRegistered を返します
+	SetRetentionDays(ctx context.Context, teamID string, days int) error
+}
+
+// EventRepository は受信した Slack イベントの処理記録を担当します
+// Slack は応答が遅いと同じイベントを再送するため、event_id で重複処理を防ぎます
+type EventRepository interface {
+	// ClaimEvent は event_id の処理権を取得し、expireAt（Unix秒）まで記録します
+	// 同じ event_id が記録済み（処理中または処理済み）の場合は何もせずに false を返します
+	ClaimEvent(ctx context.Context, teamID, eventID string, expireAt int64) (bool, error)
+
+	// ReleaseEvent は event_id の記録を削除し、Slack の再送で再び処理できるようにします
+	// 記録がない場合も成功します
+	ReleaseEvent(ctx context.Context, teamID, eventID string) error
 }
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// MinRetentionDays はワークスペースごとに設定できる保持期間の下限（日）です
+	// 監視中のメンションが期限切れ（デフォルト7日）になる前に削除されないようにします
+	MinRetentionDays = 7
+
+	// MaxRetentionDays はワークスペースごとに設定できる保持期間の上限（日）です
+	MaxRetentionDays = 3650
+)
+
+// ValidateRetentionDays は保持期間（日）が設定できる範囲かどうかを検証します
+func ValidateRetentionDays(days int) error {
+	if days < MinRetentionDays || days > MaxRetentionDays {
+		return fmt.Errorf("%w: 保持期間は%d日以上%d日以下で指定してください", ErrInvalid, MinRetentionDays, MaxRetentionDays)
+	}
+	return nil
+}
+
+// RetentionPeriod はメンション記録の保持期間（メンションからの期間）を返します
+// ワークスペースの設定がない場合は defaultPeriod を返します（0 の場合は削除しない）
+func (t Tenant) RetentionPeriod(defaultPeriod time.Duration) time.Duration {
+	if t.RetentionDays > 0 {
+		return time.Duration(t.RetentionDays) * 24 * time.Hour
+	}
+	return defaultPeriod
+}
+
+// ClosedMentionStatuses は監視を終了した状態の一覧を返します（保持期間を過ぎた記録の削除対象）
+func ClosedMentionStatuses() []MentionStatus {
+	return []MentionStatus{
+		MentionStatusReplied,
+		MentionStatusAcknowledged,
+		MentionStatusDeclined,
+		MentionStatusDone,
+		MentionStatusExpired,
+		MentionStatusCancelled,
+	}
+}
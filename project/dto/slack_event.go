@@ -26,6 +26,27 @@ type SlackEvent struct {
 
 	// app_mention イベント固有
 	BotProfile *SlackBotProfile `json:"bot_profile,omitempty"`
+
+	// tokens_revoked イベント固有
+	Tokens *SlackRevokedTokens `json:"tokens,omitempty"`
+
+	// reaction_added / reaction_removed イベント固有
+	Reaction string             `json:"reaction,omitempty"`  // 絵文字名（コロンなし）
+	Item     *SlackReactionItem `json:"item,omitempty"`      // リアクションされたアイテム
+	ItemUser string             `json:"item_user,omitempty"` // リアクションされたメッセージの投稿者
+}
+
+// SlackReactionItem はリアクションされたアイテム（メッセージ）を表します
+type SlackReactionItem struct {
+	Type    string `json:"type"`    // "message" / "file" など
+	Channel string `json:"channel"` // チャンネルID
+	Ts      string `json:"ts"`      // メッセージTS
+}
+
+// SlackRevokedTokens は tokens_revoked イベントで失効したトークンの持ち主です
+type SlackRevokedTokens struct {
+	OAuth []string `json:"oauth,omitempty"` // ユーザートークンのユーザーID
+	Bot   []string `json:"bot,omitempty"`   // Bot トークンの Bot ユーザーID
 }
 
 // SlackBotProfile は Bot ユーザー情報を表します
@@ -0,0 +1,58 @@
+package dto
+
+// リマインドメッセージに付けるインタラクティブ要素の action_id です
+const (
+	// ActionIDReminderAck は「確認しました」ボタン（以後のエスカレーションを停止）
+	ActionIDReminderAck = "reminder_ack"
+
+	// ActionIDReminderSnooze はスヌーズ期間の選択メニュー
+	ActionIDReminderSnooze = "reminder_snooze"
+
+	// ActionIDReminderDecline は「担当外です」ボタン（監視を終了し送信者に通知）
+	ActionIDReminderDecline = "reminder_decline"
+)
+
+// SlackInteractionPayload は Slack インタラクション（ボタン押下など）のペイロードです
+// application/x-www-form-urlencoded の payload フィールドに JSON で格納されて届きます
+type SlackInteractionPayload struct {
+	Type        string             `json:"type"` // "block_actions" など
+	Team        SlackTeam          `json:"team"`
+	User        SlackActionUser    `json:"user"`
+	Channel     SlackActionChannel `json:"channel"`
+	Actions     []SlackBlockAction `json:"actions"`
+	ResponseURL string             `json:"response_url"` // 元メッセージの更新・エフェメラル応答用
+	TriggerID   string             `json:"trigger_id"`
+}
+
+// SlackActionUser は操作したユーザーです
+type SlackActionUser struct {
+	ID   string `json:"id"`
+	Name string `json:"username"`
+}
+
+// SlackActionChannel は操作が行われたチャンネルです
+type SlackActionChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SlackBlockAction は操作されたブロック要素です
+type SlackBlockAction struct {
+	ActionID       string             `json:"action_id"`
+	BlockID        string             `json:"block_id"`
+	Type           string             `json:"type"`  // "button", "static_select" など
+	Value          string             `json:"value"` // ボタンの value
+	SelectedOption *SlackSelectOption `json:"selected_option,omitempty"`
+}
+
+// SlackSelectOption は選択メニューで選ばれた項目です
+type SlackSelectOption struct {
+	Value string `json:"value"`
+}
+
+// SlackActionResponse は response_url に送信する応答です
+type SlackActionResponse struct {
+	ResponseType    string `json:"response_type,omitempty"` // "ephemeral" or "in_channel"
+	Text            string `json:"text"`
+	ReplaceOriginal bool   `json:"replace_original"`
+}
@@ -2,17 +2,26 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"slack-bot/project/domain"
 	"slack-bot/project/dto"
 	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/service"
+
+	"github.com/slack-go/slack"
 )
 
 // CommandsHandler は Slack スラッシュコマンドを処理します
@@ -20,20 +29,29 @@ type CommandsHandler struct {
 	signingSecret    string
 	tenantRepository domain.TenantRepository
 	slackPort        SlackPort // ユーザー情報取得用
+	reminderService  service.ReminderService
+	analyticsService service.AnalyticsService
+	defaultRetention time.Duration // ワークスペースの設定がない場合の保持期間（/_get_retention の表示用）
 }
 
 // SlackPort は Slack API 操作の最小インターフェース
 type SlackPort interface {
 	// GetUserID はユーザーメールアドレスまたはユーザー名から ID を取得
 	GetUserID(ctx context.Context, teamID, userNameOrEmail string) (string, error)
+
+	// IsWorkspaceAdmin はユーザーがワークスペースの管理者またはオーナーかどうかを返します
+	IsWorkspaceAdmin(ctx context.Context, teamID, userID string) (bool, error)
 }
 
 // NewCommandsHandler はコマンドハンドラーを作成します
-func NewCommandsHandler(signingSecret string, tenantRepository domain.TenantRepository, slackPort SlackPort) *CommandsHandler {
+func NewCommandsHandler(signingSecret string, tenantRepository domain.TenantRepository, slackPort SlackPort, reminderService service.ReminderService, analyticsService service.AnalyticsService, defaultRetention time.Duration) *CommandsHandler {
 	return &CommandsHandler{
 		signingSecret:    signingSecret,
 		tenantRepository: tenantRepository,
 		slackPort:        slackPort,
+		reminderService:  reminderService,
+		analyticsService: analyticsService,
+		defaultRetention: defaultRetention,
 	}
 }
 
@@ -69,11 +87,25 @@ func (h *CommandsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	cmd.Text = values.Get("text")
 
 	// コマンド実行
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(context.WithoutCancel(r.Context()), ""), 10*time.Second)
 	defer cancel()
 
 	w.Header().Set("Content-Type", "application/json")
 
+	// 設定を変更するコマンドはワークスペースの管理者・オーナーのみ実行できる
+	if settingsCommands[cmd.Command] {
+		if err := h.authorizeSettings(ctx, cmd.TeamID, cmd.UserID); err != nil {
+			if errors.Is(err, domain.ErrInsufficientPermission) {
+				slog.InfoContext(ctx, "権限のないユーザーによる設定変更を拒否しました", "team_id", cmd.TeamID, "user_id", cmd.UserID, "command", cmd.Command)
+				writeEphemeral(w, http.StatusForbidden, "このコマンドはワークスペースの管理者・オーナーのみ実行できます")
+				return
+			}
+			slog.ErrorContext(ctx, "権限確認エラー", "team_id", cmd.TeamID, "user_id", cmd.UserID, "command", cmd.Command, "error", err)
+			writeEphemeral(w, http.StatusInternalServerError, "権限の確認に失敗しました")
+			return
+		}
+	}
+
 	switch cmd.Command {
 	case "/_set_manager":
 		h.handleSetManager(w, ctx, cmd)
@@ -81,87 +113,1352 @@ func (h *CommandsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleUnsetManager(w, ctx, cmd)
 	case "/_get_manager":
 		h.handleGetManager(w, ctx, cmd)
+	case "/_set_channel_manager":
+		h.handleSetChannelManager(w, ctx, cmd)
+	case "/_unset_channel_manager":
+		h.handleUnsetChannelManager(w, ctx, cmd)
+	case "/_set_escalation":
+		h.handleSetEscalation(w, ctx, cmd)
+	case "/_unset_escalation":
+		h.handleUnsetEscalation(w, ctx, cmd)
+	case "/_get_escalation":
+		h.handleGetEscalation(w, ctx, cmd)
+	case "/_set_calendar":
+		h.handleSetCalendar(w, ctx, cmd)
+	case "/_unset_calendar":
+		h.handleUnsetCalendar(w, ctx, cmd)
+	case "/_get_calendar":
+		h.handleGetCalendar(w, ctx, cmd)
+	case "/_set_reply_policy":
+		h.handleSetReplyPolicy(w, ctx, cmd)
+	case "/_unset_reply_policy":
+		h.handleUnsetReplyPolicy(w, ctx, cmd)
+	case "/_get_reply_policy":
+		h.handleGetReplyPolicy(w, ctx, cmd)
+	case "/_set_reactions":
+		h.handleSetReactions(w, ctx, cmd)
+	case "/_unset_reactions":
+		h.handleUnsetReactions(w, ctx, cmd)
+	case "/_get_reactions":
+		h.handleGetReactions(w, ctx, cmd)
+	case "/_set_retention":
+		h.handleSetRetention(w, ctx, cmd)
+	case "/_unset_retention":
+		h.handleUnsetRetention(w, ctx, cmd)
+	case "/_get_retention":
+		h.handleGetRetention(w, ctx, cmd)
+	case "/_mine":
+		h.handleMine(w, ctx, cmd)
+	case "/_pending":
+		h.handlePending(w, ctx, cmd)
+	case "/_stats":
+		h.handleStats(w, ctx, cmd)
 	default:
 		w.WriteHeader(http.StatusBadRequest)
 		fmt.Fprintf(w, `{"response_type":"ephemeral","text":"不明なコマンド: %s"}`, cmd.Command)
 	}
 }
 
+// settingsCommands はワークスペースの設定を変更するコマンドです（管理者・オーナーのみ実行可能）
+var settingsCommands = map[string]bool{
+	"/_set_manager":           true,
+	"/_unset_manager":         true,
+	"/_set_channel_manager":   true,
+	"/_unset_channel_manager": true,
+	"/_set_escalation":        true,
+	"/_unset_escalation":      true,
+	"/_set_calendar":          true,
+	"/_unset_calendar":        true,
+	"/_set_reply_policy":      true,
+	"/_unset_reply_policy":    true,
+	"/_set_reactions":         true,
+	"/_unset_reactions":       true,
+	"/_set_retention":         true,
+	"/_unset_retention":       true,
+}
+
+// authorizeSettings は設定を変更できるユーザー（ワークスペースの管理者・オーナー）か確認します
+// 上長の設定はワークスペース全体に影響するため、上長に設定されているだけのユーザーには許可しません
+// 権限がない場合は domain.ErrInsufficientPermission を返します
+func (h *CommandsHandler) authorizeSettings(ctx context.Context, teamID, userID string) error {
+	admin, err := h.slackPort.IsWorkspaceAdmin(ctx, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("管理者確認失敗: %w", err)
+	}
+	if !admin {
+		return fmt.Errorf("%w: 管理者・オーナーのみ設定を変更できます (user=%s)", domain.ErrInsufficientPermission, userID)
+	}
+	return nil
+}
+
+// managerUsage は上長設定コマンドの使用方法です
+const managerUsage = "使用方法: /_set_manager @上長（ワークスペース全体） または /_set_manager @メンバー @上長（メンバーごと）"
+
+// channelManagerUsage はチャンネル上長設定コマンドの使用方法です
+const channelManagerUsage = "使用方法: /_set_channel_manager #チャンネル @上長（チャンネル省略時は実行したチャンネル）"
+
 // handleSetManager は /_set_manager コマンドを処理
+// 引数が1つならワークスペース全体の上長、2つならメンバーごとの上長を設定します
 func (h *CommandsHandler) handleSetManager(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
-	log.Printf("/_set_manager called: TeamID=%s, Text=%s", cmd.TeamID, cmd.Text)
+	slog.DebugContext(ctx, "/_set_manager 実行", "team_id", cmd.TeamID, "user_id", cmd.UserID)
 
-	if cmd.Text == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, `{"response_type":"ephemeral","text":"使用方法: /_set_manager @ユーザー名"}`)
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 || len(args) > 2 {
+		writeEphemeral(w, http.StatusBadRequest, managerUsage)
 		return
 	}
 
-	// @ユーザー名 から ID を抽出
-	userRef := strings.TrimPrefix(strings.TrimSpace(cmd.Text), "@")
-	log.Printf("GetUserID: TeamID=%s, userRef=%s", cmd.TeamID, userRef)
-
-	userID, err := h.slackPort.GetUserID(ctx, cmd.TeamID, userRef)
+	managerID, err := h.resolveUserRef(ctx, cmd.TeamID, args[len(args)-1])
 	if err != nil {
-		log.Printf("GetUserID error: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, `{"response_type":"ephemeral","text":"ユーザー検索失敗: %v"}`, err)
+		slog.ErrorContext(ctx, "ユーザー検索失敗", "team_id", cmd.TeamID, "error", err)
+		writeEphemeral(w, http.StatusInternalServerError, fmt.Sprintf("ユーザー検索失敗: %v", err))
 		return
 	}
 
-	log.Printf("Found userID: %s", userID)
+	// メンバーごとの上長
+	if len(args) == 2 {
+		memberID, err := h.resolveUserRef(ctx, cmd.TeamID, args[0])
+		if err != nil {
+			slog.ErrorContext(ctx, "ユーザー検索失敗", "team_id", cmd.TeamID, "error", err)
+			writeEphemeral(w, http.StatusInternalServerError, fmt.Sprintf("ユーザー検索失敗: %v", err))
+			return
+		}
 
-	// Tenant 更新
-	if err := h.tenantRepository.SetManager(ctx, cmd.TeamID, &userID); err != nil {
-		log.Printf("SetManager error: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		if err == domain.ErrTenantNotRegistered {
-			fmt.Fprint(w, `{"response_type":"ephemeral","text":"このワークスペースは登録されていません"}`)
-		} else {
-			fmt.Fprintf(w, `{"response_type":"ephemeral","text":"上長設定に失敗しました: %v"}`, err)
+		if err := h.tenantRepository.SetUserManager(ctx, cmd.TeamID, memberID, &managerID); err != nil {
+			slog.ErrorContext(ctx, "SetUserManager 失敗", "team_id", cmd.TeamID, "error", err)
+			writeTenantUpdateError(w, "上長設定に失敗しました", err)
+			return
 		}
+
+		writeEphemeral(w, http.StatusOK, fmt.Sprintf("<@%s> さんの上長を <@%s> に設定しました", memberID, managerID))
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"response_type":"ephemeral","text":"上長を <@%s> に設定しました"}`, userID)
+	// ワークスペース全体の上長
+	if err := h.tenantRepository.SetManager(ctx, cmd.TeamID, &managerID); err != nil {
+		slog.ErrorContext(ctx, "SetManager 失敗", "team_id", cmd.TeamID, "error", err)
+		writeTenantUpdateError(w, "上長設定に失敗しました", err)
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, fmt.Sprintf("上長を <@%s> に設定しました", managerID))
 }
 
 // handleUnsetManager は /_unset_manager コマンドを処理
+// 引数なしならワークスペース全体の上長、@メンバー指定ならそのメンバーの上長設定を削除します
 func (h *CommandsHandler) handleUnsetManager(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
-	// Tenant から上長を削除
-	if err := h.tenantRepository.SetManager(ctx, cmd.TeamID, nil); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, `{"response_type":"ephemeral","text":"上長削除失敗"}`)
-		return
-	}
+	args := strings.Fields(cmd.Text)
+	switch len(args) {
+	case 0:
+		// Tenant から上長を削除
+		if err := h.tenantRepository.SetManager(ctx, cmd.TeamID, nil); err != nil {
+			writeEphemeral(w, http.StatusInternalServerError, "上長削除失敗")
+			return
+		}
+		writeEphemeral(w, http.StatusOK, "上長設定を削除しました")
+
+	case 1:
+		memberID, err := h.resolveUserRef(ctx, cmd.TeamID, args[0])
+		if err != nil {
+			writeEphemeral(w, http.StatusInternalServerError, fmt.Sprintf("ユーザー検索失敗: %v", err))
+			return
+		}
+		if err := h.tenantRepository.SetUserManager(ctx, cmd.TeamID, memberID, nil); err != nil {
+			writeEphemeral(w, http.StatusInternalServerError, "上長削除失敗")
+			return
+		}
+		writeEphemeral(w, http.StatusOK, fmt.Sprintf("<@%s> さんの上長設定を削除しました", memberID))
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprint(w, `{"response_type":"ephemeral","text":"上長設定を削除しました"}`)
+	default:
+		writeEphemeral(w, http.StatusBadRequest, "使用方法: /_unset_manager または /_unset_manager @メンバー")
+	}
 }
 
 // handleGetManager は /_get_manager コマンドを処理
+// ワークスペース全体・メンバーごと・チャンネルごとの上長設定を一覧表示します
 func (h *CommandsHandler) handleGetManager(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
 	tenant, err := h.tenantRepository.Get(ctx, cmd.TeamID)
 	if err != nil {
-		if err == domain.ErrTenantNotRegistered {
-			w.WriteHeader(http.StatusOK)
-			fmt.Fprint(w, `{"response_type":"ephemeral","text":"このワークスペースは登録されていません"}`)
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusOK, "このワークスペースは登録されていません")
 			return
 		}
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, `{"response_type":"ephemeral","text":"テナント取得に失敗しました"}`)
+		writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+		return
+	}
+
+	if tenant.ManagerUserID == nil && len(tenant.UserManagers) == 0 && len(tenant.ChannelManagers) == 0 {
+		writeEphemeral(w, http.StatusOK, "上長が設定されていません")
+		return
+	}
+
+	var lines []string
+	if tenant.ManagerUserID != nil {
+		lines = append(lines, fmt.Sprintf("現在の上長: <@%s>", *tenant.ManagerUserID))
+	} else {
+		lines = append(lines, "現在の上長: 未設定")
+	}
+	if len(tenant.UserManagers) > 0 {
+		lines = append(lines, "メンバーごとの上長:")
+		for _, memberID := range sortedKeys(tenant.UserManagers) {
+			lines = append(lines, fmt.Sprintf("• <@%s> → <@%s>", memberID, tenant.UserManagers[memberID]))
+		}
+	}
+	if len(tenant.ChannelManagers) > 0 {
+		lines = append(lines, "チャンネルごとの上長:")
+		for _, channelID := range sortedKeys(tenant.ChannelManagers) {
+			lines = append(lines, fmt.Sprintf("• <#%s> → <@%s>", channelID, tenant.ChannelManagers[channelID]))
+		}
+	}
+
+	writeEphemeral(w, http.StatusOK, strings.Join(lines, "\n"))
+}
+
+// handleSetChannelManager は /_set_channel_manager コマンドを処理
+func (h *CommandsHandler) handleSetChannelManager(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	args := strings.Fields(cmd.Text)
+	if len(args) == 0 || len(args) > 2 {
+		writeEphemeral(w, http.StatusBadRequest, channelManagerUsage)
+		return
+	}
+
+	channelID := cmd.ChannelID
+	if len(args) == 2 {
+		var err error
+		if channelID, err = parseChannelRef(args[0]); err != nil {
+			writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, channelManagerUsage))
+			return
+		}
+	}
+
+	managerID, err := h.resolveUserRef(ctx, cmd.TeamID, args[len(args)-1])
+	if err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, fmt.Sprintf("ユーザー検索失敗: %v", err))
+		return
+	}
+
+	if err := h.tenantRepository.SetChannelManager(ctx, cmd.TeamID, channelID, &managerID); err != nil {
+		slog.ErrorContext(ctx, "SetChannelManager 失敗", "team_id", cmd.TeamID, "error", err)
+		writeTenantUpdateError(w, "チャンネル上長の設定に失敗しました", err)
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, fmt.Sprintf("<#%s> の上長を <@%s> に設定しました", channelID, managerID))
+}
+
+// handleUnsetChannelManager は /_unset_channel_manager コマンドを処理
+func (h *CommandsHandler) handleUnsetChannelManager(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	args := strings.Fields(cmd.Text)
+	if len(args) > 1 {
+		writeEphemeral(w, http.StatusBadRequest, "使用方法: /_unset_channel_manager #チャンネル（省略時は実行したチャンネル）")
+		return
+	}
+
+	channelID := cmd.ChannelID
+	if len(args) == 1 {
+		var err error
+		if channelID, err = parseChannelRef(args[0]); err != nil {
+			writeEphemeral(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.tenantRepository.SetChannelManager(ctx, cmd.TeamID, channelID, nil); err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, "チャンネル上長の削除に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, fmt.Sprintf("<#%s> の上長設定を削除しました", channelID))
+}
+
+// resolveUserRef はコマンド引数のユーザー指定をユーザーIDに変換します
+// エスケープ済みの "<@U123|name>" 形式はそのまま ID を取り出し、それ以外は名前として検索します
+func (h *CommandsHandler) resolveUserRef(ctx context.Context, teamID, ref string) (string, error) {
+	if inner, ok := strings.CutPrefix(ref, "<@"); ok {
+		id, _, _ := strings.Cut(strings.TrimSuffix(inner, ">"), "|")
+		if id != "" {
+			return id, nil
+		}
+	}
+
+	userRef := strings.TrimPrefix(ref, "@")
+	slog.DebugContext(ctx, "ユーザー検索", "team_id", teamID, "user_ref", userRef)
+
+	return h.slackPort.GetUserID(ctx, teamID, userRef)
+}
+
+// parseChannelRef はコマンド引数のチャンネル指定をチャンネルIDに変換します
+// エスケープ済みの "<#C123|name>" 形式またはチャンネルIDを受け付けます
+func parseChannelRef(ref string) (string, error) {
+	if inner, ok := strings.CutPrefix(ref, "<#"); ok {
+		id, _, _ := strings.Cut(strings.TrimSuffix(inner, ">"), "|")
+		if id != "" {
+			return id, nil
+		}
+	}
+
+	if isSlackID(ref) && (ref[0] == 'C' || ref[0] == 'G') {
+		return ref, nil
+	}
+
+	return "", fmt.Errorf("チャンネルを特定できません: %s（チャンネル名の入力補完で選んだ #チャンネル、またはチャンネルID で指定してください。補完を使わずに入力したチャンネル名は指定できません）", ref)
+}
+
+// isSlackID は Slack の ID 形式（英大文字と数字のみ）かどうかを返します
+func isSlackID(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedKeys はマップのキーを昇順で返します（表示順を安定させるため）
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeTenantUpdateError はテナント更新失敗時の応答を書き込みます
+func writeTenantUpdateError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, domain.ErrTenantNotRegistered) {
+		writeEphemeral(w, http.StatusInternalServerError, "このワークスペースは登録されていません")
+		return
+	}
+	writeEphemeral(w, http.StatusInternalServerError, fmt.Sprintf("%s: %v", message, err))
+}
+
+// escalationUsage は /_set_escalation の使用方法です
+const escalationUsage = "使用方法: /_set_escalation 10m:thread 1h:dm 4h:manager 1d:channel\n" +
+	"（遅延はメンションからの経過時間。アクション: thread=スレッドリマインド, dm=本人DM, manager=上長DM, channel=チャンネル投稿）"
+
+// handleSetEscalation は /_set_escalation コマンドを処理
+func (h *CommandsHandler) handleSetEscalation(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	policy, err := parseEscalationSpec(cmd.Text)
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, escalationUsage))
+		return
+	}
+
+	if err := h.tenantRepository.SetEscalationPolicy(ctx, cmd.TeamID, policy); err != nil {
+		slog.ErrorContext(ctx, "SetEscalationPolicy 失敗", "team_id", cmd.TeamID, "error", err)
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusInternalServerError, "このワークスペースは登録されていません")
+			return
+		}
+		writeEphemeral(w, http.StatusInternalServerError, fmt.Sprintf("エスカレーション手順の設定に失敗しました: %v", err))
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "エスカレーション手順を設定しました\n"+formatEscalationPolicy(*policy))
+}
+
+// handleUnsetEscalation は /_unset_escalation コマンドを処理
+func (h *CommandsHandler) handleUnsetEscalation(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	if err := h.tenantRepository.SetEscalationPolicy(ctx, cmd.TeamID, nil); err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, "エスカレーション手順の削除に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "エスカレーション手順を削除しました（デフォルト手順に戻ります）")
+}
+
+// handleGetEscalation は /_get_escalation コマンドを処理
+func (h *CommandsHandler) handleGetEscalation(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	tenant, err := h.tenantRepository.Get(ctx, cmd.TeamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusOK, "このワークスペースは登録されていません")
+			return
+		}
+		writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+		return
+	}
+
+	if tenant.EscalationPolicy == nil || len(tenant.EscalationPolicy.Steps) == 0 {
+		writeEphemeral(w, http.StatusOK, "エスカレーション手順は未設定です（デフォルト: REMIND_AFTER 後にスレッドリマインド、ESCALATE_AFTER 後に再リマインドと上長DM）")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "現在のエスカレーション手順:\n"+formatEscalationPolicy(*tenant.EscalationPolicy))
+}
+
+// escalationActionAliases はコマンド入力で使えるアクションの短縮名です
+var escalationActionAliases = map[string]domain.EscalationAction{
+	"thread":  domain.EscalationActionThreadReminder,
+	"dm":      domain.EscalationActionDMMentionee,
+	"manager": domain.EscalationActionDMManager,
+	"channel": domain.EscalationActionChannelPost,
+}
+
+// escalationActionLabels はアクションの表示名です
+var escalationActionLabels = map[domain.EscalationAction]string{
+	domain.EscalationActionThreadReminder: "スレッドリマインド",
+	domain.EscalationActionDMMentionee:    "本人DM",
+	domain.EscalationActionDMManager:      "上長DM",
+	domain.EscalationActionChannelPost:    "チャンネル投稿",
+}
+
+// parseEscalationSpec は "10m:thread 1h:dm" 形式の文字列をエスカレーション手順に変換します
+// 区切りは空白またはカンマ。遅延は Go の duration 形式に加えて "1d"（日数）を受け付けます
+func parseEscalationSpec(text string) (*domain.EscalationPolicy, error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\n' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("ステップが指定されていません")
+	}
+
+	policy := &domain.EscalationPolicy{}
+	for _, field := range fields {
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("ステップの形式が不正です: %s", field)
+		}
+
+		delay, err := parseDelay(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("遅延時間の形式が不正です: %s", parts[0])
+		}
+
+		action, ok := escalationActionAliases[parts[1]]
+		if !ok {
+			action = domain.EscalationAction(parts[1])
+		}
+
+		policy.Steps = append(policy.Steps, domain.EscalationStep{
+			DelaySeconds: int64(delay / time.Second),
+			Action:       action,
+		})
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// parseDelay は duration 文字列を解析します（"1d" のような日数指定にも対応）
+func parseDelay(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// formatEscalationPolicy はエスカレーション手順を表示用の文字列に変換します
+func formatEscalationPolicy(policy domain.EscalationPolicy) string {
+	lines := make([]string, 0, len(policy.Steps))
+	for i, step := range policy.Steps {
+		label, ok := escalationActionLabels[step.Action]
+		if !ok {
+			label = string(step.Action)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s 後: %s", i+1, step.Delay(), label))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// calendarUsage は /_set_calendar の使用方法です
+const calendarUsage = "使用方法: /_set_calendar tz=Asia/Tokyo days=mon-fri hours=09:00-18:00 jp_holidays=on holidays=2026-12-29,2026-12-30\n" +
+	"（省略した項目はデフォルト: Asia/Tokyo・平日・9:00〜18:00・祝日休み。引数なしでデフォルトを設定）"
+
+// handleSetCalendar は /_set_calendar コマンドを処理
+func (h *CommandsHandler) handleSetCalendar(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	calendar, err := parseCalendarSpec(cmd.Text)
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, calendarUsage))
+		return
+	}
+
+	if err := h.tenantRepository.SetWorkingCalendar(ctx, cmd.TeamID, calendar); err != nil {
+		slog.ErrorContext(ctx, "SetWorkingCalendar 失敗", "team_id", cmd.TeamID, "error", err)
+		writeTenantUpdateError(w, "稼働カレンダーの設定に失敗しました", err)
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "稼働カレンダーを設定しました\n"+formatCalendar(calendar.Normalize()))
+}
+
+// handleUnsetCalendar は /_unset_calendar コマンドを処理
+func (h *CommandsHandler) handleUnsetCalendar(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	if err := h.tenantRepository.SetWorkingCalendar(ctx, cmd.TeamID, nil); err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, "稼働カレンダーの削除に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "稼働カレンダーを削除しました（時間帯を問わず経過時間で通知します）")
+}
+
+// handleGetCalendar は /_get_calendar コマンドを処理
+func (h *CommandsHandler) handleGetCalendar(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	tenant, err := h.tenantRepository.Get(ctx, cmd.TeamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusOK, "このワークスペースは登録されていません")
+			return
+		}
+		writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+		return
+	}
+
+	if tenant.WorkingCalendar == nil {
+		writeEphemeral(w, http.StatusOK, "稼働カレンダーは未設定です（時間帯を問わず経過時間で通知します）")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "現在の稼働カレンダー:\n"+formatCalendar(*tenant.WorkingCalendar))
+}
+
+// weekdayNames はコマンド入力で使える曜日名です
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// weekdayLabels は曜日の表示名です
+var weekdayLabels = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// parseCalendarSpec は "tz=Asia/Tokyo days=mon-fri hours=09:00-18:00" 形式の文字列を稼働カレンダーに変換します
+// 指定のない項目はデフォルトカレンダーの値を使用します
+func parseCalendarSpec(text string) (*domain.WorkingCalendar, error) {
+	calendar := domain.DefaultWorkingCalendar()
+
+	for _, field := range strings.Fields(text) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("項目の形式が不正です: %s", field)
+		}
+
+		switch strings.ToLower(key) {
+		case "tz":
+			calendar.TimeZone = value
+		case "days":
+			days, err := parseWeekdays(value)
+			if err != nil {
+				return nil, err
+			}
+			calendar.WorkingDays = days
+		case "hours":
+			start, end, err := parseHours(value)
+			if err != nil {
+				return nil, err
+			}
+			calendar.StartMinute, calendar.EndMinute = start, end
+		case "jp_holidays":
+			switch strings.ToLower(value) {
+			case "on", "true", "yes":
+				calendar.JapaneseHolidays = true
+			case "off", "false", "no":
+				calendar.JapaneseHolidays = false
+			default:
+				return nil, fmt.Errorf("jp_holidays は on / off で指定してください: %s", value)
+			}
+		case "holidays":
+			calendar.Holidays = strings.Split(value, ",")
+		default:
+			return nil, fmt.Errorf("不明な項目です: %s", key)
+		}
+	}
+
+	if err := calendar.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &calendar, nil
+}
+
+// parseWeekdays は "mon-fri" や "mon,wed,fri" 形式の曜日指定を解析します（"fri-mon" のような週またぎも可）
+func parseWeekdays(value string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(strings.ToLower(value), ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok := weekdayNames[from]
+		if !ok {
+			return nil, fmt.Errorf("曜日の形式が不正です: %s", part)
+		}
+		if !isRange {
+			days = append(days, int(start))
+			continue
+		}
+		end, ok := weekdayNames[to]
+		if !ok {
+			return nil, fmt.Errorf("曜日の形式が不正です: %s", part)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days = append(days, int(d))
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseHours は "09:00-18:00" 形式の稼働時間を解析し、0時からの分数で返します
+func parseHours(value string) (int, int, error) {
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("稼働時間の形式が不正です: %s", value)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseClock は "9:30" 形式の時刻を 0時からの分数に変換します（"24:00" も可）
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("時刻の形式が不正です: %s", s)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("時刻の形式が不正です: %s", s)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("時刻の形式が不正です: %s", s)
+	}
+	return h*60 + m, nil
+}
+
+// formatCalendar は稼働カレンダーを表示用の文字列に変換します
+func formatCalendar(calendar domain.WorkingCalendar) string {
+	days := make([]string, 0, len(calendar.WorkingDays))
+	for _, d := range calendar.WorkingDays {
+		if d >= 0 && d < len(weekdayLabels) {
+			days = append(days, weekdayLabels[d])
+		}
+	}
+
+	jpHolidays := "休み"
+	if !calendar.JapaneseHolidays {
+		jpHolidays = "稼働"
+	}
+
+	lines := []string{
+		"タイムゾーン: " + calendar.TimeZone,
+		"稼働曜日: " + strings.Join(days, "・"),
+		fmt.Sprintf("稼働時間: %02d:%02d〜%02d:%02d", calendar.StartMinute/60, calendar.StartMinute%60, calendar.EndMinute/60, calendar.EndMinute%60),
+		"日本の祝日: " + jpHolidays,
+	}
+	if len(calendar.Holidays) > 0 {
+		lines = append(lines, "個別の休日: "+strings.Join(calendar.Holidays, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replyPolicyUsage は /_set_reply_policy の使用方法です
+const replyPolicyUsage = "使用方法: /_set_reply_policy [#チャンネル] mention|thread|channel|reaction [:絵文字:]\n" +
+	"（mention=送信者へのメンション付きスレッド返信, thread=スレッド返信, channel=スレッドまたはチャンネルへの投稿, reaction=メッセージへのリアクション（既定 :ok:。確認中・対応済みと同じリアクションは指定不可）。" +
+	"チャンネル省略時はワークスペース全体）"
+
+// replyPolicyAliases はコマンド入力で使える返信判定方針の短縮名です
+var replyPolicyAliases = map[string]domain.ReplyPolicy{
+	"thread": domain.ReplyPolicyThreadReply,
+}
+
+// replyPolicyLabels は返信判定方針の表示名です
+var replyPolicyLabels = map[domain.ReplyPolicy]string{
+	domain.ReplyPolicyMention:     "送信者へのメンション付きスレッド返信",
+	domain.ReplyPolicyThreadReply: "スレッド返信（メンション不要）",
+	domain.ReplyPolicyChannel:     "スレッドまたはチャンネルへの投稿",
+	domain.ReplyPolicyReaction:    "メッセージへのリアクション",
+}
+
+// handleSetReplyPolicy は /_set_reply_policy コマンドを処理
+// 先頭に #チャンネル があればチャンネルごと、なければワークスペース全体の返信判定ルールを設定します
+func (h *CommandsHandler) handleSetReplyPolicy(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	args := strings.Fields(cmd.Text)
+
+	channelID := ""
+	if len(args) > 0 && strings.HasPrefix(args[0], "<#") {
+		var err error
+		if channelID, err = parseChannelRef(args[0]); err != nil {
+			writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, replyPolicyUsage))
+			return
+		}
+		args = args[1:]
+	}
+
+	rule, err := parseReplyRule(args)
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, replyPolicyUsage))
+		return
+	}
+
+	// 確認中・対応済みのリアクションと重なる場合は返信判定として機能しないため拒否する
+	if err := h.validateReactions(ctx, cmd.TeamID, func(t *domain.Tenant) {
+		if channelID != "" {
+			t.ChannelReplyRules[channelID] = *rule
+		} else {
+			t.ReplyRule = rule
+		}
+	}); err != nil {
+		writeReactionValidationError(w, ctx, cmd.TeamID, err, replyPolicyUsage)
+		return
+	}
+
+	scope := "ワークスペース全体"
+	if channelID != "" {
+		scope = fmt.Sprintf("<#%s>", channelID)
+		err = h.tenantRepository.SetChannelReplyRule(ctx, cmd.TeamID, channelID, rule)
+	} else {
+		err = h.tenantRepository.SetReplyRule(ctx, cmd.TeamID, rule)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "SetReplyRule 失敗", "team_id", cmd.TeamID, "error", err)
+		writeTenantUpdateError(w, "返信判定ルールの設定に失敗しました", err)
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, fmt.Sprintf("%s の返信判定を「%s」に設定しました", scope, formatReplyRule(*rule)))
+}
+
+// handleUnsetReplyPolicy は /_unset_reply_policy コマンドを処理
+func (h *CommandsHandler) handleUnsetReplyPolicy(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	args := strings.Fields(cmd.Text)
+	if len(args) > 1 {
+		writeEphemeral(w, http.StatusBadRequest, "使用方法: /_unset_reply_policy [#チャンネル]（省略時はワークスペース全体）")
+		return
+	}
+
+	if len(args) == 0 {
+		if err := h.tenantRepository.SetReplyRule(ctx, cmd.TeamID, nil); err != nil {
+			writeEphemeral(w, http.StatusInternalServerError, "返信判定ルールの削除に失敗しました")
+			return
+		}
+		writeEphemeral(w, http.StatusOK, "ワークスペース全体の返信判定ルールを削除しました（メンション付きスレッド返信で判定します）")
+		return
+	}
+
+	channelID, err := parseChannelRef(args[0])
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.tenantRepository.SetChannelReplyRule(ctx, cmd.TeamID, channelID, nil); err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, "返信判定ルールの削除に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, fmt.Sprintf("<#%s> の返信判定ルールを削除しました（ワークスペース全体のルールに従います）", channelID))
+}
+
+// handleGetReplyPolicy は /_get_reply_policy コマンドを処理
+func (h *CommandsHandler) handleGetReplyPolicy(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	tenant, err := h.tenantRepository.Get(ctx, cmd.TeamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusOK, "このワークスペースは登録されていません")
+			return
+		}
+		writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+		return
+	}
+
+	workspaceRule := domain.DefaultReplyRule()
+	suffix := "（デフォルト）"
+	if tenant.ReplyRule != nil {
+		workspaceRule = *tenant.ReplyRule
+		suffix = ""
+	}
+
+	lines := []string{fmt.Sprintf("ワークスペース全体: %s%s", formatReplyRule(workspaceRule), suffix)}
+	if len(tenant.ChannelReplyRules) > 0 {
+		channelIDs := make([]string, 0, len(tenant.ChannelReplyRules))
+		for channelID := range tenant.ChannelReplyRules {
+			channelIDs = append(channelIDs, channelID)
+		}
+		sort.Strings(channelIDs)
+
+		lines = append(lines, "チャンネルごと:")
+		for _, channelID := range channelIDs {
+			lines = append(lines, fmt.Sprintf("• <#%s> → %s", channelID, formatReplyRule(tenant.ChannelReplyRules[channelID])))
+		}
+	}
+
+	writeEphemeral(w, http.StatusOK, strings.Join(lines, "\n"))
+}
+
+// parseReplyRule は "reaction :eyes:" 形式の引数を返信判定ルールに変換します
+func parseReplyRule(args []string) (*domain.ReplyRule, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("返信判定方針を指定してください")
+	}
+
+	if args[0] == "dm" {
+		// Bot トークンではユーザー同士の DM を読み取れないため判定できない
+		return nil, fmt.Errorf("メンション送信者への DM は Bot から確認できないため指定できません")
+	}
+
+	policy, ok := replyPolicyAliases[args[0]]
+	if !ok {
+		policy = domain.ReplyPolicy(args[0])
+	}
+
+	rule := &domain.ReplyRule{Policy: policy}
+	if len(args) == 2 {
+		rule.Reaction = strings.Trim(args[1], ":")
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// formatReplyRule は返信判定ルールを表示用の文字列に変換します
+func formatReplyRule(rule domain.ReplyRule) string {
+	label, ok := replyPolicyLabels[rule.Policy]
+	if !ok {
+		label = string(rule.Policy)
+	}
+	if rule.Policy == domain.ReplyPolicyReaction {
+		label += fmt.Sprintf(" :%s:", rule.ReactionName())
+	}
+	return label
+}
+
+// reactionsUsage は /_set_reactions の使用方法です
+const reactionsUsage = "使用方法: /_set_reactions ack=:eyes: done=:white_check_mark:\n" +
+	"（ack=確認中：上長DMを抑止しリマインドは継続, done=対応済み：監視を終了。省略した項目はデフォルト）"
+
+// handleSetReactions は /_set_reactions コマンドを処理
+func (h *CommandsHandler) handleSetReactions(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	workflow, err := parseReactionWorkflow(cmd.Text)
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, reactionsUsage))
+		return
+	}
+
+	// 返信判定（reaction 方針）のリアクションと重なる場合は返信判定が機能しなくなるため拒否する
+	if err := h.validateReactions(ctx, cmd.TeamID, func(t *domain.Tenant) {
+		t.ReactionWorkflow = workflow
+	}); err != nil {
+		writeReactionValidationError(w, ctx, cmd.TeamID, err, reactionsUsage)
+		return
+	}
+
+	if err := h.tenantRepository.SetReactionWorkflow(ctx, cmd.TeamID, workflow); err != nil {
+		slog.ErrorContext(ctx, "SetReactionWorkflow 失敗", "team_id", cmd.TeamID, "error", err)
+		writeTenantUpdateError(w, "リアクション設定に失敗しました", err)
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "リアクション設定を更新しました\n"+formatReactionWorkflow(*workflow))
+}
+
+// handleUnsetReactions は /_unset_reactions コマンドを処理
+func (h *CommandsHandler) handleUnsetReactions(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	// デフォルトに戻した結果、返信判定（reaction 方針）のリアクションと重なる場合も拒否する
+	if err := h.validateReactions(ctx, cmd.TeamID, func(t *domain.Tenant) {
+		t.ReactionWorkflow = nil
+	}); err != nil {
+		writeReactionValidationError(w, ctx, cmd.TeamID, err, "返信判定のリアクションを変更してから実行してください")
+		return
+	}
+
+	if err := h.tenantRepository.SetReactionWorkflow(ctx, cmd.TeamID, nil); err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, "リアクション設定の削除に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "リアクション設定を削除しました（デフォルトに戻ります）\n"+formatReactionWorkflow(domain.ReactionWorkflow{}))
+}
+
+// handleGetReactions は /_get_reactions コマンドを処理
+func (h *CommandsHandler) handleGetReactions(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	tenant, err := h.tenantRepository.Get(ctx, cmd.TeamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusOK, "このワークスペースは登録されていません")
+			return
+		}
+		writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, "現在のリアクション設定:\n"+formatReactionWorkflow(tenant.ReactionWorkflowOrDefault()))
+}
+
+// parseReactionWorkflow は "ack=:eyes: done=:white_check_mark:" 形式の文字列をリアクション設定に変換します
+func parseReactionWorkflow(text string) (*domain.ReactionWorkflow, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("設定する項目を指定してください")
+	}
+
+	workflow := &domain.ReactionWorkflow{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("項目の形式が不正です: %s", field)
+		}
+		name := strings.Trim(value, ":")
+
+		switch key {
+		case "ack":
+			workflow.AckReaction = name
+		case "done":
+			workflow.DoneReaction = name
+		default:
+			return nil, fmt.Errorf("不明な項目です: %s", key)
+		}
+	}
+
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	return workflow, nil
+}
+
+// validateReactions は apply で変更した後のテナント設定で、リアクションの用途が重複していないかを検証します
+// テナントが未登録の場合は検証せずに成功を返します（設定の更新で未登録のエラーになります）
+func (h *CommandsHandler) validateReactions(ctx context.Context, teamID string, apply func(t *domain.Tenant)) error {
+	tenant, err := h.tenantRepository.Get(ctx, teamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) || errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	updated := *tenant
+	updated.ChannelReplyRules = maps.Clone(tenant.ChannelReplyRules)
+	if updated.ChannelReplyRules == nil {
+		updated.ChannelReplyRules = make(map[string]domain.ReplyRule)
+	}
+	apply(&updated)
+
+	return updated.ValidateReactions()
+}
+
+// writeReactionValidationError はリアクションの重複検証のエラーを応答します
+func writeReactionValidationError(w http.ResponseWriter, ctx context.Context, teamID string, err error, usage string) {
+	if errors.Is(err, domain.ErrInvalid) {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, usage))
+		return
+	}
+	slog.ErrorContext(ctx, "テナント取得失敗", "team_id", teamID, "error", err)
+	writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+}
+
+// formatReactionWorkflow はリアクション設定を表示用の文字列に変換します
+func formatReactionWorkflow(workflow domain.ReactionWorkflow) string {
+	return fmt.Sprintf("確認中（上長DMを抑止）: :%s:\n対応済み（監視を終了）: :%s:", workflow.AckName(), workflow.DoneName())
+}
+
+// retentionUsage は /_set_retention の使用方法です
+const retentionUsage = "使用方法: /_set_retention 30d（メンションから何日間記録を保持するか。7〜3650日）"
+
+// handleSetRetention は /_set_retention コマンドを処理
+func (h *CommandsHandler) handleSetRetention(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	days, err := parseRetentionDays(cmd.Text)
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, retentionUsage))
+		return
+	}
+
+	if err := h.tenantRepository.SetRetentionDays(ctx, cmd.TeamID, days); err != nil {
+		slog.ErrorContext(ctx, "SetRetentionDays 失敗", "team_id", cmd.TeamID, "error", err)
+		if errors.Is(err, domain.ErrInvalid) {
+			writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, retentionUsage))
+			return
+		}
+		writeTenantUpdateError(w, "保持期間の設定に失敗しました", err)
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, fmt.Sprintf("メンション記録の保持期間を%d日に設定しました（これから記録するメンションの TTL と定期削除に適用）", days))
+}
+
+// handleUnsetRetention は /_unset_retention コマンドを処理
+func (h *CommandsHandler) handleUnsetRetention(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	if err := h.tenantRepository.SetRetentionDays(ctx, cmd.TeamID, 0); err != nil {
+		writeEphemeral(w, http.StatusInternalServerError, "保持期間の設定の削除に失敗しました")
 		return
 	}
 
-	if tenant.ManagerUserID == nil {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprint(w, `{"response_type":"ephemeral","text":"上長が設定されていません"}`)
+	writeEphemeral(w, http.StatusOK, "保持期間の設定を削除しました（デフォルトに戻ります）\n"+formatRetention(domain.Tenant{}, h.defaultRetention))
+}
+
+// handleGetRetention は /_get_retention コマンドを処理
+func (h *CommandsHandler) handleGetRetention(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	tenant, err := h.tenantRepository.Get(ctx, cmd.TeamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) {
+			writeEphemeral(w, http.StatusOK, "このワークスペースは登録されていません")
+			return
+		}
+		writeEphemeral(w, http.StatusInternalServerError, "テナント取得に失敗しました")
+		return
+	}
+
+	writeEphemeral(w, http.StatusOK, formatRetention(*tenant, h.defaultRetention))
+}
+
+// parseRetentionDays は "30d" または "30" 形式の日数を解析します
+func parseRetentionDays(text string) (int, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(text), "d")
+	if s == "" {
+		return 0, fmt.Errorf("保持期間を指定してください")
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("保持期間は日数で指定してください: %s", text)
+	}
+	if err := domain.ValidateRetentionDays(days); err != nil {
+		return 0, err
+	}
+	return days, nil
+}
+
+// formatRetention は保持期間の設定を表示用の文字列に変換します
+func formatRetention(tenant domain.Tenant, defaultRetention time.Duration) string {
+	period := tenant.RetentionPeriod(defaultRetention)
+	source := "デフォルト"
+	if tenant.RetentionDays > 0 {
+		source = "ワークスペースの設定"
+	}
+	if period <= 0 {
+		return fmt.Sprintf("メンション記録の保持期間: 無期限（%s）", source)
+	}
+	return fmt.Sprintf("メンション記録の保持期間: メンションから%s（%s）", formatDuration(period), source)
+}
+
+// mineListLimit は /_mine で種類ごとに表示するメンションの上限です（Block Kit のブロック数上限 50 に収めるため）
+const mineListLimit = 20
+
+// handleMine は /_mine コマンドを処理
+// 実行者が返信を期待されているメンションと、実行者が返信を待っているメンションを一覧表示します
+func (h *CommandsHandler) handleMine(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	now := time.Now()
+	mine, err := h.reminderService.ListMine(ctx, cmd.TeamID, cmd.UserID, now.Unix())
+	if err != nil {
+		slog.ErrorContext(ctx, "メンション一覧取得エラー", "team_id", cmd.TeamID, "user_id", cmd.UserID, "error", err)
+		writeEphemeral(w, http.StatusInternalServerError, "メンション一覧の取得に失敗しました")
+		return
+	}
+
+	text := fmt.Sprintf("返信待ち: %d件 / あなたが待っているもの: %d件", len(mine.Owed), len(mine.Waiting))
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType,
+			fmt.Sprintf("📥 あなたの返信待ち（%d件）", len(mine.Owed)), false, false)),
+	}
+	blocks = append(blocks, mentionSummaryBlocks(mine.Owed, now, "返信待ちのメンションはありません 🎉", func(m *domain.Mention) string {
+		return fmt.Sprintf("<@%s> から", m.ParentUserID)
+	})...)
+	blocks = append(blocks,
+		slack.NewDividerBlock(),
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType,
+			fmt.Sprintf("📤 あなたが返信を待っているもの（%d件）", len(mine.Waiting)), false, false)),
+	)
+	blocks = append(blocks, mentionSummaryBlocks(mine.Waiting, now, "返信を待っているメンションはありません", func(m *domain.Mention) string {
+		return fmt.Sprintf("<@%s> へ", m.MentionedUserID)
+	})...)
+
+	writeEphemeralBlocks(w, http.StatusOK, text, blocks)
+}
+
+// mentionSummaryBlocks はメンション一覧を1件1セクションのブロックに変換します
+// counterpart は相手（送信者・対象者）の表示を返します。mineListLimit を超えた分は件数のみ表示します
+func mentionSummaryBlocks(summaries []service.MentionSummary, now time.Time, emptyText string, counterpart func(m *domain.Mention) string) []slack.Block {
+	if len(summaries) == 0 {
+		return []slack.Block{
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, emptyText, false, false)),
+		}
+	}
+
+	blocks := make([]slack.Block, 0, min(len(summaries), mineListLimit)+1)
+	for i, summary := range summaries {
+		if i >= mineListLimit {
+			blocks = append(blocks, slack.NewContextBlock("",
+				slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("ほか %d件", len(summaries)-mineListLimit), false, false)))
+			break
+		}
+		m := summary.Mention
+		text := fmt.Sprintf("<#%s> ・ %s ・ <%s|スレッドを開く>\n経過: %s ・ 次の通知: %s",
+			m.ChannelID, counterpart(m), summary.URL,
+			formatDuration(now.Sub(time.Unix(m.CreatedAt, 0))), formatNextStep(summary, now))
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil))
+	}
+	return blocks
+}
+
+// pendingUsage は /_pending の使用方法です
+const pendingUsage = "使用方法: /_pending [#チャンネル] [@対象者] [page=2]（#チャンネル は入力補完で選ぶか、チャンネルIDで指定。管理者・上長のみ）"
+
+// handlePending は /_pending コマンドを処理
+// ワークスペースの未返信メンションを古い順に一覧表示します（チャンネル・対象者で絞り込み可能）
+func (h *CommandsHandler) handlePending(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	q, err := h.parsePendingQuery(ctx, cmd.TeamID, cmd.Text)
+	if err != nil {
+		writeEphemeral(w, http.StatusBadRequest, fmt.Sprintf("%v\n%s", err, pendingUsage))
+		return
+	}
+
+	page, err := h.reminderService.ListPending(ctx, cmd.TeamID, cmd.UserID, q, time.Now().Unix())
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientPermission) {
+			writeEphemeral(w, http.StatusForbidden, "このコマンドはワークスペースの管理者・上長のみ実行できます")
+			return
+		}
+		slog.ErrorContext(ctx, "未返信メンション一覧取得エラー", "team_id", cmd.TeamID, "user_id", cmd.UserID, "error", err)
+		writeEphemeral(w, http.StatusInternalServerError, "未返信メンション一覧の取得に失敗しました")
+		return
+	}
+
+	filter := "ワークスペース全体"
+	var filterArgs []string
+	if q.ChannelID != "" {
+		filter = fmt.Sprintf("<#%s>", q.ChannelID)
+		filterArgs = append(filterArgs, fmt.Sprintf("<#%s>", q.ChannelID))
+	}
+	if q.UserID != "" {
+		filter += fmt.Sprintf(" ・ 対象者 <@%s>", q.UserID)
+		filterArgs = append(filterArgs, fmt.Sprintf("<@%s>", q.UserID))
+	}
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType,
+			fmt.Sprintf("⏳ 未返信のメンション（%dページ目）", page.Page), false, false)),
+		slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, "対象: "+filter+" ・ 古い順", false, false)),
+	}
+	blocks = append(blocks, mentionSummaryBlocks(page.Mentions, time.Now(), "未返信のメンションはありません 🎉", func(m *domain.Mention) string {
+		return fmt.Sprintf("<@%s> → <@%s>", m.ParentUserID, m.MentionedUserID)
+	})...)
+	if page.HasNext {
+		next := strings.Join(append(filterArgs, fmt.Sprintf("page=%d", page.Page+1)), " ")
+		blocks = append(blocks, slack.NewContextBlock("",
+			slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("次のページ: `/_pending %s`", next), false, false)))
+	}
+
+	text := fmt.Sprintf("未返信のメンション %dページ目: %d件", page.Page, len(page.Mentions))
+	writeEphemeralBlocks(w, http.StatusOK, text, blocks)
+}
+
+// parsePendingQuery は /_pending の引数（#チャンネル・@対象者・page=N。順不同）を解析します
+// チャンネルは Slack がエスケープしたチャンネルメンション（<#C…|name>）かチャンネルIDのみ受け付けます
+func (h *CommandsHandler) parsePendingQuery(ctx context.Context, teamID, text string) (service.PendingQuery, error) {
+	var q service.PendingQuery
+	for _, arg := range strings.Fields(text) {
+		switch {
+		case strings.HasPrefix(arg, "page="):
+			page, err := strconv.Atoi(strings.TrimPrefix(arg, "page="))
+			if err != nil || page < 1 {
+				return q, fmt.Errorf("ページ番号の形式が不正です: %s", arg)
+			}
+			q.Page = page
+
+		case strings.HasPrefix(arg, "<#") || strings.HasPrefix(arg, "#") || (isSlackID(arg) && (arg[0] == 'C' || arg[0] == 'G')):
+			channelID, err := parseChannelRef(arg)
+			if err != nil {
+				return q, err
+			}
+			q.ChannelID = channelID
+
+		default:
+			userID, err := h.resolveUserRef(ctx, teamID, arg)
+			if err != nil {
+				return q, fmt.Errorf("ユーザー検索失敗: %w", err)
+			}
+			q.UserID = userID
+		}
+	}
+	return q, nil
+}
+
+// statsUsage は /_stats の使用方法です
+const statsUsage = "使用方法: /_stats [7d|30d|90d]（集計期間。省略時は7日。管理者・上長のみ）"
+
+// defaultStatsWindow は /_stats の集計期間のデフォルトです
+const defaultStatsWindow = 7 * 24 * time.Hour
+
+// maxStatsWindow は /_stats で指定できる集計期間の上限です
+const maxStatsWindow = 365 * 24 * time.Hour
+
+// statsTopN は /_stats で対象者別・チャンネル別に表示する件数の上限です
+const statsTopN = 10
+
+// handleStats は /_stats コマンドを処理
+// 期間内のメンションの応答時間（中央値・p90）と結果の内訳をワークスペース・対象者・チャンネルごとに表示します
+func (h *CommandsHandler) handleStats(w http.ResponseWriter, ctx context.Context, cmd dto.SlackCommandRequest) {
+	window := defaultStatsWindow
+	if arg := strings.TrimSpace(cmd.Text); arg != "" {
+		d, err := parseDelay(arg)
+		if err != nil || d <= 0 || d > maxStatsWindow {
+			writeEphemeral(w, http.StatusBadRequest, statsUsage)
+			return
+		}
+		window = d
+	}
+
+	now := time.Now()
+	stats, err := h.analyticsService.Stats(ctx, cmd.TeamID, cmd.UserID, now.Add(-window).Unix(), now.Unix())
+	if err != nil {
+		if errors.Is(err, domain.ErrInsufficientPermission) {
+			writeEphemeral(w, http.StatusForbidden, "このコマンドはワークスペースの管理者・上長のみ実行できます")
+			return
+		}
+		slog.ErrorContext(ctx, "応答時間集計エラー", "team_id", cmd.TeamID, "user_id", cmd.UserID, "error", err)
+		writeEphemeral(w, http.StatusInternalServerError, "応答時間の集計に失敗しました")
+		return
+	}
+
+	team := stats.Team
+	text := fmt.Sprintf("過去%sの応答時間: 中央値 %s ・ p90 %s（メンション %d件）",
+		formatDuration(window), formatResponseSeconds(team, team.MedianSeconds), formatResponseSeconds(team, team.P90Seconds), team.Total)
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType,
+			fmt.Sprintf("📊 応答時間（過去%s）", formatDuration(window)), false, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, formatStatsGroup(team), false, false), nil, nil),
+	}
+	if team.Total > 0 {
+		blocks = append(blocks,
+			slack.NewDividerBlock(),
+			statsRankingBlock("*対象者別*（p90 の長い順）", stats.ByUser, func(key string) string { return fmt.Sprintf("<@%s>", key) }),
+			statsRankingBlock("*チャンネル別*（p90 の長い順）", stats.ByChannel, func(key string) string { return fmt.Sprintf("<#%s>", key) }),
+		)
+	}
+
+	writeEphemeralBlocks(w, http.StatusOK, text, blocks)
+}
+
+// formatStatsGroup はワークスペース全体の集計を表示用の文字列に変換します
+func formatStatsGroup(group service.StatsGroup) string {
+	if group.Total == 0 {
+		return "期間内のメンションはありません"
+	}
+	return fmt.Sprintf("メンション *%d件* ・ 応答 %d件 ・ 未返信 %d件\n"+
+		"応答までの時間: 中央値 *%s* ・ p90 *%s*\n"+
+		"内訳: リマインド前 %d件 ・ リマインド後 %d件 ・ エスカレーション後 %d件 ・ 期限切れ %d件",
+		group.Total, group.Responded, group.Open,
+		formatResponseSeconds(group, group.MedianSeconds), formatResponseSeconds(group, group.P90Seconds),
+		group.Outcomes[domain.MentionOutcomeBeforeRemind], group.Outcomes[domain.MentionOutcomeAfterRemind],
+		group.Outcomes[domain.MentionOutcomeAfterEscalation], group.Outcomes[domain.MentionOutcomeExpired])
+}
+
+// statsRankingBlock は対象者別・チャンネル別の集計を上位 statsTopN 件のセクションに変換します
+func statsRankingBlock(title string, groups []service.StatsGroup, label func(key string) string) slack.Block {
+	lines := []string{title}
+	for i, group := range groups {
+		if i >= statsTopN {
+			lines = append(lines, fmt.Sprintf("ほか %d件", len(groups)-statsTopN))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s — 中央値 %s ・ p90 %s（%d件中 応答 %d件）",
+			i+1, label(group.Key), formatResponseSeconds(group, group.MedianSeconds), formatResponseSeconds(group, group.P90Seconds),
+			group.Total, group.Responded))
+	}
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, strings.Join(lines, "\n"), false, false), nil, nil)
+}
+
+// formatResponseSeconds は応答時間を表示用の文字列に変換します（応答がない場合は "-"）
+func formatResponseSeconds(group service.StatsGroup, seconds int64) string {
+	if group.Responded == 0 {
+		return "-"
+	}
+	return formatDuration(time.Duration(seconds) * time.Second)
+}
+
+// formatDuration はメンションからの経過時間・応答時間を表示用の文字列に変換します
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "1分未満"
+	case d < time.Hour:
+		return fmt.Sprintf("%d分", int(d/time.Minute))
+	case d < 24*time.Hour:
+		if d%time.Hour < time.Minute {
+			return fmt.Sprintf("%d時間", int(d/time.Hour))
+		}
+		return fmt.Sprintf("%d時間%d分", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	default:
+		if d%(24*time.Hour) < time.Hour {
+			return fmt.Sprintf("%d日", int(d/(24*time.Hour)))
+		}
+		return fmt.Sprintf("%d日%d時間", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	}
+}
+
+// formatNextStep は次の通知予定を表示用の文字列に変換します
+// 時刻は Slack の日付書式で表示し、閲覧者のタイムゾーンで表示されるようにします
+func formatNextStep(summary service.MentionSummary, now time.Time) string {
+	if summary.NextStepAt == 0 {
+		return "なし（通知はすべて完了）"
+	}
+
+	label, ok := escalationActionLabels[summary.NextAction]
+	if !ok {
+		label = string(summary.NextAction)
+	}
+
+	when := "まもなく"
+	if summary.NextStepAt > now.Unix() {
+		fallback := time.Unix(summary.NextStepAt, 0).UTC().Format("2006-01-02 15:04 UTC")
+		when = fmt.Sprintf("<!date^%d^{date_short_pretty} {time}|%s>", summary.NextStepAt, fallback)
+	}
+	if summary.Mention.IsSnoozed(now.Unix()) {
+		label += "・スヌーズ中"
+	}
+	return fmt.Sprintf("%s（%s）", when, label)
+}
+
+// writeEphemeral はスラッシュコマンドの実行者のみに見える応答を書き込みます
+func writeEphemeral(w http.ResponseWriter, status int, text string) {
+	writeEphemeralBlocks(w, status, text, nil)
+}
+
+// writeEphemeralBlocks はスラッシュコマンドの実行者のみに見える Block Kit 形式の応答を書き込みます
+// text は通知やブロックを表示できないクライアント向けの代替テキストです
+func writeEphemeralBlocks(w http.ResponseWriter, status int, text string, blocks []slack.Block) {
+	var blockValues []interface{}
+	for _, block := range blocks {
+		blockValues = append(blockValues, block)
+	}
+
+	body, err := json.Marshal(dto.SlackSlashResponse{
+		ResponseType: "ephemeral",
+		Text:         text,
+		Blocks:       blockValues,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `{"response_type":"ephemeral","text":"現在の上長: <@%s>"}`, *tenant.ManagerUserID)
+	w.WriteHeader(status)
+	w.Write(body)
 }
 
 // parseFormFromBytes はバイト列からURLエンコードされたフォームをパースします
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/store/memory"
+	"slack-bot/project/service"
+)
+
+// fakeCommandsSlack は管理者・オーナーのユーザーIDを返す SlackPort のテスト用実装です
+type fakeCommandsSlack struct {
+	admins map[string]bool
+	err    error // IsWorkspaceAdmin のエラー
+}
+
+func (s *fakeCommandsSlack) GetUserID(ctx context.Context, teamID, userNameOrEmail string) (string, error) {
+	return "", domain.ErrNotFound
+}
+
+func (s *fakeCommandsSlack) IsWorkspaceAdmin(ctx context.Context, teamID, userID string) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	return s.admins[userID], nil
+}
+
+func TestAuthorizeSettings(t *testing.T) {
+	errSlack := errors.New("slack: users.info failed")
+
+	tests := []struct {
+		name     string
+		userID   string
+		adminErr error
+		wantErr  error
+	}{
+		{name: "管理者・オーナーは設定を変更できる", userID: "UADMIN"},
+		{name: "ワークスペースの上長でも管理者でなければ拒否する", userID: "M1", wantErr: domain.ErrInsufficientPermission},
+		{name: "メンバーごとの上長でも管理者でなければ拒否する", userID: "M2", wantErr: domain.ErrInsufficientPermission},
+		{name: "チャンネルごとの上長でも管理者でなければ拒否する", userID: "M3", wantErr: domain.ErrInsufficientPermission},
+		{name: "一般メンバーは拒否する", userID: "U1", wantErr: domain.ErrInsufficientPermission},
+		{name: "管理者を確認できない場合はエラーを返す", userID: "UADMIN", adminErr: errSlack, wantErr: errSlack},
+	}
+
+	ctx := context.Background()
+	repo := memory.NewRepo()
+	if err := repo.UpsertBotTokenSecret(ctx, "T1", "slack_token_T1"); err != nil {
+		t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+	}
+	m1, m2, m3 := "M1", "M2", "M3"
+	if err := repo.SetManager(ctx, "T1", &m1); err != nil {
+		t.Fatalf("SetManager() error = %v", err)
+	}
+	if err := repo.SetUserManager(ctx, "T1", "U1", &m2); err != nil {
+		t.Fatalf("SetUserManager() error = %v", err)
+	}
+	if err := repo.SetChannelManager(ctx, "T1", "C1", &m3); err != nil {
+		t.Fatalf("SetChannelManager() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := &fakeCommandsSlack{admins: map[string]bool{"UADMIN": true}, err: tt.adminErr}
+			h := NewCommandsHandler("secret", repo, sp, nil, nil, 0)
+
+			err := h.authorizeSettings(ctx, "T1", tt.userID)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("authorizeSettings() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("authorizeSettings() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.adminErr != nil && errors.Is(err, domain.ErrInsufficientPermission) {
+				t.Errorf("authorizeSettings() error = %v, must not be %v", err, domain.ErrInsufficientPermission)
+			}
+		})
+	}
+}
+
+func TestParsePendingQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    service.PendingQuery
+		wantErr bool
+	}{
+		{name: "引数なし", text: ""},
+		{name: "エスケープされたチャンネルメンション", text: "<#C0123ABCD|general>", want: service.PendingQuery{ChannelID: "C0123ABCD"}},
+		{name: "チャンネルID", text: "C0123ABCD", want: service.PendingQuery{ChannelID: "C0123ABCD"}},
+		{name: "対象者とページ（順不同）", text: "page=2 <@U1|taro> <#C0123ABCD>", want: service.PendingQuery{ChannelID: "C0123ABCD", UserID: "U1", Page: 2}},
+		{name: "補完を使わずに入力したチャンネル名は特定できない", text: "#general", wantErr: true},
+		{name: "ページ番号が不正", text: "page=0", wantErr: true},
+	}
+
+	h := NewCommandsHandler("secret", memory.NewRepo(), &fakeCommandsSlack{}, nil, nil, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.parsePendingQuery(context.Background(), "T1", tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePendingQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parsePendingQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/service"
+)
+
+// WeeklyDigestHandler は上長向け週次ダイジェストの定期実行（Cloud Scheduler・ローカルスケジューラ）を受け付けます
+type WeeklyDigestHandler struct {
+	adminToken    string
+	digestService service.DigestService
+}
+
+// NewWeeklyDigestHandler は週次ダイジェストハンドラーを作成します
+// adminToken が空の場合はエンドポイントを無効化します（常に 404）
+func NewWeeklyDigestHandler(adminToken string, digestService service.DigestService) *WeeklyDigestHandler {
+	return &WeeklyDigestHandler{
+		adminToken:    adminToken,
+		digestService: digestService,
+	}
+}
+
+// ServeHTTP は /cron/weekly-digest エンドポイント
+// POST /cron/weekly-digest（Authorization: Bearer <ADMIN_API_TOKEN>）
+// 今週送信済みのワークスペースはスキップするため、重複・再試行されても二重送信しません
+func (h *WeeklyDigestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.adminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validAdminToken(r, h.adminToken) {
+		http.Error(w, "認証失敗", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(r.Context(), ""), 5*time.Minute)
+	defer cancel()
+
+	sent, err := h.digestService.SendWeeklyDigests(ctx, time.Now().Unix())
+	if err != nil {
+		slog.ErrorContext(ctx, "週次ダイジェスト送信エラー", "sent", sent, "error", err)
+		http.Error(w, "週次ダイジェスト送信失敗", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "週次ダイジェスト送信完了", "sent", sent)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "sent=%d", sent)
+}
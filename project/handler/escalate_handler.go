@@ -3,23 +3,29 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
 	"slack-bot/project/service"
 )
 
 // EscalateHandler は 30分後のエスカレーション処理を行います
 type EscalateHandler struct {
 	reminderService service.ReminderService
+	verifier        httpsec.TaskVerifier
 }
 
 // NewEscalateHandler はエスカレーションハンドラーを作成します
-func NewEscalateHandler(reminderService service.ReminderService) *EscalateHandler {
+func NewEscalateHandler(reminderService service.ReminderService, verifier httpsec.TaskVerifier) *EscalateHandler {
 	return &EscalateHandler{
 		reminderService: reminderService,
+		verifier:        verifier,
 	}
 }
 
@@ -38,6 +44,13 @@ func (h *EscalateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	// スケジューラ（Cloud Tasks の OIDC トークン / ローカルスケジューラの HMAC 署名）の認証
+	if err := h.verifier.Verify(r, body); err != nil {
+		slog.WarnContext(r.Context(), "タスクコールバックの認証失敗", "path", r.URL.Path, "error", err)
+		http.Error(w, "認証失敗", http.StatusUnauthorized)
+		return
+	}
+
 	// JSON パース
 	var payload service.TaskPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
@@ -46,12 +59,18 @@ func (h *EscalateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// service.CheckEscalate 実行
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// メンションを検知したイベントの相関 ID を引き継ぐ（古いタスクで未設定の場合は新たに発行）
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(context.WithoutCancel(r.Context()), payload.CorrelationID), 30*time.Second)
 	defer cancel()
 
 	if err := h.reminderService.CheckEscalate(ctx, &payload); err != nil {
-		fmt.Printf("エスカレーション処理エラー: %v\n", err)
-		// Cloud Tasks 側へは 200 で応答（再試行回避）
+		slog.ErrorContext(ctx, "エスカレーション処理エラー", "team_id", payload.TeamID, "channel_id", payload.ChannelID, "message_ts", payload.MessageTS, "user_id", payload.UserID, "step", payload.Step, "error", err)
+		if errors.Is(err, domain.ErrTemporary) {
+			// レート制限・一時的な障害は 503 で応答し、Cloud Tasks（ローカルではスケジューラ）に再試行させる
+			http.Error(w, "一時的なエラー", http.StatusServiceUnavailable)
+			return
+		}
+		// 恒久的なエラーは 200 で応答（再試行回避）
 		w.WriteHeader(http.StatusOK)
 		return
 	}
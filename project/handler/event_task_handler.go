@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/dto"
+	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/service"
+)
+
+// eventDedupTTL は処理した event_id を記録しておく期間です
+// Slack の再送（最大3回・最後は約5分後）とタスクキューの再試行より十分長くします
+const eventDedupTTL = time.Hour
+
+// releaseTimeout は失敗したメンションの処理記録を取り消す際の上限です
+const releaseTimeout = 500 * time.Millisecond
+
+// EventTaskHandler はタスクキュー（Cloud Tasks・ローカルスケジューラ）から配送された Slack イベントを処理します
+type EventTaskHandler struct {
+	reminderService  service.ReminderService
+	lifecycleService service.LifecycleService
+	events           domain.EventRepository
+	verifier         httpsec.TaskVerifier
+}
+
+// NewEventTaskHandler はイベント処理ハンドラーを作成します
+func NewEventTaskHandler(reminderService service.ReminderService, lifecycleService service.LifecycleService, events domain.EventRepository, verifier httpsec.TaskVerifier) *EventTaskHandler {
+	return &EventTaskHandler{
+		reminderService:  reminderService,
+		lifecycleService: lifecycleService,
+		events:           events,
+		verifier:         verifier,
+	}
+}
+
+// ServeHTTP は /tasks/event エンドポイント
+func (h *EventTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// リクエスト本体を読み込む
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "リクエスト本体の読み込み失敗", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// スケジューラ（Cloud Tasks の OIDC トークン / ローカルスケジューラの HMAC 署名）の認証
+	if err := h.verifier.Verify(r, body); err != nil {
+		slog.WarnContext(r.Context(), "タスクコールバックの認証失敗", "path", r.URL.Path, "error", err)
+		http.Error(w, "認証失敗", http.StatusUnauthorized)
+		return
+	}
+
+	// JSON パース（受信時に署名検証済みの Slack イベント）
+	var task service.EventTask
+	if err := json.Unmarshal(body, &task); err != nil {
+		http.Error(w, "JSON パース失敗", http.StatusBadRequest)
+		return
+	}
+	var req dto.SlackEventRequest
+	if err := json.Unmarshal(task.Body, &req); err != nil {
+		http.Error(w, "JSON パース失敗", http.StatusBadRequest)
+		return
+	}
+
+	// イベント受信時の相関 ID を引き継ぐ
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(context.WithoutCancel(r.Context()), task.CorrelationID), 30*time.Second)
+	defer cancel()
+
+	if err := h.handleEvent(ctx, req); err != nil {
+		slog.ErrorContext(ctx, "イベント処理エラー", "team_id", req.TeamID, "event_id", req.EventID, "type", req.Event.Type, "error", err)
+		if isPermanentEventError(err) {
+			// 不正なイベント・恒久的な Slack API エラーは 200 で応答（再試行回避）
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// それ以外（一時的な障害・ストアの失敗など）は 503 で応答し、Cloud Tasks（ローカルではスケジューラ）に再試行させる
+		http.Error(w, "一時的なエラー", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// isPermanentEventError は再試行しても成功しないイベント処理のエラーかどうかを返します
+func isPermanentEventError(err error) bool {
+	if errors.Is(err, domain.ErrTemporary) {
+		return false
+	}
+	return errors.Is(err, domain.ErrInvalid) || errors.Is(err, domain.ErrSlackAPIFailed)
+}
+
+// handleEvent は個別のイベントを処理します
+func (h *EventTaskHandler) handleEvent(ctx context.Context, req dto.SlackEventRequest) error {
+	// アンインストール・トークン失効（ワークスペースのライフサイクル）
+	switch req.Event.Type {
+	case "app_uninstalled":
+		return h.lifecycleService.OnAppUninstalled(ctx, req.TeamID, time.Now().Unix())
+	case "tokens_revoked":
+		botRevoked := req.Event.Tokens != nil && len(req.Event.Tokens.Bot) > 0
+		return h.lifecycleService.OnTokensRevoked(ctx, req.TeamID, botRevoked, time.Now().Unix())
+	}
+
+	// リアクションによる確認中・対応済みの更新
+	if req.Event.Type == "reaction_added" || req.Event.Type == "reaction_removed" {
+		return h.handleReaction(ctx, req)
+	}
+
+	// Bot 自身のメッセージや bot_message は無視
+	if req.Event.BotID != "" || req.Event.SubType == "bot_message" {
+		return nil
+	}
+
+	switch req.Event.Type {
+	case "app_mention":
+		return h.handleMention(ctx, req)
+	case "message":
+		// message イベントはスレッド返信・チャンネルへの投稿の検知に使用
+		return h.handleMessage(ctx, req)
+	}
+	return nil
+}
+
+// handleMention は app_mention イベントをメンション検知として service に渡します
+// 監視レコードの保存・タスクの予約は冪等ではないため、event_id を記録して処理済みのイベントを処理しないようにします
+// 失敗した場合は記録を取り消してエラーを返し、タスクキューの再試行で処理し直します
+func (h *EventTaskHandler) handleMention(ctx context.Context, req dto.SlackEventRequest) error {
+	if req.EventID != "" {
+		claimed, err := h.events.ClaimEvent(ctx, req.TeamID, req.EventID, time.Now().Add(eventDedupTTL).Unix())
+		if err != nil {
+			// 記録できなくてもメンションを取りこぼさないよう処理を続ける（タスク名の重複排除で多重通知は防がれる）
+			slog.WarnContext(ctx, "イベントの処理記録に失敗しました", "team_id", req.TeamID, "event_id", req.EventID, "error", err)
+		} else if !claimed {
+			slog.InfoContext(ctx, "処理済みのイベントをスキップ", "team_id", req.TeamID, "event_id", req.EventID)
+			return nil
+		}
+	}
+
+	// BotUserID は Authorization から取得
+	botUserID := ""
+	for _, auth := range req.Authorizations {
+		if auth.IsBot {
+			botUserID = auth.UserID
+			break
+		}
+	}
+
+	event := service.MentionEvent{
+		TeamID:       req.TeamID,
+		ChannelID:    req.Event.Channel,
+		MessageTS:    req.Event.Timestamp,
+		Text:         req.Event.Text,
+		BotUserID:    botUserID,
+		ParentUserID: req.Event.User,
+		ThreadTS:     req.Event.ThreadTs,
+		NowUnix:      mentionTime(req),
+	}
+
+	if err := h.reminderService.OnMention(ctx, &event); err != nil {
+		// タスクキューの再試行で処理し直せるよう記録を取り消す
+		// 期限切れで失敗した場合も取り消せるよう、処理の期限は引き継がない
+		if req.EventID != "" {
+			rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+			defer cancel()
+			if rerr := h.events.ReleaseEvent(rctx, req.TeamID, req.EventID); rerr != nil {
+				slog.WarnContext(ctx, "イベントの処理記録の削除に失敗しました", "team_id", req.TeamID, "event_id", req.EventID, "error", rerr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// mentionTime はメンションの発生時刻（Unix秒）を返します
+// 再送・再試行されたイベントでも同じ時刻になるよう event_time を使い、ステップの実行時刻（タスク名）を一致させます
+func mentionTime(req dto.SlackEventRequest) int64 {
+	if req.EventTime > 0 {
+		return req.EventTime
+	}
+	return time.Now().Unix()
+}
+
+// handleMessage は message イベントを返信検知として service に渡します
+func (h *EventTaskHandler) handleMessage(ctx context.Context, req dto.SlackEventRequest) error {
+	// 編集・削除・参加通知などのサブタイプは返信として扱わない
+	if req.Event.SubType != "" && req.Event.SubType != "thread_broadcast" {
+		return nil
+	}
+
+	// スレッド外の投稿も、チャンネルへの投稿で返信完了とするルールの判定に使うため渡す
+	if req.Event.User == "" {
+		return nil
+	}
+
+	event := service.MessageEvent{
+		TeamID:    req.TeamID,
+		ChannelID: req.Event.Channel,
+		MessageTS: req.Event.Timestamp,
+		ThreadTS:  req.Event.ThreadTs,
+		UserID:    req.Event.User,
+		Text:      req.Event.Text,
+		NowUnix:   time.Now().Unix(),
+	}
+
+	return h.reminderService.OnMessage(ctx, &event)
+}
+
+// handleReaction は reaction_added / reaction_removed イベントを service に渡します
+func (h *EventTaskHandler) handleReaction(ctx context.Context, req dto.SlackEventRequest) error {
+	// メッセージ以外（ファイルなど）へのリアクションは対象外
+	if req.Event.Item == nil || req.Event.Item.Type != "message" || req.Event.User == "" {
+		return nil
+	}
+
+	event := service.ReactionEvent{
+		TeamID:    req.TeamID,
+		ChannelID: req.Event.Item.Channel,
+		MessageTS: req.Event.Item.Ts,
+		UserID:    req.Event.User,
+		Reaction:  req.Event.Reaction,
+		Removed:   req.Event.Type == "reaction_removed",
+		NowUnix:   time.Now().Unix(),
+	}
+
+	return h.reminderService.OnReaction(ctx, &event)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/store/memory"
+	"slack-bot/project/service"
+)
+
+// fakeEventReminders は受け取ったイベントを記録する ReminderService のテスト用実装です
+type fakeEventReminders struct {
+	service.ReminderService
+
+	mentions  []*service.MentionEvent
+	messages  []*service.MessageEvent
+	reactions []*service.ReactionEvent
+	err       error
+}
+
+func (f *fakeEventReminders) OnMention(ctx context.Context, ev *service.MentionEvent) error {
+	f.mentions = append(f.mentions, ev)
+	return f.err
+}
+
+func (f *fakeEventReminders) OnMessage(ctx context.Context, ev *service.MessageEvent) error {
+	f.messages = append(f.messages, ev)
+	return f.err
+}
+
+func (f *fakeEventReminders) OnReaction(ctx context.Context, ev *service.ReactionEvent) error {
+	f.reactions = append(f.reactions, ev)
+	return f.err
+}
+
+// fakeLifecycle は無効化したワークスペースを記録する LifecycleService のテスト用実装です
+type fakeLifecycle struct {
+	deactivated []string
+}
+
+func (f *fakeLifecycle) OnAppUninstalled(ctx context.Context, teamID string, nowUnix int64) error {
+	f.deactivated = append(f.deactivated, teamID)
+	return nil
+}
+
+func (f *fakeLifecycle) OnTokensRevoked(ctx context.Context, teamID string, botTokensRevoked bool, nowUnix int64) error {
+	if botTokensRevoked {
+		f.deactivated = append(f.deactivated, teamID)
+	}
+	return nil
+}
+
+// fakeVerifier は err を返す TaskVerifier のテスト用実装です
+type fakeVerifier struct {
+	err error
+}
+
+func (v fakeVerifier) Verify(r *http.Request, body []byte) error {
+	return v.err
+}
+
+// newEventTaskRequest は Slack イベントの本体を EventTask として配送するリクエストを作成します
+func newEventTaskRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	task, err := json.Marshal(&service.EventTask{TeamID: "T1", EventID: "Ev1", Body: json.RawMessage(body), CorrelationID: "corr-1"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return httptest.NewRequest(http.MethodPost, "/tasks/event", strings.NewReader(string(task)))
+}
+
+const testMentionEvent = `{"type":"event_callback","team_id":"T1","event_id":"Ev1","event_time":1700000000,"event":{"type":"app_mention","user":"U0","text":"<@U1> 確認お願いします","channel":"C1","ts":"1700000000.000100"},"authorizations":[{"user_id":"UBOT","is_bot":true}]}`
+
+func TestEventTaskHandlerStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		verifyErr  error
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "処理に成功したら 200", body: testMentionEvent, wantStatus: http.StatusOK},
+		{name: "認証に失敗したら 401", body: testMentionEvent, verifyErr: errors.New("invalid token"), wantStatus: http.StatusUnauthorized},
+		{name: "一時的なエラーは 503 で再試行させる", body: testMentionEvent, serviceErr: domain.ErrTemporary, wantStatus: http.StatusServiceUnavailable},
+		{name: "ストアの失敗も 503 で再試行させる", body: testMentionEvent, serviceErr: errors.New("firestore: unavailable"), wantStatus: http.StatusServiceUnavailable},
+		{name: "恒久的な Slack API エラーは 200 で再試行させない", body: testMentionEvent, serviceErr: domain.ErrSlackAPIFailed, wantStatus: http.StatusOK},
+		{name: "不正なイベントは 200 で再試行させない", body: testMentionEvent, serviceErr: domain.ErrInvalid, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminders := &fakeEventReminders{err: tt.serviceErr}
+			h := NewEventTaskHandler(reminders, &fakeLifecycle{}, memory.NewRepo(), fakeVerifier{err: tt.verifyErr})
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, newEventTaskRequest(t, tt.body))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestEventTaskHandlerMention(t *testing.T) {
+	repo := memory.NewRepo()
+	reminders := &fakeEventReminders{err: domain.ErrTemporary}
+	h := NewEventTaskHandler(reminders, &fakeLifecycle{}, repo, fakeVerifier{})
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newEventTaskRequest(t, testMentionEvent))
+		return rec.Code
+	}
+
+	// 失敗した場合は処理記録を取り消し、再試行で処理し直す
+	if got := serve(); got != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+	reminders.err = nil
+	if got := serve(); got != http.StatusOK {
+		t.Fatalf("status = %d, want %d", got, http.StatusOK)
+	}
+	// 処理済みのイベントが再び配送されても処理しない
+	if got := serve(); got != http.StatusOK {
+		t.Fatalf("status = %d, want %d", got, http.StatusOK)
+	}
+
+	if len(reminders.mentions) != 2 {
+		t.Fatalf("OnMention calls = %d, want 2", len(reminders.mentions))
+	}
+	ev := reminders.mentions[1]
+	want := service.MentionEvent{
+		TeamID:       "T1",
+		ChannelID:    "C1",
+		MessageTS:    "1700000000.000100",
+		Text:         "<@U1> 確認お願いします",
+		BotUserID:    "UBOT",
+		ParentUserID: "U0",
+		NowUnix:      1700000000,
+	}
+	if *ev != want {
+		t.Errorf("MentionEvent = %+v, want %+v", *ev, want)
+	}
+}
+
+func TestEventTaskHandlerDispatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		event         string
+		wantMessages  int
+		wantReactions int
+		wantInactive  bool
+	}{
+		{name: "スレッド返信", event: `{"type":"message","user":"U1","channel":"C1","ts":"1700000100.000100","thread_ts":"1700000000.000100"}`, wantMessages: 1},
+		{name: "チャンネルへの投稿", event: `{"type":"message","user":"U1","channel":"C1","ts":"1700000100.000100"}`, wantMessages: 1},
+		{name: "スレッドからのチャンネルへの送信", event: `{"type":"message","subtype":"thread_broadcast","user":"U1","channel":"C1","ts":"1700000100.000100","thread_ts":"1700000000.000100"}`, wantMessages: 1},
+		{name: "編集は返信として扱わない", event: `{"type":"message","subtype":"message_changed","channel":"C1","ts":"1700000100.000100"}`},
+		{name: "Bot の投稿は無視する", event: `{"type":"message","user":"U1","bot_id":"B1","channel":"C1","ts":"1700000100.000100"}`},
+		{name: "リアクション", event: `{"type":"reaction_added","user":"U1","reaction":"eyes","item":{"type":"message","channel":"C1","ts":"1700000000.000100"}}`, wantReactions: 1},
+		{name: "ファイルへのリアクションは無視する", event: `{"type":"reaction_added","user":"U1","reaction":"eyes","item":{"type":"file"}}`},
+		{name: "アンインストール", event: `{"type":"app_uninstalled"}`, wantInactive: true},
+		{name: "Bot トークンの失効", event: `{"type":"tokens_revoked","tokens":{"bot":["UBOT"]}}`, wantInactive: true},
+		{name: "ユーザートークンのみの失効", event: `{"type":"tokens_revoked","tokens":{"oauth":["U1"]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminders := &fakeEventReminders{}
+			lifecycle := &fakeLifecycle{}
+			h := NewEventTaskHandler(reminders, lifecycle, memory.NewRepo(), fakeVerifier{})
+
+			body := `{"type":"event_callback","team_id":"T1","event_id":"Ev1","event":` + tt.event + `}`
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, newEventTaskRequest(t, body))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if len(reminders.messages) != tt.wantMessages || len(reminders.reactions) != tt.wantReactions {
+				t.Errorf("messages = %d, reactions = %d, want %d, %d", len(reminders.messages), len(reminders.reactions), tt.wantMessages, tt.wantReactions)
+			}
+			if inactive := len(lifecycle.deactivated) == 1; inactive != tt.wantInactive {
+				t.Errorf("deactivated = %v, want deactivated = %v", lifecycle.deactivated, tt.wantInactive)
+			}
+		})
+	}
+}
@@ -3,27 +3,34 @@ package handler
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"slack-bot/project/dto"
 	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/infrastructure/metrics"
 	"slack-bot/project/service"
 )
 
-// EventsHandler は Slack Events API からのイベントを処理します
+// enqueueTimeout は受信したイベントをタスクキューに登録する処理の上限です
+// Slack の3秒制限に収まるようにし、超えた場合はエラーで応答して再送で登録し直します
+const enqueueTimeout = 2 * time.Second
+
+// EventsHandler は Slack Events API からのイベントを受信し、タスクキューに登録します
+// イベントの処理はキューから配送される EventTaskHandler（/tasks/event）で行います
 type EventsHandler struct {
-	signingSecret   string
-	reminderService service.ReminderService
+	signingSecret string
+	tasks         service.TaskPort
 }
 
 // NewEventsHandler はイベントハンドラーを作成します
-func NewEventsHandler(signingSecret string, reminderService service.ReminderService) *EventsHandler {
+func NewEventsHandler(signingSecret string, tasks service.TaskPort) *EventsHandler {
 	return &EventsHandler{
-		signingSecret:   signingSecret,
-		reminderService: reminderService,
+		signingSecret: signingSecret,
+		tasks:         tasks,
 	}
 }
 
@@ -73,56 +80,34 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// イベント処理
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	metrics.EventReceived(req.TeamID, req.Event.Type)
 
-	if err := h.handleEvent(ctx, req); err != nil {
-		fmt.Printf("イベント処理エラー: %v\n", err)
-		// Slack側への応答は成功にして、ログだけ記録
-		w.WriteHeader(http.StatusOK)
-		return
-	}
+	// イベントごとに相関 ID を発行し、イベントの処理・予約するタスクにも引き継ぐ
+	ctx := logging.WithCorrelationID(r.Context(), "")
 
-	w.WriteHeader(http.StatusOK)
-}
-
-// handleEvent は個別のイベントを処理します
-func (h *EventsHandler) handleEvent(ctx context.Context, req dto.SlackEventRequest) error {
-	// app_mention イベント (Bot メンション) または message イベント (返信確認用)
-	if req.Event.Type != "app_mention" && req.Event.Type != "message" {
-		return nil
-	}
+	// 応答が3秒を超えるなどで Slack が再送した場合は X-Slack-Retry-Num（1〜3）と理由が付与される
+	retryNum := r.Header.Get("X-Slack-Retry-Num")
+	slog.DebugContext(ctx, "イベント受信", "team_id", req.TeamID, "event_id", req.EventID, "type", req.Event.Type,
+		"retry_num", retryNum, "retry_reason", r.Header.Get("X-Slack-Retry-Reason"))
 
-	// Bot 自身のメッセージや bot_message は無視
-	if req.Event.BotID != "" || req.Event.SubType == "bot_message" {
-		return nil
-	}
-
-	// app_mention の場合のみメンション検知を処理
-	if req.Event.Type != "app_mention" {
-		return nil
-	}
-
-	// メンション検知イベントを service に渡す
-	// BotUserID は Authorization から取得
-	botUserID := ""
-	for _, auth := range req.Authorizations {
-		if auth.IsBot {
-			botUserID = auth.UserID
-			break
-		}
+	// イベントはタスクキュー（Cloud Tasks・ローカルスケジューラ）に永続化してから 200 で応答し、処理はキューからの配送で行う
+	// 登録に失敗した場合はエラーで応答し、Slack の再送で登録し直す
+	task := &service.EventTask{
+		TeamID:        req.TeamID,
+		EventID:       req.EventID,
+		EventType:     req.Event.Type,
+		Body:          body,
+		CorrelationID: logging.CorrelationID(ctx),
 	}
+	enqueueCtx, cancel := context.WithTimeout(ctx, enqueueTimeout)
+	defer cancel()
 
-	event := service.MentionEvent{
-		TeamID:       req.TeamID,
-		ChannelID:    req.Event.Channel,
-		MessageTS:    req.Event.Timestamp,
-		Text:         req.Event.Text,
-		BotUserID:    botUserID,
-		ParentUserID: req.Event.User,
-		NowUnix:      time.Now().Unix(),
+	if err := h.tasks.EnqueueEvent(enqueueCtx, task); err != nil {
+		metrics.EventRejected(req.TeamID, req.Event.Type)
+		slog.ErrorContext(ctx, "イベントのタスク登録失敗", "team_id", req.TeamID, "event_id", req.EventID, "type", req.Event.Type, "retry_num", retryNum, "error", err)
+		http.Error(w, "イベント登録失敗", http.StatusInternalServerError)
+		return
 	}
 
-	return h.reminderService.OnMention(ctx, &event)
+	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"slack-bot/project/service"
+)
+
+const testSigningSecret = "signing-secret"
+
+// fakeEventTasks は登録したイベントを記録する TaskPort のテスト用実装です
+type fakeEventTasks struct {
+	events []*service.EventTask
+	err    error
+}
+
+func (f *fakeEventTasks) EnqueueRemind(ctx context.Context, runAt int64, payload *service.TaskPayload) error {
+	return nil
+}
+
+func (f *fakeEventTasks) EnqueueEscalate(ctx context.Context, runAt int64, payload *service.TaskPayload) error {
+	return nil
+}
+
+func (f *fakeEventTasks) EnqueueEvent(ctx context.Context, task *service.EventTask) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.events = append(f.events, task)
+	return nil
+}
+
+// newSignedEventRequest は Slack と同じ方法で署名したイベント受信リクエストを作成します
+func newSignedEventRequest(body, secret string) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestEventsHandler(t *testing.T) {
+	const mention = `{"type":"event_callback","team_id":"T1","event_id":"Ev1","event":{"type":"app_mention","user":"U0","channel":"C1","ts":"1700000000.000100"}}`
+
+	tests := []struct {
+		name        string
+		body        string
+		secret      string
+		enqueueErr  error
+		wantStatus  int
+		wantBody    string
+		wantEnqueue bool
+	}{
+		{
+			name:       "url_verification には challenge を返す",
+			body:       `{"type":"url_verification","challenge":"abc123"}`,
+			secret:     "wrong",
+			wantStatus: http.StatusOK,
+			wantBody:   "abc123",
+		},
+		{
+			name:        "署名済みのイベントはタスクキューに登録して 200 で応答する",
+			body:        mention,
+			secret:      testSigningSecret,
+			wantStatus:  http.StatusOK,
+			wantEnqueue: true,
+		},
+		{
+			name:       "署名が不正なイベントは拒否する",
+			body:       mention,
+			secret:     "wrong",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "登録に失敗した場合はエラーで応答して Slack に再送させる",
+			body:       mention,
+			secret:     testSigningSecret,
+			enqueueErr: errors.New("cloudtasks: unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "event_callback 以外は登録しない",
+			body:       `{"type":"app_rate_limited","team_id":"T1"}`,
+			secret:     testSigningSecret,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := &fakeEventTasks{err: tt.enqueueErr}
+			h := NewEventsHandler(testSigningSecret, tasks)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, newSignedEventRequest(tt.body, tt.secret))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if got := len(tasks.events) == 1; got != tt.wantEnqueue {
+				t.Fatalf("enqueued = %d, want enqueued = %v", len(tasks.events), tt.wantEnqueue)
+			}
+			if tt.wantEnqueue {
+				task := tasks.events[0]
+				if task.TeamID != "T1" || task.EventID != "Ev1" || task.EventType != "app_mention" || string(task.Body) != tt.body || task.CorrelationID == "" {
+					t.Errorf("enqueued task = %+v, want event Ev1 with body and correlation ID", task)
+				}
+			}
+		})
+	}
+}
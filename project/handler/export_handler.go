@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/service"
+)
+
+// defaultExportWindow はエクスポート期間の開始を省略した場合の期間です
+const defaultExportWindow = 30 * 24 * time.Hour
+
+// exportHeader は CSV エクスポートの列名です
+var exportHeader = []string{
+	"team_id", "channel_id", "message_ts", "thread_ts", "mentioned_user_id", "parent_user_id",
+	"created_at", "status", "outcome", "reminded_at", "escalated_at", "closed_at", "response_seconds",
+}
+
+// StatsExportHandler はメンションの応答記録を CSV でエクスポートします（応答時間の分析用）
+type StatsExportHandler struct {
+	adminToken       string
+	analyticsService service.AnalyticsService
+}
+
+// NewStatsExportHandler はエクスポートハンドラーを作成します
+// adminToken が空の場合はエンドポイントを無効化します（常に 404）
+func NewStatsExportHandler(adminToken string, analyticsService service.AnalyticsService) *StatsExportHandler {
+	return &StatsExportHandler{
+		adminToken:       adminToken,
+		analyticsService: analyticsService,
+	}
+}
+
+// ServeHTTP は /admin/stats/export エンドポイント
+// GET /admin/stats/export?team_id=T123&from=2026-01-01&to=2026-02-01
+// from・to は YYYY-MM-DD（UTC）または RFC3339。to の省略時は現在、from の省略時は to の30日前
+func (h *StatsExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.adminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !validAdminToken(r, h.adminToken) {
+		http.Error(w, "認証失敗", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	teamID := query.Get("team_id")
+	if teamID == "" {
+		http.Error(w, "team_id は必須です", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now()
+	if s := query.Get("to"); s != "" {
+		t, err := parseExportTime(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultExportWindow)
+	if s := query.Get("from"); s != "" {
+		t, err := parseExportTime(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		http.Error(w, "from は to より前を指定してください", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(r.Context(), ""), 60*time.Second)
+	defer cancel()
+
+	mentions, err := h.analyticsService.Export(ctx, teamID, from.Unix(), to.Unix())
+	if err != nil {
+		slog.ErrorContext(ctx, "エクスポートエラー", "team_id", teamID, "error", err)
+		http.Error(w, "エクスポート失敗", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="mentions_%s_%s_%s.csv"`,
+		teamID, from.UTC().Format("20060102"), to.UTC().Format("20060102")))
+
+	cw := csv.NewWriter(w)
+	cw.Write(exportHeader)
+	for _, m := range mentions {
+		cw.Write(exportRecord(m))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		slog.ErrorContext(ctx, "エクスポート書き込みエラー", "team_id", teamID, "error", err)
+	}
+}
+
+// validAdminToken は Authorization ヘッダーの Bearer トークンが adminToken と一致するかを返します
+// タイミング攻撃対策で固定時間比較を行います
+func validAdminToken(r *http.Request, adminToken string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+// parseExportTime は YYYY-MM-DD（UTC の0時）または RFC3339 形式の日時を解析します
+func parseExportTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("日時の形式が不正です（YYYY-MM-DD または RFC3339）: %s", s)
+	}
+	return t, nil
+}
+
+// exportRecord はメンションを CSV の1行に変換します（本文・表示名は含めない）
+func exportRecord(m *domain.Mention) []string {
+	status := m.Status
+	if status == "" {
+		status = domain.MentionStatusOpen
+	}
+	responseSeconds := ""
+	if seconds, ok := m.ResponseSeconds(); ok {
+		responseSeconds = strconv.FormatInt(seconds, 10)
+	}
+	return []string{
+		m.TeamID, m.ChannelID, m.MessageTS, m.ThreadTS, m.MentionedUserID, m.ParentUserID,
+		formatExportTime(m.CreatedAt), string(status), string(m.Outcome),
+		formatExportTime(m.RemindedAt), formatExportTime(m.EscalatedAt), formatExportTime(m.ClosedAt),
+		responseSeconds,
+	}
+}
+
+// formatExportTime は Unix 秒を RFC3339（UTC）に変換します（0の場合は空）
+func formatExportTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/dto"
+	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/service"
+)
+
+// InteractionsHandler は Slack インタラクション（リマインドのボタン操作）を処理します
+type InteractionsHandler struct {
+	signingSecret   string
+	reminderService service.ReminderService
+	httpClient      *http.Client // response_url への応答用
+}
+
+// NewInteractionsHandler はインタラクションハンドラーを作成します
+func NewInteractionsHandler(signingSecret string, reminderService service.ReminderService) *InteractionsHandler {
+	return &InteractionsHandler{
+		signingSecret:   signingSecret,
+		reminderService: reminderService,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ServeHTTP は Slack インタラクション受信エンドポイントです
+func (h *InteractionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// body を読み込む（署名検証用）
+	bodyBytes, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "リクエスト本体の読み込み失敗", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Slack 署名検証
+	if err := httpsec.VerifySlackSignature(h.signingSecret,
+		r.Header.Get("X-Slack-Signature"),
+		r.Header.Get("X-Slack-Request-Timestamp"),
+		string(bodyBytes)); err != nil {
+		http.Error(w, "署名検証失敗", http.StatusUnauthorized)
+		return
+	}
+
+	// payload フィールドの JSON をパース
+	values := parseFormFromBytes(bodyBytes)
+	var payload dto.SlackInteractionPayload
+	if err := json.Unmarshal([]byte(values.Get("payload")), &payload); err != nil {
+		http.Error(w, "JSON パース失敗", http.StatusBadRequest)
+		return
+	}
+
+	// ボタン・選択メニューの操作のみ処理
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(context.WithoutCancel(r.Context()), ""), 10*time.Second)
+	defer cancel()
+
+	res := h.handleAction(ctx, payload.User.ID, payload.Actions[0])
+	if res != nil && payload.ResponseURL != "" {
+		if err := h.respond(ctx, payload.ResponseURL, res); err != nil {
+			slog.ErrorContext(ctx, "インタラクション応答失敗", "team_id", payload.Team.ID, "user_id", payload.User.ID, "error", err)
+		}
+	}
+
+	// Slack には処理結果に関わらず 200 を返す（結果は response_url で通知済み）
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleAction は操作に応じてサービスを呼び出し、response_url に送る応答を返します
+func (h *InteractionsHandler) handleAction(ctx context.Context, actorUserID string, action dto.SlackBlockAction) *dto.SlackActionResponse {
+	now := time.Now().Unix()
+
+	switch action.ActionID {
+	case dto.ActionIDReminderAck:
+		ref, err := service.ParseReminderRef(action.Value)
+		if err != nil {
+			return ephemeralActionResponse("リマインド情報を読み取れませんでした")
+		}
+		if err := h.reminderService.Acknowledge(ctx, ref, actorUserID, now); err != nil {
+			return actionErrorResponse(ctx, "Acknowledge", err)
+		}
+		return &dto.SlackActionResponse{
+			Text:            fmt.Sprintf("👀 <@%s> さんが確認済みです（以後のリマインドは停止しました）", ref.UserID),
+			ReplaceOriginal: true,
+		}
+
+	case dto.ActionIDReminderSnooze:
+		if action.SelectedOption == nil {
+			return nil
+		}
+		ref, d, err := service.ParseSnoozeValue(action.SelectedOption.Value)
+		if err != nil {
+			return ephemeralActionResponse("スヌーズ期間を読み取れませんでした")
+		}
+		until, err := h.reminderService.Snooze(ctx, ref, actorUserID, d, now)
+		if err != nil {
+			return actionErrorResponse(ctx, "Snooze", err)
+		}
+		return &dto.SlackActionResponse{
+			Text:            fmt.Sprintf("⏰ <@%s> さんがスヌーズ中です（<!date^%d^{date_short} {time}|%s> に再通知します）", ref.UserID, until, time.Unix(until, 0).Format(time.RFC3339)),
+			ReplaceOriginal: true,
+		}
+
+	case dto.ActionIDReminderDecline:
+		ref, err := service.ParseReminderRef(action.Value)
+		if err != nil {
+			return ephemeralActionResponse("リマインド情報を読み取れませんでした")
+		}
+		if err := h.reminderService.Decline(ctx, ref, actorUserID, now); err != nil {
+			return actionErrorResponse(ctx, "Decline", err)
+		}
+		return &dto.SlackActionResponse{
+			Text:            fmt.Sprintf("🙅 <@%s> さんは担当外とのことです（メンション送信者に通知しました）", ref.UserID),
+			ReplaceOriginal: true,
+		}
+
+	default:
+		// このアプリが付けていない操作は無視
+		return nil
+	}
+}
+
+// respond は response_url に応答を送信します
+func (h *InteractionsHandler) respond(ctx context.Context, responseURL string, res *dto.SlackActionResponse) error {
+	body, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("応答 JSON 化失敗: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("リクエスト作成失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("リクエスト送信失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("予期しないステータス: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ephemeralActionResponse は操作したユーザーのみに見える応答を作成します（元メッセージは残す）
+func ephemeralActionResponse(text string) *dto.SlackActionResponse {
+	return &dto.SlackActionResponse{
+		ResponseType:    "ephemeral",
+		Text:            text,
+		ReplaceOriginal: false,
+	}
+}
+
+// actionErrorResponse はサービスのエラーを操作したユーザー向けの応答に変換します
+func actionErrorResponse(ctx context.Context, op string, err error) *dto.SlackActionResponse {
+	switch {
+	case errors.Is(err, domain.ErrInsufficientPermission):
+		return ephemeralActionResponse("このリマインドはメンションされた本人のみ操作できます")
+	case errors.Is(err, domain.ErrInvalidMentionState), errors.Is(err, domain.ErrMentionNotFound):
+		return ephemeralActionResponse("このリマインドはすでに完了しています")
+	default:
+		slog.ErrorContext(ctx, "インタラクション処理失敗", "op", op, "error", err)
+		return ephemeralActionResponse("操作に失敗しました。時間をおいて再度お試しください")
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"slack-bot/project/infrastructure/metrics"
+)
+
+// MetricsHandler は Prometheus 形式のメトリクスを出力します
+type MetricsHandler struct {
+	adminToken string
+	metrics    http.Handler
+}
+
+// NewMetricsHandler はメトリクスハンドラーを作成します
+// adminToken が空の場合はエンドポイントを無効化します（常に 404）
+func NewMetricsHandler(adminToken string) *MetricsHandler {
+	return &MetricsHandler{
+		adminToken: adminToken,
+		metrics:    metrics.Handler(),
+	}
+}
+
+// ServeHTTP は /metrics エンドポイント
+// GET /metrics（Authorization: Bearer <ADMIN_API_TOKEN>）
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.adminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validAdminToken(r, h.adminToken) {
+		http.Error(w, "認証失敗", http.StatusUnauthorized)
+		return
+	}
+
+	h.metrics.ServeHTTP(w, r)
+}
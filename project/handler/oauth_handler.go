@@ -4,41 +4,120 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"slack-bot/project/domain"
 	"slack-bot/project/dto"
 	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
 	"slack-bot/project/infrastructure/secret"
+	"slack-bot/project/service"
+)
+
+const (
+	// oauthStateCookie は OAuth state を保存する cookie 名です
+	oauthStateCookie = "slack_oauth_state"
+
+	// oauthStateTTL は OAuth state の有効期限です（インストール画面での操作時間を想定）
+	oauthStateTTL = 10 * time.Minute
+
+	// slackAuthorizeURL は Slack の OAuth 認可画面の URL です
+	slackAuthorizeURL = "https://slack.com/oauth/v2/authorize"
 )
 
 // OAuthHandler は Slack OAuth フロー（インストール完了）を処理します
 type OAuthHandler struct {
 	cfg              *config.Config
 	tenantRepository domain.TenantRepository
-	secretManager    *secret.Manager
+	secretManager    secret.Store
+	tokenCache       service.TokenCachePort // 再インストール時に古いトークンのクライアントを破棄
 }
 
 // NewOAuthHandler は OAuth ハンドラーを作成します
-func NewOAuthHandler(cfg *config.Config, tenantRepository domain.TenantRepository, secretManager *secret.Manager) *OAuthHandler {
+func NewOAuthHandler(cfg *config.Config, tenantRepository domain.TenantRepository, secretManager secret.Store, tokenCache service.TokenCachePort) *OAuthHandler {
 	return &OAuthHandler{
 		cfg:              cfg,
 		tenantRepository: tenantRepository,
 		secretManager:    secretManager,
+		tokenCache:       tokenCache,
+	}
+}
+
+// ServeInstall はインストール開始処理 (/slack/install)
+// 署名付き state を発行して cookie に保存し、Slack の認可画面へリダイレクトします
+func (h *OAuthHandler) ServeInstall(w http.ResponseWriter, r *http.Request) {
+	state, err := httpsec.NewOAuthState(h.cfg.OAuthStateSecret, time.Now(), oauthStateTTL)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "OAuth state 生成失敗", "error", err)
+		http.Error(w, "インストールを開始できませんでした", http.StatusInternalServerError)
+		return
 	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/slack",
+		MaxAge:   int(oauthStateTTL / time.Second),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.cfg.OAuthRedirectURL, "https://"),
+		// Slack からのリダイレクト（トップレベルの GET 遷移）で cookie が送られるよう Lax を指定
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	query := url.Values{}
+	query.Set("client_id", h.cfg.SlackClientID)
+	query.Set("scope", h.cfg.SlackBotScopes)
+	query.Set("redirect_uri", h.cfg.OAuthRedirectURL)
+	query.Set("state", state)
+
+	http.Redirect(w, r, slackAuthorizeURL+"?"+query.Encode(), http.StatusFound)
 }
 
 // ServeHTTP は OAuth コールバック処理 (/oauth_redirect)
 func (h *OAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(context.WithoutCancel(r.Context()), ""), 10*time.Second)
+	defer cancel()
+
+	// state を検証（/slack/install で発行した cookie と一致し、署名・有効期限が正しいこと）
+	// 検証前に code を使うと、第三者が用意した code で偽のインストールを完了できてしまう
+	state := r.URL.Query().Get("state")
+	var cookieValue string
+	if c, err := r.Cookie(oauthStateCookie); err == nil {
+		cookieValue = c.Value
+	}
+
+	// state は一度きりの使用とするため、検証結果に関わらず cookie を削除
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    "",
+		Path:     "/slack",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.cfg.OAuthRedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if err := httpsec.VerifyOAuthState(h.cfg.OAuthStateSecret, state, cookieValue, time.Now()); err != nil {
+		slog.WarnContext(ctx, "OAuth state 検証失敗", "error", err)
+		if errors.Is(err, httpsec.ErrOAuthStateExpired) {
+			http.Error(w, "インストールの有効期限が切れました。/slack/install からやり直してください", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "不正なインストールリクエストです。/slack/install からやり直してください", http.StatusForbidden)
+		return
+	}
+
 	// クエリパラメータから code を取得
 	code := r.URL.Query().Get("code")
-	state := r.URL.Query().Get("state")
-	log.Printf("OAuth callback: code=%s, state=%s", code, state)
+	slog.DebugContext(ctx, "OAuth callback: state 検証成功")
 
 	if code == "" {
 		http.Error(w, "code パラメータが不足しています", http.StatusBadRequest)
@@ -46,42 +125,42 @@ func (h *OAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Slack OAuth token 交換 API を呼び出す
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	tokenResp, err := h.exchangeToken(ctx, code)
 	if err != nil {
-		log.Printf("トークン交換失敗: %v", err)
+		slog.ErrorContext(ctx, "トークン交換失敗", "error", err)
 		http.Error(w, fmt.Sprintf("トークン交換失敗: %v", err), http.StatusBadRequest)
 		return
 	}
 
 	if !tokenResp.OK {
-		log.Printf("OAuth エラー: %s", tokenResp.Error)
+		slog.WarnContext(ctx, "OAuth エラー", "error", tokenResp.Error)
 		http.Error(w, fmt.Sprintf("OAuth エラー: %s", tokenResp.Error), http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("OAuth成功: TeamID=%s", tokenResp.Team.ID)
+	slog.InfoContext(ctx, "OAuth 成功", "team_id", tokenResp.Team.ID)
 
 	// Secret Manager にトークンを保存
-	secretName := fmt.Sprintf("slack_token_%s", tokenResp.Team.ID)
+	secretName := h.cfg.SecretTokenPrefix + tokenResp.Team.ID
 	if err := h.secretManager.PutSecret(ctx, secretName, tokenResp.AccessToken); err != nil {
-		log.Printf("トークン保存失敗: %v", err)
+		slog.ErrorContext(ctx, "トークン保存失敗", "team_id", tokenResp.Team.ID, "error", err)
 		http.Error(w, fmt.Sprintf("トークン保存失敗: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("Secret保存成功: %s", secretName)
+	slog.InfoContext(ctx, "トークン保存成功", "team_id", tokenResp.Team.ID, "secret_name", secretName)
+
+	// 新しいトークンを使うようキャッシュ済みクライアントを破棄
+	h.tokenCache.Evict(tokenResp.Team.ID)
 
 	// Tenant として登録
 	if err := h.tenantRepository.UpsertBotTokenSecret(ctx, tokenResp.Team.ID, secretName); err != nil {
-		log.Printf("テナント登録失敗: %v", err)
+		slog.ErrorContext(ctx, "テナント登録失敗", "team_id", tokenResp.Team.ID, "error", err)
 		http.Error(w, fmt.Sprintf("テナント登録失敗: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("テナント登録成功: TeamID=%s", tokenResp.Team.ID)
+	slog.InfoContext(ctx, "テナント登録成功", "team_id", tokenResp.Team.ID)
 
 	// インストール成功画面を表示
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -117,7 +196,7 @@ func (h *OAuthHandler) exchangeToken(ctx context.Context, code string) (*dto.Sla
 	formData.Set("code", code)
 	formData.Set("redirect_uri", h.cfg.OAuthRedirectURL)
 
-	log.Printf("OAuth token exchange: client_id=%s, redirect_uri=%s", h.cfg.SlackClientID, h.cfg.OAuthRedirectURL)
+	slog.DebugContext(ctx, "OAuth トークン交換", "redirect_uri", h.cfg.OAuthRedirectURL)
 
 	// POST リクエスト
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBufferString(formData.Encode()))
@@ -139,8 +218,6 @@ func (h *OAuthHandler) exchangeToken(ctx context.Context, code string) (*dto.Sla
 		return nil, fmt.Errorf("レスポンス本体読み込み失敗: %w", err)
 	}
 
-	log.Printf("Slack OAuth response: %s", string(respBody))
-
 	var tokenResp dto.SlackTokenResponse
 	if err := json.Unmarshal(respBody, &tokenResp); err != nil {
 		return nil, fmt.Errorf("レスポンス JSON 解析失敗: %w", err)
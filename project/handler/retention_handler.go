@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/service"
+)
+
+// RetentionSweepHandler は保持期間を過ぎたメンション記録の定期削除（Cloud Scheduler・ローカルスケジューラ）を受け付けます
+type RetentionSweepHandler struct {
+	adminToken       string
+	retentionService service.RetentionService
+}
+
+// NewRetentionSweepHandler は定期削除ハンドラーを作成します
+// adminToken が空の場合はエンドポイントを無効化します（常に 404）
+func NewRetentionSweepHandler(adminToken string, retentionService service.RetentionService) *RetentionSweepHandler {
+	return &RetentionSweepHandler{
+		adminToken:       adminToken,
+		retentionService: retentionService,
+	}
+}
+
+// ServeHTTP は /cron/retention-sweep エンドポイント
+// POST /cron/retention-sweep（Authorization: Bearer <ADMIN_API_TOKEN>）
+// 1回の実行で削除しきれなかった記録は次回の実行で削除します
+func (h *RetentionSweepHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.adminToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validAdminToken(r, h.adminToken) {
+		http.Error(w, "認証失敗", http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(logging.WithCorrelationID(r.Context(), ""), 5*time.Minute)
+	defer cancel()
+
+	deleted, err := h.retentionService.Sweep(ctx, time.Now().Unix())
+	if err != nil {
+		slog.ErrorContext(ctx, "メンション記録の定期削除エラー", "deleted", deleted, "error", err)
+		http.Error(w, "定期削除失敗", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(ctx, "メンション記録の定期削除完了", "deleted", deleted)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "deleted=%d", deleted)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"time"
@@ -10,6 +12,39 @@ import (
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 )
 
+// ストア実装の種類
+const (
+	// StoreBackendFirestore は Firestore を使用します（デフォルト）
+	StoreBackendFirestore = "firestore"
+
+	// StoreBackendMemory はインメモリストアを使用します（ローカル開発用、再起動でデータ消失）
+	StoreBackendMemory = "memory"
+)
+
+// タスクスケジューラ実装の種類
+const (
+	// TasksBackendCloudTasks は Cloud Tasks を使用します（デフォルト）
+	TasksBackendCloudTasks = "cloudtasks"
+
+	// TasksBackendLocal はプロセス内スケジューラを使用します（単一 VM / docker-compose 向け）
+	TasksBackendLocal = "local"
+)
+
+// トレースの出力先の種類
+const (
+	// TracesExporterNone はトレースを出力しません（デフォルト）
+	TracesExporterNone = "none"
+
+	// TracesExporterOTLP は OTLP（gRPC）でコレクターに送信します（送信先は OTEL_EXPORTER_OTLP_ENDPOINT）
+	TracesExporterOTLP = "otlp"
+
+	// TracesExporterStdout は標準出力に出力します（ローカル開発用）
+	TracesExporterStdout = "stdout"
+)
+
+// defaultSlackBotScopes は /slack/install で要求する Bot スコープのデフォルト値です
+const defaultSlackBotScopes = "app_mentions:read,channels:history,groups:history,im:history,mpim:history,chat:write,commands,users:read,im:write,reactions:read"
+
 // Config は環境変数から読み込まれるアプリケーション設定を表します
 type Config struct {
 	// 基本設定
@@ -17,43 +52,102 @@ type Config struct {
 	GcpProject string
 	Region     string
 
-	// Firestore設定
+	// ストア設定
+	StoreBackend string
+
+	// Firestore設定（StoreBackend が firestore の場合のみ必須）
 	FirestoreProjectID string
 	CollectionTenants  string
 	CollectionMentions string
+	CollectionEvents   string // Slack イベントの処理記録（再送の重複排除）
 
 	// OAuth設定
 	OAuthRedirectURL string
-	OAuthStateSecret string // Secret Manager から読み込み
+	OAuthStateSecret string // Secret Manager（ローカル実装のみの場合は環境変数）から読み込み
+	SlackBotScopes   string // /slack/install で要求する Bot スコープ（カンマ区切り）
 
-	// Cloud Tasks設定
+	// タスクスケジューラ設定
+	TasksBackend string
+
+	// Cloud Tasks設定（TasksBackend が cloudtasks の場合のみ必須）
 	TasksQueueRemind    string
 	TasksQueueEscalate  string
 	TasksAudience       string
 	TasksServiceAccount string
 
+	// ローカルスケジューラ設定（TasksBackend が local の場合のみ使用）
+	LocalTasksFile   string // 未実行ジョブの永続化ファイル
+	LocalTasksTarget string // /check/* の配送先ベース URL
+
+	// タスクコールバックの署名シークレット（TasksBackend が local の場合のみ使用。未設定時は起動ごとに生成）
+	TasksCallbackSecret string
+
 	// Slack API設定
-	SlackClientID      string // Secret Manager から読み込み
-	SlackClientSecret  string // Secret Manager から読み込み
-	SlackSigningSecret string // Secret Manager から読み込み
+	SlackClientID      string // Secret Manager（ローカル実装のみの場合は環境変数）から読み込み
+	SlackClientSecret  string // Secret Manager（ローカル実装のみの場合は環境変数）から読み込み
+	SlackSigningSecret string // Secret Manager（ローカル実装のみの場合は環境変数）から読み込み
 	SecretTokenPrefix  string
+	SlackBotToken      string // 全ワークスペース共通の Bot トークン（ローカル実装のみの場合に使用。未設定時は OAuth インストールで取得）
 
 	// リマインド設定
 	RemindDuration   time.Duration
 	EscalateDuration time.Duration
+	ExpireDuration   time.Duration // 全ステップ実行後も未返信のメンションを期限切れにするまでの時間（メンションから。0 で無効）
+
+	// データ保持設定
+	RetentionPeriod time.Duration // メンション記録の保持期間のデフォルト（メンションから。0 で削除しない）
+
+	// 管理API設定
+	AdminAPIToken string // /admin/*・/cron/*・/metrics の Bearer トークン（未設定の場合は管理API・定期実行・メトリクスを無効化）
+
+	// トレース設定
+	TracesExporter string
+}
+
+// UsesSecretManager は Secret Manager（設定のシークレット・ワークスペースごとの Bot トークン）を使うかどうかを返します
+// ストア・タスクスケジューラがともにローカル実装の場合は GCP を使わず、シークレットは環境変数から読み込みます
+func (c *Config) UsesSecretManager() bool {
+	return usesSecretManager(c.StoreBackend, c.TasksBackend)
+}
+
+// usesSecretManager はストア・タスクスケジューラの実装から Secret Manager を使うかどうかを判定します
+func usesSecretManager(storeBackend, tasksBackend string) bool {
+	return storeBackend != StoreBackendMemory || tasksBackend != TasksBackendLocal
 }
 
 // NewConfig は環境変数から設定を読み込み、Config構造体を返します
 // センシティブな情報（Slack認証情報など）はSecret Managerから取得します
+// ストア・タスクスケジューラがともにローカル実装の場合は Secret Manager を使わず、環境変数から取得します
 func NewConfig(ctx context.Context) (*Config, error) {
-	gcpProject := mustGetEnv("GCP_PROJECT")
+	storeBackend := os.Getenv("STORE_BACKEND")
+	if storeBackend == "" {
+		storeBackend = StoreBackendFirestore // デフォルト値
+	}
+	if storeBackend != StoreBackendFirestore && storeBackend != StoreBackendMemory {
+		return nil, fmt.Errorf("invalid STORE_BACKEND: %s (firestore または memory を指定してください)", storeBackend)
+	}
 
-	// Secret Manager クライアントを初期化
-	secretClient, err := secretmanager.NewClient(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("Secret Manager クライアント初期化失敗: %v", err)
+	tasksBackend := os.Getenv("TASKS_BACKEND")
+	if tasksBackend == "" {
+		tasksBackend = TasksBackendCloudTasks // デフォルト値
+	}
+	if tasksBackend != TasksBackendCloudTasks && tasksBackend != TasksBackendLocal {
+		return nil, fmt.Errorf("invalid TASKS_BACKEND: %s (cloudtasks または local を指定してください)", tasksBackend)
+	}
+
+	// GCP_PROJECT は Secret Manager・Cloud Tasks を使う場合のみ必須
+	gcpProject := os.Getenv("GCP_PROJECT")
+	secrets := &secretLoader{}
+	if usesSecretManager(storeBackend, tasksBackend) {
+		gcpProject = mustGetEnv("GCP_PROJECT")
+
+		secretClient, err := secretmanager.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Secret Manager クライアント初期化失敗: %v", err)
+		}
+		defer secretClient.Close()
+		secrets = &secretLoader{client: secretClient, projectID: gcpProject}
 	}
-	defer secretClient.Close()
 
 	remindAfter := os.Getenv("REMIND_AFTER")
 	if remindAfter == "" {
@@ -73,62 +167,155 @@ func NewConfig(ctx context.Context) (*Config, error) {
 		return nil, fmt.Errorf("invalid ESCALATE_AFTER format: %v", err)
 	}
 
-	// Secret Manager から Slack 認証情報を取得
-	slackSigningSecret, err := getSecretFromManager(ctx, secretClient, gcpProject, "slack-signing-secret")
+	expireAfter := os.Getenv("EXPIRE_AFTER")
+	if expireAfter == "" {
+		expireAfter = "168h" // デフォルト値（7日）
+	}
+	expireDuration, err := time.ParseDuration(expireAfter)
 	if err != nil {
-		return nil, fmt.Errorf("SLACK_SIGNING_SECRET 取得失敗: %v", err)
+		return nil, fmt.Errorf("invalid EXPIRE_AFTER format: %v", err)
 	}
 
-	slackClientID, err := getSecretFromManager(ctx, secretClient, gcpProject, "slack-client-id")
+	retentionPeriodEnv := os.Getenv("RETENTION_PERIOD")
+	if retentionPeriodEnv == "" {
+		retentionPeriodEnv = "2160h" // デフォルト値（90日）
+	}
+	retentionPeriod, err := time.ParseDuration(retentionPeriodEnv)
 	if err != nil {
-		return nil, fmt.Errorf("SLACK_CLIENT_ID 取得失敗: %v", err)
+		return nil, fmt.Errorf("invalid RETENTION_PERIOD format: %v", err)
 	}
 
-	slackClientSecret, err := getSecretFromManager(ctx, secretClient, gcpProject, "slack-client-secret")
+	// Slack 認証情報を取得（Secret Manager、またはローカル実装のみの場合は環境変数）
+	slackSigningSecret, err := secrets.get(ctx, "SLACK_SIGNING_SECRET", "slack-signing-secret")
 	if err != nil {
-		return nil, fmt.Errorf("SLACK_CLIENT_SECRET 取得失敗: %v", err)
+		return nil, err
 	}
 
-	oauthStateSecret, err := getSecretFromManager(ctx, secretClient, gcpProject, "oauth-state-secret")
+	slackClientID, err := secrets.get(ctx, "SLACK_CLIENT_ID", "slack-client-id")
 	if err != nil {
-		return nil, fmt.Errorf("OAUTH_STATE_SECRET 取得失敗: %v", err)
+		return nil, err
+	}
+
+	slackClientSecret, err := secrets.get(ctx, "SLACK_CLIENT_SECRET", "slack-client-secret")
+	if err != nil {
+		return nil, err
+	}
+
+	oauthStateSecret, err := secrets.get(ctx, "OAUTH_STATE_SECRET", "oauth-state-secret")
+	if err != nil {
+		return nil, err
+	}
+
+	tracesExporter := os.Getenv("TRACES_EXPORTER")
+	if tracesExporter == "" {
+		tracesExporter = TracesExporterNone // デフォルト値
+	}
+	if tracesExporter != TracesExporterNone && tracesExporter != TracesExporterOTLP && tracesExporter != TracesExporterStdout {
+		return nil, fmt.Errorf("invalid TRACES_EXPORTER: %s (none・otlp・stdout のいずれかを指定してください)", tracesExporter)
 	}
 
 	config := &Config{
 		// 基本設定
 		AppBaseURL: mustGetEnv("APP_BASE_URL"),
 		GcpProject: gcpProject,
-		Region:     mustGetEnv("REGION"),
+		Region:     os.Getenv("REGION"),
 
-		// Firestore設定
-		FirestoreProjectID: mustGetEnv("FIRESTORE_PROJECT_ID"),
-		CollectionTenants:  mustGetEnv("FS_COLLECTION_TENANTS"),
-		CollectionMentions: mustGetEnv("FS_COLLECTION_MENTIONS"),
+		// ストア設定
+		StoreBackend: storeBackend,
 
 		// OAuth設定
 		OAuthRedirectURL: mustGetEnv("OAUTH_REDIRECT_URL"),
 		OAuthStateSecret: oauthStateSecret,
+		SlackBotScopes:   getEnvOrDefault("SLACK_BOT_SCOPES", defaultSlackBotScopes),
 
-		// Cloud Tasks設定
-		TasksQueueRemind:    mustGetEnv("TASKS_QUEUE_REMIND"),
-		TasksQueueEscalate:  mustGetEnv("TASKS_QUEUE_ESCALATE"),
-		TasksAudience:       mustGetEnv("TASKS_AUDIENCE"),
-		TasksServiceAccount: mustGetEnv("TASKS_SERVICE_ACCOUNT"),
+		// タスクスケジューラ設定
+		TasksBackend: tasksBackend,
 
 		// Slack API設定（Secret Manager から取得）
 		SlackClientID:      slackClientID,
 		SlackClientSecret:  slackClientSecret,
 		SlackSigningSecret: slackSigningSecret,
-		SecretTokenPrefix:  mustGetEnv("SECRET_TOKEN_PREFIX"),
+		SecretTokenPrefix:  getEnvOrDefault("SECRET_TOKEN_PREFIX", "slack_token_"),
 
 		// リマインド設定
 		RemindDuration:   remindDuration,
 		EscalateDuration: escalateDuration,
+		ExpireDuration:   expireDuration,
+
+		// データ保持設定
+		RetentionPeriod: retentionPeriod,
+
+		// 管理API設定
+		AdminAPIToken: os.Getenv("ADMIN_API_TOKEN"),
+
+		// トレース設定
+		TracesExporter: tracesExporter,
+	}
+
+	// Firestore設定（インメモリストア使用時は不要）
+	if storeBackend == StoreBackendFirestore {
+		config.FirestoreProjectID = mustGetEnv("FIRESTORE_PROJECT_ID")
+		config.CollectionTenants = mustGetEnv("FS_COLLECTION_TENANTS")
+		config.CollectionMentions = mustGetEnv("FS_COLLECTION_MENTIONS")
+		config.CollectionEvents = getEnvOrDefault("FS_COLLECTION_EVENTS", "events")
+	}
+
+	// タスクスケジューラ設定
+	switch tasksBackend {
+	case TasksBackendCloudTasks:
+		config.Region = mustGetEnv("REGION")
+		config.TasksQueueRemind = mustGetEnv("TASKS_QUEUE_REMIND")
+		config.TasksQueueEscalate = mustGetEnv("TASKS_QUEUE_ESCALATE")
+		config.TasksAudience = mustGetEnv("TASKS_AUDIENCE")
+		config.TasksServiceAccount = mustGetEnv("TASKS_SERVICE_ACCOUNT")
+	case TasksBackendLocal:
+		config.LocalTasksFile = getEnvOrDefault("LOCAL_TASKS_FILE", "local_tasks.json")
+		config.LocalTasksTarget = getEnvOrDefault("LOCAL_TASKS_TARGET", "http://127.0.0.1:"+getEnvOrDefault("PORT", "8080"))
+
+		// /check/* への配送は HMAC 署名で認証する
+		// スケジューラは同じプロセス内で動くため、未設定の場合は起動ごとにランダムなシークレットを生成する
+		config.TasksCallbackSecret = os.Getenv("TASKS_CALLBACK_SECRET")
+		if config.TasksCallbackSecret == "" {
+			secret := make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, fmt.Errorf("TASKS_CALLBACK_SECRET 生成失敗: %v", err)
+			}
+			config.TasksCallbackSecret = hex.EncodeToString(secret)
+		}
+	}
+
+	// Secret Manager を使わない場合、Bot トークンは環境変数（全ワークスペース共通）から読み込む
+	if !config.UsesSecretManager() {
+		config.SlackBotToken = os.Getenv("SLACK_BOT_TOKEN")
 	}
 
 	return config, nil
 }
 
+// secretLoader は設定のシークレットを Secret Manager から読み込みます
+// client が nil（ストア・タスクスケジューラがともにローカル実装）の場合は環境変数から読み込みます
+type secretLoader struct {
+	client    *secretmanager.Client
+	projectID string
+}
+
+// get は secretName のシークレット（Secret Manager を使わない場合は環境変数 envKey）を取得します
+func (l *secretLoader) get(ctx context.Context, envKey, secretName string) (string, error) {
+	if l.client == nil {
+		value := os.Getenv(envKey)
+		if value == "" {
+			return "", fmt.Errorf("%s 取得失敗: STORE_BACKEND=memory・TASKS_BACKEND=local では環境変数 %s を設定してください", envKey, envKey)
+		}
+		return value, nil
+	}
+
+	value, err := getSecretFromManager(ctx, l.client, l.projectID, secretName)
+	if err != nil {
+		return "", fmt.Errorf("%s 取得失敗: %v", envKey, err)
+	}
+	return value, nil
+}
+
 // getSecretFromManager は Secret Manager から指定されたシークレットを取得します
 func getSecretFromManager(ctx context.Context, client *secretmanager.Client, projectID, secretName string) (string, error) {
 	name := fmt.Sprintf("projects/%s/secrets/%s/versions/latest", projectID, secretName)
@@ -158,3 +345,11 @@ func mustGetEnv(key string) string {
 	}
 	return value
 }
+
+// getEnvOrDefault は環境変数を取得し、存在しない場合はデフォルト値を返します
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
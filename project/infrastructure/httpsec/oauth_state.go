@@ -0,0 +1,87 @@
+package httpsec
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OAuth state 検証エラー
+var (
+	// ErrOAuthStateMissing は state パラメータまたは cookie がない場合のエラー
+	ErrOAuthStateMissing = errors.New("oauth state missing")
+
+	// ErrOAuthStateInvalid は state の形式・署名が不正、または cookie と一致しない場合のエラー
+	ErrOAuthStateInvalid = errors.New("oauth state invalid")
+
+	// ErrOAuthStateExpired は state の有効期限が切れている場合のエラー
+	ErrOAuthStateExpired = errors.New("oauth state expired")
+)
+
+// NewOAuthState は OAuth インストールフロー用の署名付き state を生成します
+// 形式: "<nonce>.<有効期限(Unix秒)>.<HMAC-SHA256(secret, nonce.有効期限)>"
+// 同じ値を cookie にも保存し、リダイレクト時に両者の一致と署名・有効期限を検証します（CSRF 対策）
+func NewOAuthState(secret string, now time.Time, ttl time.Duration) (string, error) {
+	if secret == "" {
+		return "", fmt.Errorf("oauth state secret is empty")
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	payload := fmt.Sprintf("%s.%d", base64.RawURLEncoding.EncodeToString(nonce), now.Add(ttl).Unix())
+	return payload + "." + computeStateMAC(secret, payload), nil
+}
+
+// VerifyOAuthState は state（クエリパラメータ）と cookie の値を検証します
+// どちらかが空なら ErrOAuthStateMissing、不一致や署名不正なら ErrOAuthStateInvalid、
+// 期限切れなら ErrOAuthStateExpired を返します
+func VerifyOAuthState(secret, state, cookieValue string, now time.Time) error {
+	if state == "" || cookieValue == "" {
+		return ErrOAuthStateMissing
+	}
+
+	// 定時間比較（タイミング攻撃対策）
+	if !hmac.Equal([]byte(state), []byte(cookieValue)) {
+		return ErrOAuthStateInvalid
+	}
+
+	i := strings.LastIndex(state, ".")
+	if i < 0 {
+		return ErrOAuthStateInvalid
+	}
+	payload, mac := state[:i], state[i+1:]
+	if secret == "" || !hmac.Equal([]byte(computeStateMAC(secret, payload)), []byte(mac)) {
+		return ErrOAuthStateInvalid
+	}
+
+	_, expStr, ok := strings.Cut(payload, ".")
+	if !ok {
+		return ErrOAuthStateInvalid
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil {
+		return ErrOAuthStateInvalid
+	}
+	if now.Unix() > exp {
+		return ErrOAuthStateExpired
+	}
+
+	return nil
+}
+
+// computeStateMAC は state の署名部分（16進数）を計算します
+func computeStateMAC(secret, payload string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(payload))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,70 @@
+package httpsec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuthState(t *testing.T) {
+	const secret = "state-secret"
+	now := time.Unix(1_700_000_000, 0)
+
+	state, err := NewOAuthState(secret, now, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewOAuthState() error = %v", err)
+	}
+
+	// 署名部分を書き換えた state（cookie も同じ値に揃えて署名の検証まで到達させる）
+	tampered := state[:strings.LastIndex(state, ".")+1] + strings.Repeat("0", 64)
+
+	tests := []struct {
+		name   string
+		secret string
+		state  string
+		cookie string
+		now    time.Time
+		want   error
+	}{
+		{"有効", secret, state, state, now, nil},
+		{"有効期限ちょうどは有効", secret, state, state, now.Add(10 * time.Minute), nil},
+		{"state なし", secret, "", state, now, ErrOAuthStateMissing},
+		{"cookie なし", secret, state, "", now, ErrOAuthStateMissing},
+		{"cookie と不一致", secret, state, state + "x", now, ErrOAuthStateInvalid},
+		{"署名不正", secret, tampered, tampered, now, ErrOAuthStateInvalid},
+		{"別のシークレットで署名", "other-secret", state, state, now, ErrOAuthStateInvalid},
+		{"シークレット未設定", "", state, state, now, ErrOAuthStateInvalid},
+		{"形式不正", secret, "no-dot", "no-dot", now, ErrOAuthStateInvalid},
+		{"期限切れ", secret, state, state, now.Add(10*time.Minute + time.Second), ErrOAuthStateExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyOAuthState(tt.secret, tt.state, tt.cookie, tt.now)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("VerifyOAuthState() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOAuthStateUnique(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	a, err := NewOAuthState("secret", now, time.Minute)
+	if err != nil {
+		t.Fatalf("NewOAuthState() error = %v", err)
+	}
+	b, err := NewOAuthState("secret", now, time.Minute)
+	if err != nil {
+		t.Fatalf("NewOAuthState() error = %v", err)
+	}
+	if a == b {
+		t.Errorf("NewOAuthState() returned the same state twice: %s", a)
+	}
+
+	if _, err := NewOAuthState("", now, time.Minute); err == nil {
+		t.Errorf("NewOAuthState() with empty secret error = nil, want error")
+	}
+}
@@ -0,0 +1,158 @@
+package httpsec
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/idtoken"
+)
+
+// タスクコールバック検証エラー
+var (
+	// ErrTaskAuthMissing は認証ヘッダー（Authorization または署名ヘッダー）がない場合のエラー
+	ErrTaskAuthMissing = errors.New("task callback credentials missing")
+
+	// ErrTaskAuthInvalid はトークン・署名が不正、または発行者・対象・サービスアカウントが一致しない場合のエラー
+	ErrTaskAuthInvalid = errors.New("task callback credentials invalid")
+)
+
+// ローカルスケジューラなど Cloud Tasks 以外のスケジューラが付与する署名ヘッダー
+const (
+	// TaskTimestampHeader は署名した時刻（Unix秒）のヘッダーです
+	TaskTimestampHeader = "X-Task-Timestamp"
+
+	// TaskSignatureHeader は "v1=<HMAC-SHA256 の16進数>" 形式の署名ヘッダーです
+	TaskSignatureHeader = "X-Task-Signature"
+)
+
+// taskSignatureTolerance は署名した時刻と受信時刻の許容差です（リプレイ対策）
+const taskSignatureTolerance = 5 * time.Minute
+
+// googleIssuers は Google が発行する ID トークンの iss の値です
+var googleIssuers = map[string]bool{
+	"https://accounts.google.com": true,
+	"accounts.google.com":         true,
+}
+
+// TaskVerifier は /check/remind・/check/escalate へのリクエストが
+// このアプリが予約したタスクのスケジューラから送られたものかを検証します
+type TaskVerifier interface {
+	// Verify はリクエストの認証情報を検証します。body は読み込み済みのリクエスト本体です
+	Verify(r *http.Request, body []byte) error
+}
+
+// oidcTaskVerifier は Cloud Tasks がタスクに付与する OIDC トークンを検証します
+type oidcTaskVerifier struct {
+	validator      *idtoken.Validator
+	audience       string
+	serviceAccount string
+}
+
+// NewOIDCTaskVerifier は Cloud Tasks の OIDC トークン（Authorization: Bearer）を検証する TaskVerifier を作成します
+// 署名は Google の公開鍵（Cache-Control に従ってキャッシュ）で検証し、
+// 発行者・有効期限・audience（TASKS_AUDIENCE）・サービスアカウント（TASKS_SERVICE_ACCOUNT）を確認します
+func NewOIDCTaskVerifier(ctx context.Context, audience, serviceAccount string) (TaskVerifier, error) {
+	if audience == "" || serviceAccount == "" {
+		return nil, fmt.Errorf("oidc task verifier requires audience and service account")
+	}
+
+	validator, err := idtoken.NewValidator(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create id token validator: %w", err)
+	}
+
+	return &oidcTaskVerifier{
+		validator:      validator,
+		audience:       audience,
+		serviceAccount: serviceAccount,
+	}, nil
+}
+
+// Verify は OIDC トークンの署名・発行者・有効期限・audience・サービスアカウントを検証します
+func (v *oidcTaskVerifier) Verify(r *http.Request, _ []byte) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ErrTaskAuthMissing
+	}
+
+	// 署名・有効期限・audience を検証
+	payload, err := v.validator.Validate(r.Context(), token, v.audience)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTaskAuthInvalid, err)
+	}
+
+	if !googleIssuers[payload.Issuer] {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrTaskAuthInvalid, payload.Issuer)
+	}
+
+	// Cloud Tasks はタスクに指定したサービスアカウントの ID トークンを発行する
+	email, _ := payload.Claims["email"].(string)
+	verified, _ := payload.Claims["email_verified"].(bool)
+	if email != v.serviceAccount || !verified {
+		return fmt.Errorf("%w: unexpected service account %q", ErrTaskAuthInvalid, email)
+	}
+
+	return nil
+}
+
+// hmacTaskVerifier は共有シークレットによる HMAC 署名を検証します
+type hmacTaskVerifier struct {
+	secret string
+}
+
+// NewHMACTaskVerifier は Cloud Tasks 以外のスケジューラ向けに、HMAC 署名ヘッダーを検証する TaskVerifier を作成します
+// 署名は SignTaskRequest で付与します
+func NewHMACTaskVerifier(secret string) (TaskVerifier, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("task callback secret is empty")
+	}
+	return &hmacTaskVerifier{secret: secret}, nil
+}
+
+// Verify は署名ヘッダーの時刻（5分以内）と署名を検証します
+func (v *hmacTaskVerifier) Verify(r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(TaskTimestampHeader)
+	signature := r.Header.Get(TaskSignatureHeader)
+	if timestamp == "" || signature == "" {
+		return ErrTaskAuthMissing
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp format", ErrTaskAuthInvalid)
+	}
+	if abs(time.Now().Unix()-ts) > int64(taskSignatureTolerance/time.Second) {
+		return fmt.Errorf("%w: request timestamp too old: ts=%d", ErrTaskAuthInvalid, ts)
+	}
+
+	// 定時間比較（タイミング攻撃対策）
+	expected := computeTaskSignature(v.secret, timestamp, r.URL.Path, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("%w: signature mismatch", ErrTaskAuthInvalid)
+	}
+
+	return nil
+}
+
+// SignTaskRequest はタスクの配送リクエストに HMAC 署名ヘッダーを付与します
+// 署名対象は "v1:<timestamp>:<path>:<body>" で、パスを含めることで別のエンドポイントへの転用を防ぎます
+func SignTaskRequest(secret string, req *http.Request, body []byte, now time.Time) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(TaskTimestampHeader, timestamp)
+	req.Header.Set(TaskSignatureHeader, computeTaskSignature(secret, timestamp, req.URL.Path, body))
+}
+
+// computeTaskSignature はタスクの署名（"v1=<16進数>"）を計算します
+func computeTaskSignature(secret, timestamp, path string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(h, "v1:%s:%s:", timestamp, path)
+	h.Write(body)
+	return fmt.Sprintf("v1=%x", h.Sum(nil))
+}
@@ -0,0 +1,78 @@
+package httpsec
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACTaskVerifier(t *testing.T) {
+	const secret = "callback-secret"
+	body := []byte(`{"TeamID":"T1","Step":0}`)
+
+	verifier, err := NewHMACTaskVerifier(secret)
+	if err != nil {
+		t.Fatalf("NewHMACTaskVerifier() error = %v", err)
+	}
+
+	newRequest := func(path string) *http.Request {
+		return httptest.NewRequest(http.MethodPost, "http://127.0.0.1:8080"+path, strings.NewReader(string(body)))
+	}
+	signed := func(signSecret, path string, signedAt time.Time) *http.Request {
+		req := newRequest(path)
+		SignTaskRequest(signSecret, req, body, signedAt)
+		return req
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name string
+		req  *http.Request
+		path string // 受信したリクエストのパス（署名したパスと異なる場合のみ指定）
+		body []byte
+		want error
+	}{
+		{"有効", signed(secret, "/check/remind", now), "", body, nil},
+		{"許容範囲内の過去の署名", signed(secret, "/check/remind", now.Add(-4*time.Minute)), "", body, nil},
+		{"許容範囲内の未来の署名", signed(secret, "/check/remind", now.Add(4*time.Minute)), "", body, nil},
+		{"許容範囲を超えた古い署名", signed(secret, "/check/remind", now.Add(-6*time.Minute)), "", body, ErrTaskAuthInvalid},
+		{"許容範囲を超えた未来の署名", signed(secret, "/check/remind", now.Add(6*time.Minute)), "", body, ErrTaskAuthInvalid},
+		{"署名ヘッダーなし", newRequest("/check/remind"), "", body, ErrTaskAuthMissing},
+		{"本体の改ざん", signed(secret, "/check/remind", now), "", []byte(`{"TeamID":"T2","Step":0}`), ErrTaskAuthInvalid},
+		{"別のエンドポイントへの転用", signed(secret, "/check/remind", now), "/check/escalate", body, ErrTaskAuthInvalid},
+		{"別のシークレットで署名", signed("other-secret", "/check/remind", now), "", body, ErrTaskAuthInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.path != "" {
+				tt.req.URL.Path = tt.path
+			}
+			err := verifier.Verify(tt.req, tt.body)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHMACTaskVerifierMalformedTimestamp(t *testing.T) {
+	verifier, err := NewHMACTaskVerifier("callback-secret")
+	if err != nil {
+		t.Fatalf("NewHMACTaskVerifier() error = %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/check/remind", nil)
+	req.Header.Set(TaskTimestampHeader, "not-a-number")
+	req.Header.Set(TaskSignatureHeader, "v1=00")
+	if err := verifier.Verify(req, nil); !errors.Is(err, ErrTaskAuthInvalid) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrTaskAuthInvalid)
+	}
+
+	if _, err := NewHMACTaskVerifier(""); err == nil {
+		t.Errorf("NewHMACTaskVerifier(\"\") error = nil, want error")
+	}
+}
@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CorrelationIDKey はログに出力する相関 ID の属性名です
+// Cloud Logging では jsonPayload.correlation_id="..." で1件のイベントから派生したログをまとめて検索できます
+const CorrelationIDKey = "correlation_id"
+
+// redacted は秘匿した値の代わりに出力する文字列です
+const redacted = "[REDACTED]"
+
+// sensitiveKeys は値を常に秘匿する属性名です（小文字で比較）
+var sensitiveKeys = map[string]bool{
+	"token":          true,
+	"access_token":   true,
+	"bot_token":      true,
+	"refresh_token":  true,
+	"code":           true,
+	"client_secret":  true,
+	"signing_secret": true,
+	"secret":         true,
+	"state":          true,
+	"authorization":  true,
+	"password":       true,
+}
+
+// slackTokenPattern は文字列中の Slack トークン（Bot・User・App・リフレッシュトークン）に一致します
+// エラーメッセージなどに紛れ込んだトークンも出力しないよう、すべての文字列値に適用します
+var slackTokenPattern = regexp.MustCompile(`xox[abeoprs]-[0-9A-Za-z-]+|xapp-[0-9A-Za-z-]+`)
+
+// New は Cloud Logging の構造化ログ形式（severity・message）で JSON を出力するロガーを作成します
+// level は "debug"・"info"・"warn"・"error" のいずれかで、それ以外は info として扱います
+func New(w io.Writer, level string) *slog.Logger {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       parseLevel(level),
+		ReplaceAttr: replaceAttr,
+	})
+	return slog.New(&contextHandler{Handler: h})
+}
+
+// parseLevel はログレベルの文字列を slog.Level に変換します
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// replaceAttr は標準の属性名を Cloud Logging の特殊フィールドに変換し、秘匿すべき値を伏せます
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 {
+		switch a.Key {
+		case slog.LevelKey:
+			return slog.String("severity", severity(a.Value.Any()))
+		case slog.MessageKey:
+			return slog.String("message", Redact(a.Value.String()))
+		case slog.TimeKey:
+			return a
+		}
+	}
+
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Redact(err.Error()))
+		}
+	}
+	return a
+}
+
+// severity は slog のレベルを Cloud Logging の severity に変換します
+func severity(v any) string {
+	level, ok := v.(slog.Level)
+	if !ok {
+		return "DEFAULT"
+	}
+	switch {
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// Redact は文字列中の Slack トークンを伏せます
+func Redact(s string) string {
+	if !strings.Contains(s, "xox") && !strings.Contains(s, "xapp-") {
+		return s
+	}
+	return slackTokenPattern.ReplaceAllString(s, redacted)
+}
+
+// contextHandler は context の相関 ID・トレース ID をログに付与する slog.Handler です
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle は相関 ID とトレース ID を付与してログを出力します
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := CorrelationID(ctx); id != "" {
+		r.AddAttrs(slog.String(CorrelationIDKey, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs は属性を追加したハンドラーを返します
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup はグループを追加したハンドラーを返します
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// correlationIDKey は context に相関 ID を保持するキーです
+type correlationIDKey struct{}
+
+// NewCorrelationID は新しい相関 ID（16バイトの乱数の16進表記）を生成します
+func NewCorrelationID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithCorrelationID は相関 ID を保持する context を返します
+// id が空の場合は新しい相関 ID を生成します
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		id = NewCorrelationID()
+	}
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID は context の相関 ID を返します（未設定の場合は空文字）
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,258 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// namespace はメトリクス名の接頭辞です
+const namespace = "slackbot"
+
+// registry はアプリのメトリクスを登録する Prometheus レジストリです
+// グローバルのデフォルトレジストリを使わず、ライブラリが登録するメトリクスを出力しないようにします
+var registry = prometheus.NewRegistry()
+
+// 業務メトリクス（ワークスペースの数は限られるため team_id ラベルを付与）
+var (
+	eventsReceived = newCounterVec("events_received_total",
+		"Slack から受信したイベント数（イベント種別ごと）", "team_id", "type")
+
+	eventsRejected = newCounterVec("events_rejected_total",
+		"タスクキューへの登録に失敗したため 500 で断ったイベント数（Slack の再送で処理される）", "team_id", "type")
+
+	mentionsTracked = newCounterVec("mentions_tracked_total",
+		"監視を開始したメンション数（対象者ごとに1件）", "team_id")
+
+	remindersSent = newCounterVec("reminders_sent_total",
+		"送信したリマインド（スレッドリマインド・本人DM）の数", "team_id", "action")
+
+	escalationsSent = newCounterVec("escalations_sent_total",
+		"送信したエスカレーション（上長DM・チャンネル投稿）の数", "team_id", "action")
+
+	repliesDetected = newCounterVec("replies_detected_total",
+		"返信を検知して監視を終了したメンション数（検知経路ごと）", "team_id", "source")
+
+	digestsSent = newCounterVec("digests_sent_total",
+		"送信した上長向け週次ダイジェストの数", "team_id")
+
+	mentionsDeleted = newCounterVec("mentions_deleted_total",
+		"保持期間を過ぎて削除したメンション記録の数", "team_id")
+)
+
+// 外部 API・HTTP のメトリクス（ラベルの種類を抑えるため team_id は付与しない）
+var (
+	slackAPIRequests = newCounterVec("slack_api_requests_total",
+		"Slack Web API の呼び出し数（メソッド・結果ごと。再試行は1回の呼び出しとして数える）", "method", "result")
+
+	slackAPIErrors = newCounterVec("slack_api_errors_total",
+		"Slack Web API が返したエラー数（メソッド・エラーコードごと）", "method", "error")
+
+	slackAPIDuration = newHistogramVec("slack_api_duration_seconds",
+		"Slack Web API の呼び出し時間（レート制限の待機・再試行を含む）", "method")
+
+	gcpRPCDuration = newHistogramVec("gcp_rpc_duration_seconds",
+		"Firestore・Cloud Tasks の RPC 時間（サービス・メソッド・gRPC ステータスごと）", "service", "method", "code")
+
+	httpRequests = newCounterVec("http_requests_total",
+		"HTTP リクエスト数（ルート・ステータスコードごと）", "handler", "code")
+
+	httpDuration = newHistogramVec("http_request_duration_seconds",
+		"HTTP リクエストの処理時間（ルートごと）", "handler")
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// newCounterVec はカウンターを作成してレジストリに登録します
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: namespace, Name: name, Help: help}, labels)
+	registry.MustRegister(c)
+	return c
+}
+
+// newHistogramVec はヒストグラムを作成してレジストリに登録します
+func newHistogramVec(name, help string, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, labels)
+	registry.MustRegister(h)
+	return h
+}
+
+// ===== 業務メトリクス =====
+
+// EventReceived は Slack イベントの受信を記録します
+func EventReceived(teamID, eventType string) {
+	eventsReceived.WithLabelValues(teamID, eventType).Inc()
+}
+
+// EventRejected はタスクキューに登録できず受け付けなかった Slack イベントを記録します
+func EventRejected(teamID, eventType string) {
+	eventsRejected.WithLabelValues(teamID, eventType).Inc()
+}
+
+// MentionTracked はメンションの監視開始を記録します
+func MentionTracked(teamID string) {
+	mentionsTracked.WithLabelValues(teamID).Inc()
+}
+
+// ReminderSent はリマインド（スレッドリマインド・本人DM）の送信を記録します
+func ReminderSent(teamID, action string) {
+	remindersSent.WithLabelValues(teamID, action).Inc()
+}
+
+// EscalationSent はエスカレーション（上長DM・チャンネル投稿）の送信を記録します
+func EscalationSent(teamID, action string) {
+	escalationsSent.WithLabelValues(teamID, action).Inc()
+}
+
+// ReplyDetected は返信の検知を記録します
+// source は検知経路（"event"：返信イベント、"check"：ステップ実行時の確認、"reaction"：リアクション）です
+func ReplyDetected(teamID, source string) {
+	repliesDetected.WithLabelValues(teamID, source).Inc()
+}
+
+// DigestSent は週次ダイジェストの送信を記録します
+func DigestSent(teamID string) {
+	digestsSent.WithLabelValues(teamID).Inc()
+}
+
+// MentionsDeleted は保持期間を過ぎたメンション記録の削除件数を記録します
+func MentionsDeleted(teamID string, n int) {
+	mentionsDeleted.WithLabelValues(teamID).Add(float64(n))
+}
+
+// ===== Slack API =====
+
+// ObserveSlackAPI は Slack Web API の呼び出し結果と所要時間を記録します
+// result は "ok"・"error"（ok:false の応答）・"rate_limited"・"server_error"・"network_error" のいずれかです
+func ObserveSlackAPI(method, result string, d time.Duration) {
+	slackAPIRequests.WithLabelValues(method, result).Inc()
+	slackAPIDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// SlackAPIError は Slack Web API が返したエラーコード（ok:false の error）を記録します
+func SlackAPIError(method, code string) {
+	slackAPIErrors.WithLabelValues(method, code).Inc()
+}
+
+// ===== gRPC（Firestore・Cloud Tasks） =====
+
+// UnaryClientInterceptor は gRPC の単項呼び出しの所要時間を service のラベルで記録するインターセプターを返します
+func UnaryClientInterceptor(service string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observeRPC(service, method, err, time.Since(start))
+		return err
+	}
+}
+
+// StreamClientInterceptor は gRPC のストリーム呼び出し（Firestore のクエリなど）の所要時間を記録するインターセプターを返します
+// ストリームの開始から受信完了（io.EOF）またはエラーまでを1回の呼び出しとして記録します
+func StreamClientInterceptor(service string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			observeRPC(service, method, err, time.Since(start))
+			return nil, err
+		}
+		return &observedStream{ClientStream: stream, service: service, method: method, start: start}, nil
+	}
+}
+
+// observedStream は受信完了時に所要時間を記録する grpc.ClientStream です
+type observedStream struct {
+	grpc.ClientStream
+	service  string
+	method   string
+	start    time.Time
+	observed bool
+}
+
+// RecvMsg はメッセージを受信し、ストリームの終了時に所要時間を記録します
+func (s *observedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil && !s.observed {
+		s.observed = true
+		if errors.Is(err, io.EOF) {
+			observeRPC(s.service, s.method, nil, time.Since(s.start))
+		} else {
+			observeRPC(s.service, s.method, err, time.Since(s.start))
+		}
+	}
+	return err
+}
+
+// observeRPC は gRPC 呼び出しの所要時間をステータスコードごとに記録します
+func observeRPC(service, method string, err error, d time.Duration) {
+	gcpRPCDuration.WithLabelValues(service, method, status.Code(err).String()).Observe(d.Seconds())
+}
+
+// ClientOptions は gRPC の所要時間を記録するインターセプターを設定する Google Cloud クライアントのオプションを返します
+// service は Firestore・Cloud Tasks などを区別するラベルです
+func ClientOptions(service string) []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(service))),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(StreamClientInterceptor(service))),
+	}
+}
+
+// ===== HTTP =====
+
+// Handler は登録済みのメトリクスを Prometheus のテキスト形式で出力するハンドラーを返します
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// InstrumentHandler は next（ServeMux）の処理時間とステータスコードをルートごとに記録するミドルウェアを返します
+// ルートは ServeMux が照合したパターン（未登録のパスは "unmatched"）で、リクエストパスをそのままラベルにしません
+func InstrumentHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" || route == "/" && r.URL.Path != "/" {
+			route = "unmatched"
+		}
+		httpRequests.WithLabelValues(route, strconv.Itoa(rec.status)).Inc()
+		httpDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder はレスポンスのステータスコードを記録する http.ResponseWriter です
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader はステータスコードを記録してから書き込みます
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
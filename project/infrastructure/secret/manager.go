@@ -2,10 +2,14 @@ package secret
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Manager は Secret Manager を通じてシークレットを取得するクライアントです
@@ -95,6 +99,38 @@ func (m *Manager) PutSecret(ctx context.Context, secretName, secretValue string)
 	return nil
 }
 
+// DestroySecretVersions はシークレットの有効・無効な全バージョンを破棄します
+// 破棄後もシークレット自体は残るため、再インストール時は PutSecret で新しいバージョンを追加できます
+// シークレットが存在しない場合は何もせずに成功を返します
+func (m *Manager) DestroySecretVersions(ctx context.Context, secretName string) error {
+	parent := fmt.Sprintf("projects/%s/secrets/%s", m.projectID, secretName)
+
+	it := m.client.ListSecretVersions(ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: parent,
+		Filter: "state:(ENABLED OR DISABLED)",
+	})
+	for {
+		version, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return nil
+			}
+			return fmt.Errorf("secret manager: バージョン一覧取得失敗 (name=%s): %w", secretName, err)
+		}
+
+		if _, err := m.client.DestroySecretVersion(ctx, &secretmanagerpb.DestroySecretVersionRequest{
+			Name: version.Name,
+		}); err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("secret manager: バージョン破棄失敗 (version=%s): %w", version.Name, err)
+		}
+	}
+
+	return nil
+}
+
 // Close は Secret Manager クライアントを閉じます
 func (m *Manager) Close() error {
 	if m.client != nil {
@@ -0,0 +1,82 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Store はシークレット（ワークスペースごとの Bot トークンなど）の取得・保存・破棄を行うストアです
+// Secret Manager を使う Manager と、ローカル開発用の LocalStore があります
+type Store interface {
+	// GetSecret は指定されたシークレット名の最新の値を取得します
+	GetSecret(ctx context.Context, secretName string) (string, error)
+
+	// PutSecret はシークレット値を保存または更新します
+	PutSecret(ctx context.Context, secretName, secretValue string) error
+
+	// DestroySecretVersions はシークレットの全バージョンを破棄します（存在しない場合は成功）
+	DestroySecretVersions(ctx context.Context, secretName string) error
+
+	// Close はストアを閉じます
+	Close() error
+}
+
+// LocalStore は Store のインメモリ実装です
+// ストア・タスクスケジューラがともにローカル実装の場合に、Secret Manager の代わりに使用します
+// 保存したシークレットはプロセス終了時に失われます
+type LocalStore struct {
+	mu           sync.RWMutex
+	secrets      map[string]string
+	defaultValue string
+}
+
+// NewLocalStore はローカルストアを初期化します
+// defaultValue は保存されていないシークレットを取得した場合に返す値です（SLACK_BOT_TOKEN。空の場合はエラー）
+func NewLocalStore(defaultValue string) *LocalStore {
+	return &LocalStore{
+		secrets:      make(map[string]string),
+		defaultValue: defaultValue,
+	}
+}
+
+// GetSecret は保存されたシークレット値（なければ既定値）を取得します
+func (s *LocalStore) GetSecret(ctx context.Context, secretName string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if value, ok := s.secrets[secretName]; ok {
+		if value == "" {
+			return "", fmt.Errorf("local secret: シークレットは破棄されています (name=%s)", secretName)
+		}
+		return value, nil
+	}
+	if s.defaultValue != "" {
+		return s.defaultValue, nil
+	}
+	return "", fmt.Errorf("local secret: シークレットが保存されていません (name=%s)", secretName)
+}
+
+// PutSecret はシークレット値を保存または更新します
+func (s *LocalStore) PutSecret(ctx context.Context, secretName, secretValue string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secrets[secretName] = secretValue
+	return nil
+}
+
+// DestroySecretVersions は保存されたシークレット値を削除します
+// 削除後は既定値も返さず、再インストール（PutSecret）まで取得できません
+func (s *LocalStore) DestroySecretVersions(ctx context.Context, secretName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.secrets[secretName] = ""
+	return nil
+}
+
+// Close は何もしません（Store の実装）
+func (s *LocalStore) Close() error {
+	return nil
+}
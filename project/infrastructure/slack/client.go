@@ -2,110 +2,250 @@ package slack
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"path"
 	"strings"
+	"time"
 
+	"slack-bot/project/domain"
+	"slack-bot/project/dto"
 	"slack-bot/project/infrastructure/secret"
+	"slack-bot/project/infrastructure/tracing"
+	"slack-bot/project/service"
 
 	"github.com/slack-go/slack"
 )
 
+const (
+	// replyPageSize は conversations.replies の1ページあたりの取得件数です
+	replyPageSize = 200
+
+	// maxReplyPages は返信確認で読み込む conversations.replies の最大ページ数です
+	// 長大なスレッドで API 呼び出しが際限なく増えないよう上限を設けます
+	maxReplyPages = 10
+)
+
 // SlackClient は service.SlackPort の Slack SDK 実装です
 type SlackClient struct {
-	secretMgr  *secret.Manager
-	tokenCache map[string]*slack.Client // teamID -> SlackClient
+	secretMgr         secret.Store
+	tenantRepository  domain.TenantRepository
+	secretTokenPrefix string
+	pool              *clientPool
+	limiters          *rateLimiters
 }
 
 // NewSlackClient は Slack クライアントを初期化します
-func NewSlackClient(secretMgr *secret.Manager) *SlackClient {
-	return &SlackClient{
-		secretMgr:  secretMgr,
-		tokenCache: make(map[string]*slack.Client),
+// トークンのシークレット名はテナントの BotTokenSecretName を使い、未登録の場合は secretTokenPrefix + teamID とします
+func NewSlackClient(secretMgr secret.Store, tenantRepository domain.TenantRepository, secretTokenPrefix string) *SlackClient {
+	sc := &SlackClient{
+		secretMgr:         secretMgr,
+		tenantRepository:  tenantRepository,
+		secretTokenPrefix: secretTokenPrefix,
+		limiters:          newRateLimiters(),
 	}
+	sc.pool = newClientPool(clientTTL, sc.loadToken, sc.newAPIClient)
+	return sc
 }
 
-// getSlackClient は teamID に対応する Slack API クライアントを取得します
-// シークレット名から Slack Bot トークンを取得してクライアントを作成
-func (sc *SlackClient) getSlackClient(ctx context.Context, teamID, secretTokenPrefix string) (*slack.Client, error) {
-	// キャッシュを確認
-	if cli, exists := sc.tokenCache[teamID]; exists {
-		return cli, nil
-	}
+// newAPIClient はワークスペースのレート制限・再試行とメトリクス・トレースの記録を適用した Slack API クライアントを作成します
+func (sc *SlackClient) newAPIClient(teamID, token string) *slack.Client {
+	transport := tracing.Transport(&metricsTransport{base: newRateLimitTransport(teamID, sc.limiters)}, func(r *http.Request) string {
+		return "slack " + path.Base(r.URL.Path)
+	})
+	httpClient := &http.Client{Transport: transport}
+	return slack.New(token, slack.OptionHTTPClient(httpClient))
+}
 
-	// Secret Manager からトークンを取得
-	secretName := fmt.Sprintf("%s%s", secretTokenPrefix, teamID)
-	token, err := sc.secretMgr.GetSecret(ctx, secretName)
-	if err != nil {
-		return nil, fmt.Errorf("slack: トークン取得失敗 (teamID=%s): %w", teamID, err)
+// loadToken は teamID の Bot トークンを Secret Manager（ローカル開発ではローカルストア）から取得します
+func (sc *SlackClient) loadToken(ctx context.Context, teamID string) (string, error) {
+	secretName := sc.secretTokenPrefix + teamID
+
+	tenant, err := sc.tenantRepository.Get(ctx, teamID)
+	switch {
+	case err == nil:
+		if tenant.BotTokenSecretName != "" {
+			secretName = tenant.BotTokenSecretName
+		}
+	case errors.Is(err, domain.ErrTenantNotRegistered), errors.Is(err, domain.ErrNotFound):
+		// テナント未登録の場合は既定のシークレット名を使う
+	default:
+		return "", fmt.Errorf("テナント取得失敗: %w", err)
 	}
 
-	// Slack クライアント作成
-	cli := slack.New(token)
+	return sc.secretMgr.GetSecret(ctx, secretName)
+}
 
-	// キャッシュに保存
-	sc.tokenCache[teamID] = cli
+// withClient は teamID の Slack API クライアントで fn を実行します
+// Slack が認証エラーを返した場合はトークンが更新された可能性があるため、
+// キャッシュを破棄してトークンを取得し直し、1回だけ再実行します
+// fn のエラーは再試行可能（domain.ErrTemporary）か恒久的（domain.ErrSlackAPIFailed）かに分類して返します
+func (sc *SlackClient) withClient(ctx context.Context, teamID string, fn func(cli *slack.Client) error) error {
+	cli, err := sc.pool.get(ctx, teamID)
+	if err != nil {
+		return err
+	}
 
-	return cli, nil
-}
+	err = fn(cli)
+	if !isAuthError(err) {
+		return classifyError(err)
+	}
 
-// HasUserReplied は指定ユーザーが返信元ユーザーへメンション付きで返信しているかを判定します
-// 返信完了の条件: 対象ユーザー(userID)が送信元ユーザー(mentionerUserID)へ @メンション をつけて返信している
-func (sc *SlackClient) HasUserReplied(ctx context.Context, teamID, channelID, messageTS, userID, oldest string) (bool, error) {
-	// この新しいメソッドは ParentUserID が必要になるため、内部実装は以下の通り
-	// 呼び出し側が parentUserID を持っていない場合は以下の実装のままにする
-	return sc.HasUserRepliedWithMention(ctx, teamID, channelID, messageTS, userID, "", oldest)
+	sc.pool.evict(teamID)
+	cli, err = sc.pool.get(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	return classifyError(fn(cli))
 }
 
 // HasUserRepliedWithMention は対象ユーザーが送信元ユーザーへメンション付きで返信しているか判定します
+// threadTS: スレッド親メッセージのTS（conversations.replies の ts に指定）
+// messageTS: メンションを含むメッセージのTS（これより後の返信のみ対象）
 // userID: チェック対象のユーザー（メンションされた人）
-// parentUserID: トリガーメッセージ送信者のユーザーID（メンションした人）
-func (sc *SlackClient) HasUserRepliedWithMention(ctx context.Context, teamID, channelID, messageTS, userID, parentUserID, oldest string) (bool, error) {
-	// Slack クライアント取得
-	cli, err := sc.getSlackClient(ctx, teamID, "slack_token_")
-	if err != nil {
-		return false, err
+// parentUserID: トリガーメッセージ送信者のユーザーID（メンションした人）。空の場合は単純な投稿で判定
+//
+// conversations.replies をカーソルでページングし、最大 maxReplyPages ページまで確認します
+// 上限に達しても見つからない場合は Complete=false を返します
+func (sc *SlackClient) HasUserRepliedWithMention(ctx context.Context, teamID, channelID, threadTS, messageTS, userID, parentUserID string) (service.ReplyCheck, error) {
+	var check service.ReplyCheck
+	cursor := ""
+
+	for check.PagesScanned < maxReplyPages {
+		// conversations.replies で messageTS より後のメッセージを取得
+		var (
+			messages []slack.Message
+			hasMore  bool
+			next     string
+		)
+		err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+			var err error
+			messages, hasMore, next, err = cli.GetConversationRepliesContext(
+				ctx,
+				&slack.GetConversationRepliesParameters{
+					ChannelID: channelID,
+					Timestamp: threadTS,
+					Oldest:    messageTS,
+					Cursor:    cursor,
+					Limit:     replyPageSize,
+				},
+			)
+			return err
+		})
+		if err != nil {
+			return service.ReplyCheck{}, fmt.Errorf("slack: 返信確認失敗 (channel=%s, thread_ts=%s, page=%d): %w", channelID, threadTS, check.PagesScanned+1, err)
+		}
+		check.PagesScanned++
+
+		// メッセージをループして対象ユーザーのメンション返信を検索
+		for _, msg := range messages {
+			// 親メッセージ・メンション自体は除外（親メッセージは oldest に関わらず先頭に含まれる）
+			if msg.Timestamp == threadTS || msg.Timestamp == messageTS {
+				continue
+			}
+
+			// 対象ユーザーが投稿している場合のみチェック
+			if msg.User != userID {
+				continue
+			}
+
+			// parentUserID が指定されている場合は、userID が parentUserID へメンション (@ユーザーA) をつけているか確認
+			// 指定されていない場合は、単純な投稿で判定
+			if parentUserID == "" || hasMentionToUser(msg.Text, parentUserID) {
+				check.Replied = true
+				check.ReplyTS = msg.Timestamp
+				check.Complete = true
+				return check, nil
+			}
+		}
+
+		if !hasMore || next == "" {
+			check.Complete = true
+			return check, nil
+		}
+		cursor = next
 	}
 
-	// conversations.replies で messageTS 以降のメッセージを取得
-	messages, _, _, err := cli.GetConversationReplies(
-		&slack.GetConversationRepliesParameters{
-			ChannelID: channelID,
-			Timestamp: messageTS,
-			Oldest:    oldest,
-		},
-	)
+	// ページ上限に到達（未返信と断定できない）
+	return check, nil
+}
+
+// HasUserPostedInChannel は対象ユーザーが oldest より後にチャンネルへ（スレッド外で）投稿しているか判定します
+// conversations.history をカーソルでページングし、最大 maxReplyPages ページまで確認します
+func (sc *SlackClient) HasUserPostedInChannel(ctx context.Context, teamID, channelID, oldest, userID string) (service.ReplyCheck, error) {
+	var check service.ReplyCheck
+	cursor := ""
+
+	for check.PagesScanned < maxReplyPages {
+		var res *slack.GetConversationHistoryResponse
+		err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+			var err error
+			res, err = cli.GetConversationHistoryContext(
+				ctx,
+				&slack.GetConversationHistoryParameters{
+					ChannelID: channelID,
+					Oldest:    oldest,
+					Cursor:    cursor,
+					Limit:     replyPageSize,
+				},
+			)
+			return err
+		})
+		if err != nil {
+			return service.ReplyCheck{}, fmt.Errorf("slack: チャンネル投稿確認失敗 (channel=%s, page=%d): %w", channelID, check.PagesScanned+1, err)
+		}
+		check.PagesScanned++
+
+		for _, msg := range res.Messages {
+			// 編集・参加通知などのサブタイプは投稿として扱わない
+			if msg.User == userID && (msg.SubType == "" || msg.SubType == "thread_broadcast") {
+				check.Replied = true
+				check.ReplyTS = msg.Timestamp
+				check.Complete = true
+				return check, nil
+			}
+		}
+
+		if !res.HasMore || res.ResponseMetaData.NextCursor == "" {
+			check.Complete = true
+			return check, nil
+		}
+		cursor = res.ResponseMetaData.NextCursor
+	}
+
+	// ページ上限に到達（未返信と断定できない）
+	return check, nil
+}
+
+// HasUserReacted は対象ユーザーがメッセージに指定のリアクションをつけているか判定します
+// スキントーン付きの絵文字も同じリアクションとして扱います
+func (sc *SlackClient) HasUserReacted(ctx context.Context, teamID, channelID, messageTS, userID, reaction string) (service.ReplyCheck, error) {
+	var reactions []slack.ItemReaction
+	err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		var err error
+		reactions, err = cli.GetReactionsContext(ctx, slack.NewRefToMessage(channelID, messageTS), slack.GetReactionsParameters{Full: true})
+		return err
+	})
 	if err != nil {
-		return false, fmt.Errorf("slack: 返信確認失敗 (channel=%s, ts=%s): %w", channelID, messageTS, err)
+		return service.ReplyCheck{}, fmt.Errorf("slack: リアクション確認失敗 (channel=%s, ts=%s): %w", channelID, messageTS, err)
 	}
 
-	// メッセージをループして対象ユーザーのメンション返信を検索
-	for _, msg := range messages {
-		// 親メッセージ自体は除外
-		if msg.Timestamp == messageTS {
+	check := service.ReplyCheck{PagesScanned: 1, Complete: true}
+	for _, r := range reactions {
+		if !domain.SameReaction(r.Name, reaction) {
 			continue
 		}
-
-		// 対象ユーザーが投稿している場合のみチェック
-		if msg.User == userID {
-			// parentUserID が指定されている場合は、メンション返信を確認
-			if parentUserID != "" {
-				// userID が parentUserID へメンション (@ユーザーA) をつけているか確認
-				if hasMentionToUser(msg.Text, parentUserID) {
-					return true, nil // メンション返信を発見
-				}
-				// parentUserID へのメンションがない場合は、返信と判定しない
-				continue
-			} else {
-				// parentUserID が指定されていない場合は、単純な投稿で判定
-				return true, nil
+		for _, u := range r.Users {
+			if u == userID {
+				check.Replied = true
+				check.ReplyTS = messageTS
+				return check, nil
 			}
 		}
 	}
 
-	// ページング処理（必要に応じて次ページを確認）
-	// 簡略版: 最初のページで見つからなければ false
-
-	return false, nil
+	return check, nil
 }
 
 // hasMentionToUser は text 内に特定ユーザーへの @メンション があるか判定します
@@ -121,71 +261,185 @@ func hasMentionToUser(text string, userID string) bool {
 
 // PostThreadMessage はスレッドにメッセージを投稿します
 func (sc *SlackClient) PostThreadMessage(ctx context.Context, teamID, channelID, messageTS, text string) error {
-	// Slack クライアント取得
-	cli, err := sc.getSlackClient(ctx, teamID, "slack_token_")
-	if err != nil {
+	// スレッドにメッセージ投稿
+	err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		_, _, err := cli.PostMessageContext(
+			ctx,
+			channelID,
+			slack.MsgOptionText(text, false),
+			slack.MsgOptionTS(messageTS),
+		)
 		return err
+	})
+	if err != nil {
+		return fmt.Errorf("slack: スレッドメッセージ投稿失敗 (channel=%s, ts=%s): %w", channelID, messageTS, err)
 	}
 
-	// スレッドにメッセージ投稿
-	_, _, err = cli.PostMessageContext(
-		ctx,
-		channelID,
-		slack.MsgOptionText(text, false),
-		slack.MsgOptionTS(messageTS),
-	)
+	return nil
+}
+
+// PostThreadReminder はスレッドに操作ボタン付きのリマインドを投稿します
+func (sc *SlackClient) PostThreadReminder(ctx context.Context, teamID, channelID, messageTS, text string, ref service.ReminderRef) error {
+	// スレッドにリマインド投稿（text は通知・ボタン非対応クライアント用のフォールバック）
+	err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		_, _, err := cli.PostMessageContext(
+			ctx,
+			channelID,
+			slack.MsgOptionText(text, false),
+			slack.MsgOptionBlocks(reminderBlocks(text, ref)...),
+			slack.MsgOptionTS(messageTS),
+		)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("slack: スレッドメッセージ投稿失敗 (channel=%s, ts=%s): %w", channelID, messageTS, err)
+		return fmt.Errorf("slack: スレッドリマインド投稿失敗 (channel=%s, ts=%s): %w", channelID, messageTS, err)
 	}
 
 	return nil
 }
 
+// PostDMReminder は対象者に操作ボタン付きのリマインドを DM で送信します
+func (sc *SlackClient) PostDMReminder(ctx context.Context, teamID, userID, text string, ref service.ReminderRef) error {
+	return sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		// ユーザーとの DM チャンネルを開く
+		dmCh, _, _, err := cli.OpenConversationContext(
+			ctx,
+			&slack.OpenConversationParameters{
+				Users: []string{userID},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("slack: DM チャンネル作成失敗 (user=%s): %w", userID, err)
+		}
+
+		// DM を送信
+		_, _, err = cli.PostMessageContext(
+			ctx,
+			dmCh.ID,
+			slack.MsgOptionText(text, false),
+			slack.MsgOptionBlocks(reminderBlocks(text, ref)...),
+		)
+		if err != nil {
+			return fmt.Errorf("slack: DM リマインド送信失敗 (user=%s): %w", userID, err)
+		}
+
+		return nil
+	})
+}
+
+// snoozeOptions はリマインドのスヌーズ選択肢です（期間は稼働カレンダーの稼働時間で数えます）
+var snoozeOptions = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"30分", 30 * time.Minute},
+	{"1時間", time.Hour},
+	{"3時間", 3 * time.Hour},
+	{"1日", 24 * time.Hour},
+}
+
+// reminderBlocks はリマインド本文と操作ボタン（確認しました・スヌーズ・担当外です）の Block Kit を組み立てます
+func reminderBlocks(text string, ref service.ReminderRef) []slack.Block {
+	section := slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+
+	ack := slack.NewButtonBlockElement(dto.ActionIDReminderAck, ref.Encode(),
+		slack.NewTextBlockObject(slack.PlainTextType, "👀 確認しました", false, false)).
+		WithStyle(slack.StylePrimary)
+
+	options := make([]*slack.OptionBlockObject, 0, len(snoozeOptions))
+	for _, opt := range snoozeOptions {
+		options = append(options, slack.NewOptionBlockObject(ref.SnoozeValue(opt.duration),
+			slack.NewTextBlockObject(slack.PlainTextType, opt.label+"後に再通知", false, false), nil))
+	}
+	snooze := slack.NewOptionsSelectBlockElement(slack.OptTypeStatic,
+		slack.NewTextBlockObject(slack.PlainTextType, "⏰ スヌーズ", false, false),
+		dto.ActionIDReminderSnooze, options...)
+
+	decline := slack.NewButtonBlockElement(dto.ActionIDReminderDecline, ref.Encode(),
+		slack.NewTextBlockObject(slack.PlainTextType, "🙅 担当外です", false, false)).
+		WithStyle(slack.StyleDanger)
+
+	return []slack.Block{
+		section,
+		slack.NewActionBlock("reminder_actions", ack, snooze, decline),
+	}
+}
+
 // PostDM はユーザーに DM を送信します
 func (sc *SlackClient) PostDM(ctx context.Context, teamID, userID, text string) error {
-	// Slack クライアント取得
-	cli, err := sc.getSlackClient(ctx, teamID, "slack_token_")
-	if err != nil {
-		return err
-	}
+	return sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		// ユーザーとの DM チャンネルを開く
+		// OpenConversation で DM チャンネルを開く
+		dmCh, _, _, err := cli.OpenConversationContext(
+			ctx,
+			&slack.OpenConversationParameters{
+				Users: []string{userID},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("slack: DM チャンネル作成失敗 (user=%s): %w", userID, err)
+		}
 
-	// ユーザーとの DM チャンネルを開く
-	// OpenConversation で DM チャンネルを開く
-	dmCh, _, _, err := cli.OpenConversation(
-		&slack.OpenConversationParameters{
-			Users: []string{userID},
-		},
-	)
+		// DM を送信
+		_, _, err = cli.PostMessageContext(
+			ctx,
+			dmCh.ID,
+			slack.MsgOptionText(text, false),
+		)
+		if err != nil {
+			return fmt.Errorf("slack: DM 送信失敗 (user=%s): %w", userID, err)
+		}
+
+		return nil
+	})
+}
+
+// PostChannelMessage はチャンネルに（スレッド外で）メッセージを投稿します
+func (sc *SlackClient) PostChannelMessage(ctx context.Context, teamID, channelID, text string) error {
+	// チャンネルにメッセージ投稿
+	err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		_, _, err := cli.PostMessageContext(
+			ctx,
+			channelID,
+			slack.MsgOptionText(text, false),
+		)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("slack: DM チャンネル作成失敗 (user=%s): %w", userID, err)
+		return fmt.Errorf("slack: チャンネルメッセージ投稿失敗 (channel=%s): %w", channelID, err)
 	}
 
-	// DM を送信
-	_, _, err = cli.PostMessageContext(
-		ctx,
-		dmCh.ID,
-		slack.MsgOptionText(text, false),
-	)
+	return nil
+}
+
+// IsWorkspaceAdmin はユーザーがワークスペースの管理者またはオーナーかどうかを返します
+func (sc *SlackClient) IsWorkspaceAdmin(ctx context.Context, teamID, userID string) (bool, error) {
+	var user *slack.User
+	err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		var err error
+		user, err = cli.GetUserInfoContext(ctx, userID)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("slack: DM 送信失敗 (user=%s): %w", userID, err)
+		return false, fmt.Errorf("slack: ユーザー情報取得失敗 (user=%s): %w", userID, err)
 	}
 
-	return nil
+	return user.IsAdmin || user.IsOwner || user.IsPrimaryOwner, nil
 }
 
 // ClearCache はトークンキャッシュをクリアします（テスト用）
 func (sc *SlackClient) ClearCache() {
-	sc.tokenCache = make(map[string]*slack.Client)
+	sc.pool.clear()
+}
+
+// Evict は teamID のキャッシュ済みクライアントを破棄します
+// アンインストール・トークン失効・再インストール時に古いトークンを使い続けないために使用します
+func (sc *SlackClient) Evict(teamID string) {
+	sc.pool.evict(teamID)
 }
 
 // GetUserID はユーザー名またはメールアドレスからユーザー ID を取得します
 func (sc *SlackClient) GetUserID(ctx context.Context, teamID, userNameOrEmail string) (string, error) {
-	// Slack クライアント取得
-	cli, err := sc.getSlackClient(ctx, teamID, "slack_token_")
-	if err != nil {
-		return "", fmt.Errorf("slack: クライアント取得失敗: %w", err)
-	}
-
 	// ユーザー名で検索（@ を除去）
 	userName := userNameOrEmail
 	if len(userName) > 0 && userName[0] == '@' {
@@ -193,7 +447,12 @@ func (sc *SlackClient) GetUserID(ctx context.Context, teamID, userNameOrEmail st
 	}
 
 	// users.list を使ってユーザー名から ID を取得
-	users, err := cli.GetUsersContext(ctx)
+	var users []slack.User
+	err := sc.withClient(ctx, teamID, func(cli *slack.Client) error {
+		var err error
+		users, err = cli.GetUsersContext(ctx)
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("slack: ユーザー一覧取得失敗: %w", err)
 	}
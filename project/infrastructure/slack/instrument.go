@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"slack-bot/project/infrastructure/metrics"
+)
+
+// maxInspectBody は ok:false の判定のために読み込む応答本体の上限です
+// これを超える応答（大きな users.list など）は判定せずに成功として記録します
+const maxInspectBody = 1 << 20
+
+// metricsTransport は Slack Web API の呼び出し数・エラー・所要時間を記録する http.RoundTripper です
+// レート制限・再試行を含めた1回の呼び出しとして記録するため rateLimitTransport の外側に置きます
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip はリクエストを送信し、結果をメソッドごとに記録します
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	start := time.Now()
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		metrics.ObserveSlackAPI(method, "network_error", time.Since(start))
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		metrics.ObserveSlackAPI(method, "rate_limited", time.Since(start))
+		metrics.SlackAPIError(method, "ratelimited")
+	case resp.StatusCode >= http.StatusInternalServerError:
+		metrics.ObserveSlackAPI(method, "server_error", time.Since(start))
+		metrics.SlackAPIError(method, "http_"+strconv.Itoa(resp.StatusCode))
+	default:
+		code := inspectSlackError(resp)
+		if code == "" {
+			metrics.ObserveSlackAPI(method, "ok", time.Since(start))
+		} else {
+			metrics.ObserveSlackAPI(method, "error", time.Since(start))
+			metrics.SlackAPIError(method, code)
+		}
+	}
+	return resp, nil
+}
+
+// inspectSlackError は JSON 応答の ok:false を判定し、エラーコードを返します（成功時は空文字）
+// 読み込んだ応答本体は呼び出し元（slack-go）が読めるように差し戻します
+func inspectSlackError(resp *http.Response) string {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") || resp.ContentLength > maxInspectBody {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxInspectBody+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil || len(body) > maxInspectBody {
+		return ""
+	}
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.OK {
+		return ""
+	}
+	if result.Error == "" {
+		return "unknown"
+	}
+	return result.Error
+}
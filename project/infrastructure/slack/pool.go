@@ -0,0 +1,135 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// clientTTL はキャッシュしたクライアント（トークン）を使い続ける期間です
+	// 期限切れ後は Secret Manager から取得し直し、トークンのローテーションに追従します
+	clientTTL = 15 * time.Minute
+
+	// tokenLoadTimeout は Secret Manager からのトークン取得のタイムアウトです
+	tokenLoadTimeout = 10 * time.Second
+)
+
+// authErrorCodes はトークンが無効であることを示す Slack API のエラーコードです
+var authErrorCodes = map[string]bool{
+	"invalid_auth":     true,
+	"not_authed":       true,
+	"token_revoked":    true,
+	"token_expired":    true,
+	"account_inactive": true,
+}
+
+// tokenLoader は teamID の Bot トークンを取得する関数です
+type tokenLoader func(ctx context.Context, teamID string) (string, error)
+
+// clientBuilder は teamID の Bot トークンから Slack API クライアントを作成する関数です
+type clientBuilder func(teamID, token string) *slack.Client
+
+// poolEntry はキャッシュしたクライアントと有効期限です
+type poolEntry struct {
+	cli       *slack.Client
+	expiresAt time.Time
+}
+
+// clientPool はワークスペースごとの Slack API クライアントを保持する goroutine セーフなプールです
+// 同じワークスペースのトークン取得が同時に発生した場合は singleflight で1回にまとめます
+type clientPool struct {
+	mu      sync.RWMutex
+	entries map[string]poolEntry // teamID -> クライアント
+	gens    map[string]uint64    // teamID -> 破棄の世代（破棄前に始まった取得結果を保存しないため）
+	group   singleflight.Group
+	ttl     time.Duration
+	load    tokenLoader
+	build   clientBuilder
+}
+
+// newClientPool はクライアントプールを作成します
+func newClientPool(ttl time.Duration, load tokenLoader, build clientBuilder) *clientPool {
+	return &clientPool{
+		entries: make(map[string]poolEntry),
+		gens:    make(map[string]uint64),
+		ttl:     ttl,
+		load:    load,
+		build:   build,
+	}
+}
+
+// get は teamID のクライアントを返します。キャッシュがない・期限切れの場合はトークンを取得して作成します
+func (p *clientPool) get(ctx context.Context, teamID string) (*slack.Client, error) {
+	p.mu.RLock()
+	entry, ok := p.entries[teamID]
+	gen := p.gens[teamID]
+	p.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.cli, nil
+	}
+
+	v, err, _ := p.group.Do(teamID, func() (interface{}, error) {
+		// 待ち合わせた呼び出し元のキャンセルに巻き込まれないよう、取得は独立したタイムアウトで行う
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenLoadTimeout)
+		defer cancel()
+
+		token, err := p.load(loadCtx, teamID)
+		if err != nil {
+			return nil, err
+		}
+		cli := p.build(teamID, token)
+
+		p.mu.Lock()
+		if p.gens[teamID] == gen {
+			p.entries[teamID] = poolEntry{cli: cli, expiresAt: time.Now().Add(p.ttl)}
+		}
+		p.mu.Unlock()
+
+		return cli, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("slack: トークン取得失敗 (teamID=%s): %w", teamID, err)
+	}
+
+	return v.(*slack.Client), nil
+}
+
+// evict は teamID のクライアントを破棄し、次回の get でトークンを取得し直すようにします
+func (p *clientPool) evict(teamID string) {
+	p.mu.Lock()
+	delete(p.entries, teamID)
+	p.gens[teamID]++
+	p.mu.Unlock()
+
+	// 取得中の結果を新しい呼び出し元で共有しない
+	p.group.Forget(teamID)
+}
+
+// clear は全てのクライアントを破棄します
+func (p *clientPool) clear() {
+	p.mu.Lock()
+	teamIDs := make([]string, 0, len(p.entries))
+	for teamID := range p.entries {
+		teamIDs = append(teamIDs, teamID)
+	}
+	p.mu.Unlock()
+
+	for _, teamID := range teamIDs {
+		p.evict(teamID)
+	}
+}
+
+// isAuthError は Slack API のエラーがトークン無効（認証エラー）かどうかを判定します
+func isAuthError(err error) bool {
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) {
+		return authErrorCodes[slackErr.Err]
+	}
+	return false
+}
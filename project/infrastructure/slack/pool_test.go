@@ -0,0 +1,188 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// countingLoader は呼び出し回数ごとに異なるトークンを返す tokenLoader です
+// release が設定されている場合は、閉じられるまで取得を待たせます
+type countingLoader struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (l *countingLoader) load(ctx context.Context, teamID string) (string, error) {
+	n := l.calls.Add(1)
+	if l.release != nil {
+		<-l.release
+	}
+	if l.err != nil {
+		return "", l.err
+	}
+	return fmt.Sprintf("xoxb-%s-%d", teamID, n), nil
+}
+
+func buildTestClient(teamID, token string) *slack.Client {
+	return slack.New(token)
+}
+
+func TestClientPoolSharesConcurrentLoads(t *testing.T) {
+	loader := &countingLoader{release: make(chan struct{})}
+	pool := newClientPool(time.Hour, loader.load, buildTestClient)
+
+	const callers = 10
+	clients := make([]*slack.Client, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cli, err := pool.get(context.Background(), "T1")
+			if err != nil {
+				t.Errorf("get() error = %v", err)
+			}
+			clients[i] = cli
+		}()
+	}
+
+	// 全ての呼び出し元が取得を待ち合わせてから完了させる
+	for loader.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(loader.release)
+	wg.Wait()
+
+	if got := loader.calls.Load(); got != 1 {
+		t.Errorf("loads = %d, want 1", got)
+	}
+	for i, cli := range clients {
+		if cli == nil || cli != clients[0] {
+			t.Errorf("clients[%d] = %p, want shared client %p", i, cli, clients[0])
+		}
+	}
+}
+
+func TestClientPoolCachesUntilExpiryOrEviction(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{}
+	pool := newClientPool(time.Hour, loader.load, buildTestClient)
+
+	first, err := pool.get(ctx, "T1")
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	cached, err := pool.get(ctx, "T1")
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if cached != first || loader.calls.Load() != 1 {
+		t.Errorf("second get() = %p (loads %d), want cached %p (loads 1)", cached, loader.calls.Load(), first)
+	}
+
+	// ワークスペースごとにキャッシュする
+	if _, err := pool.get(ctx, "T2"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if got := loader.calls.Load(); got != 2 {
+		t.Errorf("loads = %d, want 2", got)
+	}
+
+	// 破棄後はトークンを取得し直す
+	pool.evict("T1")
+	reloaded, err := pool.get(ctx, "T1")
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if reloaded == first || loader.calls.Load() != 3 {
+		t.Errorf("get() after evict = %p (loads %d), want new client (loads 3)", reloaded, loader.calls.Load())
+	}
+
+	// 期限切れのクライアントは使わない
+	expiring := newClientPool(0, loader.load, buildTestClient)
+	if _, err := expiring.get(ctx, "T1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if _, err := expiring.get(ctx, "T1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if got := loader.calls.Load(); got != 5 {
+		t.Errorf("loads = %d, want 5", got)
+	}
+}
+
+func TestClientPoolEvictDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	loader := &countingLoader{release: make(chan struct{})}
+	pool := newClientPool(time.Hour, loader.load, buildTestClient)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := pool.get(ctx, "T1"); err != nil {
+			t.Errorf("get() error = %v", err)
+		}
+	}()
+	for loader.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 取得中に破棄された（トークンが更新された可能性がある）場合、古い取得結果はキャッシュしない
+	pool.evict("T1")
+	close(loader.release)
+	<-done
+
+	if _, err := pool.get(ctx, "T1"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if got := loader.calls.Load(); got != 2 {
+		t.Errorf("loads = %d, want 2", got)
+	}
+}
+
+func TestClientPoolLoadError(t *testing.T) {
+	errSecret := errors.New("secretmanager: not found")
+	loader := &countingLoader{err: errSecret}
+	pool := newClientPool(time.Hour, loader.load, buildTestClient)
+
+	for range 2 {
+		if _, err := pool.get(context.Background(), "T1"); !errors.Is(err, errSecret) {
+			t.Fatalf("get() error = %v, want %v", err, errSecret)
+		}
+	}
+	// 失敗した取得はキャッシュしない
+	if got := loader.calls.Load(); got != 2 {
+		t.Errorf("loads = %d, want 2", got)
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid_auth", slack.SlackErrorResponse{Err: "invalid_auth"}, true},
+		{"token_revoked（ラップ済み）", fmt.Errorf("chat.postMessage: %w", slack.SlackErrorResponse{Err: "token_revoked"}), true},
+		{"channel_not_found", slack.SlackErrorResponse{Err: "channel_not_found"}, false},
+		{"Slack API 以外のエラー", errors.New("connection reset"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAuthError(tt.err); got != tt.want {
+				t.Errorf("isAuthError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
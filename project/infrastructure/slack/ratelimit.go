@@ -0,0 +1,244 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"slack-bot/project/domain"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxAPIRetries は 429・5xx 応答時の再試行回数の上限です
+	maxAPIRetries = 3
+
+	// maxRetryAfter は Retry-After に従って待機する時間の上限です
+	// これを超える待機が必要な場合は待たずに呼び出し元へ再試行可能エラーとして返します
+	maxRetryAfter = 30 * time.Second
+
+	// baseBackoff は 5xx 応答時の指数バックオフの初期値です
+	baseBackoff = 500 * time.Millisecond
+)
+
+// Slack Web API のレート制限ティア（1分あたりの呼び出し回数）
+// https://api.slack.com/apis/rate-limits
+const (
+	tier2PerMinute = 20
+	tier3PerMinute = 50
+	tier4PerMinute = 100
+)
+
+// methodLimits は Web API メソッドごとのレート制限です（未登録のメソッドは Tier 3）
+var methodLimits = map[string]rate.Limit{
+	"conversations.replies": perMinute(tier3PerMinute),
+	"conversations.history": perMinute(tier3PerMinute),
+	"conversations.open":    perMinute(tier3PerMinute),
+	"reactions.get":         perMinute(tier3PerMinute),
+	"users.list":            perMinute(tier2PerMinute),
+	"users.info":            perMinute(tier4PerMinute),
+	// chat.postMessage はチャンネルあたり1秒1件程度（Special tier）のため、ワークスペース単位で1秒1件に抑える
+	"chat.postMessage": rate.Every(time.Second),
+}
+
+// idempotentMethods は 5xx 応答時に再試行してよい読み取り系（冪等）のメソッドです
+// chat.postMessage などの書き込みは、5xx でも Slack 側で処理済みの場合があり再試行すると重複投稿になるため、
+// 再試行せずに errWriteServerError（恒久的なエラー domain.ErrSlackAPIFailed に分類）として呼び出し元に返します
+// タスクキューの再試行でも送り直さないよう、再試行可能エラーにはしません
+// conversations.open は既存の DM を返すだけのため冪等です
+var idempotentMethods = map[string]bool{
+	"conversations.replies": true,
+	"conversations.history": true,
+	"conversations.open":    true,
+	"reactions.get":         true,
+	"users.list":            true,
+	"users.info":            true,
+}
+
+// errWriteServerError は書き込み系のメソッドが 5xx を返したことを表します（Slack 側で処理済みかどうかは不明）
+var errWriteServerError = errors.New("slack: 書き込みメソッドのサーバーエラー")
+
+// perMinute は1分あたりの回数をレートに変換します
+func perMinute(n int) rate.Limit {
+	return rate.Every(time.Minute / time.Duration(n))
+}
+
+// methodBurst はトークンバケットのバースト数です
+const methodBurst = 5
+
+// rateLimiters はワークスペース・メソッドごとのトークンバケットを保持します
+// クライアントを作り直しても（TTL 切れ・トークン更新）制限を引き継ぐため SlackClient 単位で共有します
+type rateLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter // "teamID/method" -> トークンバケット
+}
+
+// newRateLimiters はトークンバケットの集合を作成します
+func newRateLimiters() *rateLimiters {
+	return &rateLimiters{limiters: make(map[string]*rate.Limiter)}
+}
+
+// get は teamID・method のトークンバケットを返します（なければ作成）
+func (r *rateLimiters) get(teamID, method string) *rate.Limiter {
+	key := teamID + "/" + method
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.limiters[key]
+	if !ok {
+		limit, ok := methodLimits[method]
+		if !ok {
+			limit = perMinute(tier3PerMinute)
+		}
+		l = rate.NewLimiter(limit, methodBurst)
+		r.limiters[key] = l
+	}
+	return l
+}
+
+// rateLimitTransport はワークスペースごとの Slack API 呼び出しにレート制限と再試行を適用する http.RoundTripper です
+// 呼び出し前にトークンバケットで待機し、429 は Retry-After、5xx は指数バックオフ（読み取り系のメソッドのみ）で再試行します
+type rateLimitTransport struct {
+	teamID   string
+	limiters *rateLimiters
+	base     http.RoundTripper
+}
+
+// newRateLimitTransport はワークスペース用の RoundTripper を作成します
+func newRateLimitTransport(teamID string, limiters *rateLimiters) *rateLimitTransport {
+	return &rateLimitTransport{
+		teamID:   teamID,
+		limiters: limiters,
+		base:     http.DefaultTransport,
+	}
+}
+
+// RoundTrip はレート制限と再試行を適用してリクエストを送信します
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	method := path.Base(req.URL.Path)
+	limiter := t.limiters.get(t.teamID, method)
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("slack: レート制限の待機中断 (teamID=%s, method=%s): %w", t.teamID, method, err)
+		}
+
+		if attempt > 0 {
+			// 再試行ではリクエスト本体を作り直す
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// 書き込みの 5xx は処理済みの可能性があるため、slack-go の再試行可能エラーにさせず errWriteServerError を返す
+		if resp.StatusCode >= 500 && !idempotentMethods[method] {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w (teamID=%s, method=%s, status=%d)", errWriteServerError, t.teamID, method, resp.StatusCode)
+		}
+
+		// 本体を作り直せないリクエストは再試行しない
+		wait, retry := retryDelayFor(resp, method, attempt)
+		if !retry || attempt >= maxAPIRetries || (req.Body != nil && req.GetBody == nil) {
+			// 再試行しない場合は応答をそのまま返し、slack-go に RateLimitedError などへ変換させる
+			return resp, nil
+		}
+
+		// 応答本体を読み捨ててコネクションを再利用できるようにする
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelayFor は method の応答が再試行対象かどうかと、再試行までの待機時間を返します
+// 429 は処理されていないためどのメソッドも再試行し、5xx は読み取り系のメソッドのみ再試行します
+func retryDelayFor(resp *http.Response, method string, attempt int) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		wait := time.Second
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		if wait > maxRetryAfter {
+			// 長時間の待機はタスクキュー側の再試行に任せる
+			return 0, false
+		}
+		return wait, true
+
+	case resp.StatusCode >= 500 && idempotentMethods[method]:
+		// 指数バックオフ（ジッターあり）
+		backoff := baseBackoff << attempt
+		return backoff/2 + rand.N(backoff/2+1), true
+	}
+
+	return 0, false
+}
+
+// sleepContext は d だけ待機します。ctx がキャンセルされた場合は中断してエラーを返します
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryable は slack-go のエラーが再試行可能かどうかを示すインターフェースです
+type retryable interface {
+	Retryable() bool
+}
+
+// classifyError は Slack API のエラーを再試行可能（domain.ErrTemporary）と恒久的（domain.ErrSlackAPIFailed）に分類します
+// 呼び出し元は errors.Is で判定し、再試行可能ならタスクキューに再試行させます
+// 書き込みの 5xx（errWriteServerError）は再試行すると重複投稿になり得るため恒久的なエラーとします
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, errWriteServerError) {
+		return fmt.Errorf("%w: %w", domain.ErrSlackAPIFailed, err)
+	}
+
+	var r retryable
+	if errors.As(err, &r) && r.Retryable() {
+		return fmt.Errorf("%w: %w", domain.ErrTemporary, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", domain.ErrTemporary, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", domain.ErrTemporary, err)
+	}
+
+	return fmt.Errorf("%w: %w", domain.ErrSlackAPIFailed, err)
+}
@@ -0,0 +1,135 @@
+package slack
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"slack-bot/project/domain"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitTransportWriteServerError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	cli := &http.Client{Transport: newRateLimitTransport("T1", newRateLimiters())}
+	resp, err := cli.Post(srv.URL+"/api/chat.postMessage", "application/x-www-form-urlencoded", strings.NewReader("channel=C1"))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Post() error = nil, want %v", errWriteServerError)
+	}
+
+	// 書き込みは処理済みの可能性があるため再試行しない
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+	// タスクキューの再試行でも送り直さないよう恒久的なエラーにする
+	if got := classifyError(err); !errors.Is(got, domain.ErrSlackAPIFailed) || errors.Is(got, domain.ErrTemporary) {
+		t.Errorf("classifyError() = %v, want %v", got, domain.ErrSlackAPIFailed)
+	}
+}
+
+func TestRateLimitTransportRetriesRateLimited(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	cli := &http.Client{Transport: newRateLimitTransport("T1", newRateLimiters())}
+	resp, err := cli.Post(srv.URL+"/api/chat.postMessage", "application/x-www-form-urlencoded", strings.NewReader("channel=C1"))
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+
+	// 429 は処理されていないため書き込みでも再試行する
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Errorf("status = %d (calls %d), want 200 (calls 2)", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetryDelayFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		method     string
+		attempt    int
+		wantRetry  bool
+		wantMin    time.Duration
+		wantMax    time.Duration
+	}{
+		{name: "429 は Retry-After だけ待つ", status: http.StatusTooManyRequests, retryAfter: "3", method: "chat.postMessage", wantRetry: true, wantMin: 3 * time.Second, wantMax: 3 * time.Second},
+		{name: "429 で Retry-After がなければ1秒待つ", status: http.StatusTooManyRequests, method: "users.info", wantRetry: true, wantMin: time.Second, wantMax: time.Second},
+		{name: "Retry-After が上限を超える場合はタスクキューに任せる", status: http.StatusTooManyRequests, retryAfter: "31", method: "users.info"},
+		{name: "読み取り系の 5xx は指数バックオフで再試行する", status: http.StatusServiceUnavailable, method: "conversations.replies", attempt: 2, wantRetry: true, wantMin: baseBackoff * 2, wantMax: baseBackoff * 4},
+		{name: "書き込みの 5xx は再試行しない", status: http.StatusInternalServerError, method: "chat.postMessage"},
+		{name: "成功応答は再試行しない", status: http.StatusOK, method: "users.info"},
+		{name: "4xx は再試行しない", status: http.StatusBadRequest, method: "users.info"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			wait, retry := retryDelayFor(resp, tt.method, tt.attempt)
+			if retry != tt.wantRetry {
+				t.Fatalf("retryDelayFor() retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if wait < tt.wantMin || wait > tt.wantMax {
+				t.Errorf("retryDelayFor() wait = %v, want between %v and %v", wait, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestRateLimitersGet(t *testing.T) {
+	r := newRateLimiters()
+
+	// ワークスペース・メソッドごとに同じトークンバケットを使い続ける
+	l := r.get("T1", "chat.postMessage")
+	if r.get("T1", "chat.postMessage") != l {
+		t.Error("get() returned a different limiter for the same team and method")
+	}
+	if r.get("T2", "chat.postMessage") == l {
+		t.Error("get() shared a limiter between teams")
+	}
+	if r.get("T1", "users.info") == l {
+		t.Error("get() shared a limiter between methods")
+	}
+
+	tests := []struct {
+		method string
+		want   rate.Limit
+	}{
+		{"chat.postMessage", rate.Every(time.Second)},
+		{"users.list", perMinute(tier2PerMinute)},
+		{"users.info", perMinute(tier4PerMinute)},
+		{"auth.test", perMinute(tier3PerMinute)}, // 未登録のメソッドは Tier 3
+	}
+	for _, tt := range tests {
+		l := r.get("T3", tt.method)
+		if l.Limit() != tt.want || l.Burst() != methodBurst {
+			t.Errorf("get(%s) = limit %v burst %d, want limit %v burst %d", tt.method, l.Limit(), l.Burst(), tt.want, methodBurst)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"slack-bot/project/domain"
 	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/metrics"
 
 	"cloud.google.com/go/firestore"
 	"google.golang.org/grpc/codes"
@@ -19,16 +20,17 @@ func isNotFound(err error) bool {
 	return ok && st.Code() == codes.NotFound
 }
 
-// FirestoreRepo は domain.MentionRepository と domain.TenantRepository の Firestore 実装です
+// FirestoreRepo は domain.MentionRepository・domain.TenantRepository・domain.EventRepository の Firestore 実装です
 type FirestoreRepo struct {
 	cli         *firestore.Client
 	tenantsCol  string
 	mentionsCol string
+	eventsCol   string
 }
 
 // NewFirestoreRepo は Firestore リポジトリを初期化します
 func NewFirestoreRepo(ctx context.Context, cfg *config.Config) (*FirestoreRepo, error) {
-	client, err := firestore.NewClient(ctx, cfg.FirestoreProjectID)
+	client, err := firestore.NewClient(ctx, cfg.FirestoreProjectID, metrics.ClientOptions("firestore")...)
 	if err != nil {
 		return nil, fmt.Errorf("firestore: クライアント初期化失敗: %w", err)
 	}
@@ -37,12 +39,14 @@ func NewFirestoreRepo(ctx context.Context, cfg *config.Config) (*FirestoreRepo,
 		cli:         client,
 		tenantsCol:  cfg.CollectionTenants,
 		mentionsCol: cfg.CollectionMentions,
+		eventsCol:   cfg.CollectionEvents,
 	}, nil
 }
 
 // ===== MentionRepository 実装 =====
 
-// Save はメンション監視対象を保存します（新規作成または上書き）
+// Save はメンション監視対象を保存します（新規作成、または保存済みのレコードへのマージ）
+// 保存済みの作成日時・進行状況（ステップ完了・スヌーズなど）を失わないよう、トランザクションで読み取ってからマージします
 func (repo *FirestoreRepo) Save(ctx context.Context, m *domain.Mention) error {
 	if err := m.Validate(); err != nil {
 		return fmt.Errorf("firestore: Save検証失敗: %w", err)
@@ -51,22 +55,58 @@ func (repo *FirestoreRepo) Save(ctx context.Context, m *domain.Mention) error {
 	docID := mentionDocID(m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID)
 	docRef := repo.cli.Collection(repo.mentionsCol).Doc(docID)
 
-	// Firestore保存用のマップ
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		merged := *m
+		snapshot, err := tx.Get(docRef)
+		switch {
+		case err == nil:
+			var saved domain.Mention
+			if err := decodeMention(snapshot, &saved); err != nil {
+				return err
+			}
+			merged = m.MergeSaved(saved)
+		case !isNotFound(err):
+			return err
+		}
+
+		return tx.Set(docRef, mentionData(&merged), firestore.MergeAll)
+	})
+	if err != nil {
+		return fmt.Errorf("firestore: メンション保存失敗 (docID=%s): %w", docID, err)
+	}
+
+	return nil
+}
+
+// mentionData はメンション監視対象を Firestore 保存用のマップに変換します
+func mentionData(m *domain.Mention) map[string]interface{} {
 	data := map[string]interface{}{
 		"team_id":           m.TeamID,
 		"channel_id":        m.ChannelID,
 		"message_ts":        m.MessageTS,
+		"thread_ts":         m.ThreadTS,
 		"mentioned_user_id": m.MentionedUserID,
+		"parent_user_id":    m.ParentUserID,
 		"created_at":        m.CreatedAt,
-		"reminded":          m.Reminded,
-		"escalated":         m.Escalated,
+		"step":              m.Step,
+		"status":            string(m.Status),
+		"replied_at":        m.RepliedAt,
+		"resolved_at":       m.ResolvedAt,
+		"snoozed_until":     m.SnoozedUntil,
+		"acknowledged_at":   m.AcknowledgedAt,
+		"reminded_at":       m.RemindedAt,
+		"escalated_at":      m.EscalatedAt,
+		"closed_at":         m.ClosedAt,
+		"outcome":           string(m.Outcome),
+		"retain_until":      m.RetainUntil,
 	}
 
-	if _, err := docRef.Set(ctx, data, firestore.MergeAll); err != nil {
-		return fmt.Errorf("firestore: メンション保存失敗 (docID=%s): %w", docID, err)
+	// Firestore の TTL ポリシーはタイムスタンプ型のフィールドのみ対象にできるため、保持期限を expire_at にも書き込む
+	if m.RetainUntil > 0 {
+		data["expire_at"] = time.Unix(m.RetainUntil, 0)
 	}
 
-	return nil
+	return data
 }
 
 // Find は指定キーのメンション監視対象を取得します
@@ -84,48 +124,420 @@ func (repo *FirestoreRepo) Find(ctx context.Context, teamID, channelID, messageT
 
 	// Firestore ドキュメントから domain.Mention へ写経
 	var m domain.Mention
-	if err := snapshot.DataTo(&m); err != nil {
+	if err := decodeMention(snapshot, &m); err != nil {
 		return nil, fmt.Errorf("firestore: メンション構造体変換失敗: %w", err)
 	}
 
 	return &m, nil
 }
 
-// MarkReminded は10分後リマインド完了フラグを立てます
-func (repo *FirestoreRepo) MarkReminded(ctx context.Context, teamID, channelID, messageTS, userID string) error {
+// ListByMessage は指定メッセージに紐づくメンション監視対象を全て取得します
+func (repo *FirestoreRepo) ListByMessage(ctx context.Context, teamID, channelID, messageTS string) ([]*domain.Mention, error) {
+	iter := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where("channel_id", "==", channelID).
+		Where("message_ts", "==", messageTS).
+		Documents(ctx)
+
+	snapshots, err := iter.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("firestore: メンション一覧取得失敗 (channel=%s, ts=%s): %w", channelID, messageTS, domain.ErrDatabaseError)
+	}
+
+	mentions := make([]*domain.Mention, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return nil, fmt.Errorf("firestore: メンション構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		mentions = append(mentions, &m)
+	}
+
+	return mentions, nil
+}
+
+// ListByThread は threadTS を親とするスレッドに紐づくメンション監視対象を全て取得します
+func (repo *FirestoreRepo) ListByThread(ctx context.Context, teamID, channelID, threadTS string) ([]*domain.Mention, error) {
+	// 親メッセージへのメンション
+	mentions, err := repo.ListByMessage(ctx, teamID, channelID, threadTS)
+	if err != nil {
+		return nil, err
+	}
+
+	// スレッド内の返信で発生したメンション
+	iter := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where("channel_id", "==", channelID).
+		Where("thread_ts", "==", threadTS).
+		Documents(ctx)
+
+	snapshots, err := iter.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("firestore: スレッドのメンション一覧取得失敗 (channel=%s, thread_ts=%s): %w", channelID, threadTS, domain.ErrDatabaseError)
+	}
+
+	for _, snapshot := range snapshots {
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return nil, fmt.Errorf("firestore: メンション構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		mentions = append(mentions, &m)
+	}
+
+	return mentions, nil
+}
+
+// ListOpenByMentionee は userID が対象者である監視中のメンションを全て取得します
+func (repo *FirestoreRepo) ListOpenByMentionee(ctx context.Context, teamID, userID string) ([]*domain.Mention, error) {
+	return repo.listOpenByUser(ctx, teamID, "mentioned_user_id", userID)
+}
+
+// ListOpenByMentioner は userID がメンション送信者である監視中のメンションを全て取得します
+func (repo *FirestoreRepo) ListOpenByMentioner(ctx context.Context, teamID, userID string) ([]*domain.Mention, error) {
+	return repo.listOpenByUser(ctx, teamID, "parent_user_id", userID)
+}
+
+// listOpenByUser は field が userID に一致する監視中のメンションを全て取得します
+func (repo *FirestoreRepo) listOpenByUser(ctx context.Context, teamID, field, userID string) ([]*domain.Mention, error) {
+	snapshots, err := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where(field, "==", userID).
+		Where("status", "==", string(domain.MentionStatusOpen)).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("firestore: 監視中メンション取得失敗 (team=%s, %s=%s): %w", teamID, field, userID, domain.ErrDatabaseError)
+	}
+
+	mentions := make([]*domain.Mention, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return nil, fmt.Errorf("firestore: メンション構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		mentions = append(mentions, &m)
+	}
+
+	return mentions, nil
+}
+
+// ListCreatedBetween は from 以上 to 未満に作成されたメンションを全て取得します
+// (team_id, created_at) の複合インデックスが必要です
+func (repo *FirestoreRepo) ListCreatedBetween(ctx context.Context, teamID string, from, to int64) ([]*domain.Mention, error) {
+	snapshots, err := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where("created_at", ">=", from).
+		Where("created_at", "<", to).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("firestore: 期間内メンション取得失敗 (team=%s, from=%d, to=%d): %w", teamID, from, to, domain.ErrDatabaseError)
+	}
+
+	mentions := make([]*domain.Mention, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return nil, fmt.Errorf("firestore: メンション構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		mentions = append(mentions, &m)
+	}
+
+	return mentions, nil
+}
+
+// ListOpen はワークスペースの監視中のメンションを古い順に取得します
+// 絞り込み条件ごとに (team_id, status[, channel_id][, mentioned_user_id], created_at) の複合インデックスが必要です
+func (repo *FirestoreRepo) ListOpen(ctx context.Context, teamID string, q domain.OpenMentionQuery) ([]*domain.Mention, error) {
+	query := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where("status", "==", string(domain.MentionStatusOpen))
+	if q.ChannelID != "" {
+		query = query.Where("channel_id", "==", q.ChannelID)
+	}
+	if q.MentionedUserID != "" {
+		query = query.Where("mentioned_user_id", "==", q.MentionedUserID)
+	}
+
+	snapshots, err := query.
+		OrderBy("created_at", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Offset(q.Offset).
+		Limit(q.Limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("firestore: 監視中メンション一覧取得失敗 (team=%s, channel=%s, user=%s): %w", teamID, q.ChannelID, q.MentionedUserID, domain.ErrDatabaseError)
+	}
+
+	mentions := make([]*domain.Mention, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return nil, fmt.Errorf("firestore: メンション構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		mentions = append(mentions, &m)
+	}
+
+	return mentions, nil
+}
+
+// MarkReplied は返信検知により監視を終了し、返信日時を記録します
+func (repo *FirestoreRepo) MarkReplied(ctx context.Context, teamID, channelID, messageTS, userID string, repliedAt int64) error {
+	return repo.closeMention(ctx, teamID, channelID, messageTS, userID, domain.MentionStatusReplied, repliedAt)
+}
+
+// Resolve は対象者の回答（確認済み・担当外・対応済み）または期限切れにより監視を終了します
+func (repo *FirestoreRepo) Resolve(ctx context.Context, teamID, channelID, messageTS, userID string, status domain.MentionStatus, resolvedAt int64) error {
+	return repo.closeMention(ctx, teamID, channelID, messageTS, userID, status, resolvedAt)
+}
+
+// closeMention は監視中のメンションを status で終了し、終了日時と結果（応答までの段階）を記録します
+// 結果はリマインド・エスカレーションの実行日時から判定するため、トランザクションで読み取り→更新します
+// 監視終了済みの場合は何もせずに成功を返します（冪等）。対象が存在しない場合は domain.ErrMentionNotFound を返します
+func (repo *FirestoreRepo) closeMention(ctx context.Context, teamID, channelID, messageTS, userID string, status domain.MentionStatus, closedAt int64) error {
 	docID := mentionDocID(teamID, channelID, messageTS, userID)
 	docRef := repo.cli.Collection(repo.mentionsCol).Doc(docID)
 
-	// 単一フィールド更新
-	_, err := docRef.Update(ctx, []firestore.Update{
-		{Path: "reminded", Value: true},
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return err
+		}
+		if !m.IsOpen() {
+			// すでに監視終了済み（冪等）
+			return nil
+		}
+
+		m.Close(status, closedAt)
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "status", Value: string(m.Status)},
+			{Path: "replied_at", Value: m.RepliedAt},
+			{Path: "resolved_at", Value: m.ResolvedAt},
+			{Path: "closed_at", Value: m.ClosedAt},
+			{Path: "outcome", Value: string(m.Outcome)},
+		})
 	})
 	if err != nil {
 		if isNotFound(err) {
-			// ドキュメントが存在しない場合は ErrMentionNotFound を返す
 			return domain.ErrMentionNotFound
 		}
-		return fmt.Errorf("firestore: リマインド状態更新失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+		return fmt.Errorf("firestore: メンション終了失敗 (docID=%s, status=%s): %w", docID, status, domain.ErrDatabaseError)
 	}
 
 	return nil
 }
 
-// MarkEscalated は30分後エスカレーション完了フラグを立てます
-func (repo *FirestoreRepo) MarkEscalated(ctx context.Context, teamID, channelID, messageTS, userID string) error {
+// Snooze は until までステップの実行を保留します
+func (repo *FirestoreRepo) Snooze(ctx context.Context, teamID, channelID, messageTS, userID string, until int64) error {
+	return repo.updateOpenMention(ctx, teamID, channelID, messageTS, userID, []firestore.Update{
+		{Path: "snoozed_until", Value: until},
+	})
+}
+
+// SetAcknowledged は「確認中」の日時を記録します（0の場合は解除）
+func (repo *FirestoreRepo) SetAcknowledged(ctx context.Context, teamID, channelID, messageTS, userID string, acknowledgedAt int64) error {
+	return repo.updateOpenMention(ctx, teamID, channelID, messageTS, userID, []firestore.Update{
+		{Path: "acknowledged_at", Value: acknowledgedAt},
+	})
+}
+
+// Reopen は status で監視終了したメンションを監視中に戻します
+func (repo *FirestoreRepo) Reopen(ctx context.Context, teamID, channelID, messageTS, userID string, status domain.MentionStatus) (bool, error) {
 	docID := mentionDocID(teamID, channelID, messageTS, userID)
 	docRef := repo.cli.Collection(repo.mentionsCol).Doc(docID)
 
-	// 単一フィールド更新
-	_, err := docRef.Update(ctx, []firestore.Update{
-		{Path: "escalated", Value: true},
+	reopened := false
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		reopened = false
+
+		snapshot, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return err
+		}
+		if m.Status != status {
+			return nil
+		}
+
+		reopened = true
+		m.Reopen()
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "status", Value: string(m.Status)},
+			{Path: "resolved_at", Value: m.ResolvedAt},
+			{Path: "closed_at", Value: m.ClosedAt},
+			{Path: "outcome", Value: string(m.Outcome)},
+		})
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, domain.ErrMentionNotFound
+		}
+		return false, fmt.Errorf("firestore: メンション再開失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	return reopened, nil
+}
+
+// updateOpenMention は監視中のメンションのみをトランザクションで更新します
+// 監視終了済みの場合は何もせずに成功を返します（冪等）
+func (repo *FirestoreRepo) updateOpenMention(ctx context.Context, teamID, channelID, messageTS, userID string, updates []firestore.Update) error {
+	docID := mentionDocID(teamID, channelID, messageTS, userID)
+	docRef := repo.cli.Collection(repo.mentionsCol).Doc(docID)
+
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return err
+		}
+		if !m.IsOpen() {
+			return nil
+		}
+
+		return tx.Update(docRef, updates)
 	})
 	if err != nil {
 		if isNotFound(err) {
-			// ドキュメントが存在しない場合は ErrMentionNotFound を返す
 			return domain.ErrMentionNotFound
 		}
-		return fmt.Errorf("firestore: Escalated フラグ更新失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+		return fmt.Errorf("firestore: メンション状態更新失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	return nil
+}
+
+// CancelOpenByTeam は指定ワークスペースの監視中メンションを全て中止にします
+func (repo *FirestoreRepo) CancelOpenByTeam(ctx context.Context, teamID string, cancelledAt int64) (int, error) {
+	snapshots, err := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where("status", "==", string(domain.MentionStatusOpen)).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("firestore: 監視中メンション取得失敗 (team=%s): %w", teamID, domain.ErrDatabaseError)
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	// 件数が多い場合に備えて BulkWriter でまとめて更新
+	bw := repo.cli.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		job, err := bw.Update(snapshot.Ref, []firestore.Update{
+			{Path: "status", Value: string(domain.MentionStatusCancelled)},
+			{Path: "resolved_at", Value: cancelledAt},
+			{Path: "closed_at", Value: cancelledAt},
+		})
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("firestore: メンション中止登録失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	cancelled := 0
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return cancelled, fmt.Errorf("firestore: メンション中止失敗 (team=%s): %w", teamID, domain.ErrDatabaseError)
+		}
+		cancelled++
+	}
+
+	return cancelled, nil
+}
+
+// DeleteClosedCreatedBefore は before より前に作成され、監視を終了したメンションを最大 limit 件削除します
+func (repo *FirestoreRepo) DeleteClosedCreatedBefore(ctx context.Context, teamID string, before int64, limit int) (int, error) {
+	statuses := make([]string, 0, len(domain.ClosedMentionStatuses()))
+	for _, status := range domain.ClosedMentionStatuses() {
+		statuses = append(statuses, string(status))
+	}
+
+	snapshots, err := repo.cli.Collection(repo.mentionsCol).
+		Where("team_id", "==", teamID).
+		Where("status", "in", statuses).
+		Where("created_at", "<", before).
+		OrderBy("created_at", firestore.Asc).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("firestore: 削除対象メンション取得失敗 (team=%s): %w", teamID, domain.ErrDatabaseError)
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	bw := repo.cli.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		job, err := bw.Delete(snapshot.Ref)
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("firestore: メンション削除登録失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	deleted := 0
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return deleted, fmt.Errorf("firestore: メンション削除失敗 (team=%s): %w", teamID, domain.ErrDatabaseError)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// MarkStepDone は step 番目のエスカレーションステップを完了として記録します
+func (repo *FirestoreRepo) MarkStepDone(ctx context.Context, teamID, channelID, messageTS, userID string, step int, action domain.EscalationAction, notified bool, doneAt int64) error {
+	docID := mentionDocID(teamID, channelID, messageTS, userID)
+	docRef := repo.cli.Collection(repo.mentionsCol).Doc(docID)
+
+	// 完了済みステップを巻き戻さないようトランザクションで読み取り→更新
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var m domain.Mention
+		if err := decodeMention(snapshot, &m); err != nil {
+			return err
+		}
+		if m.Step > step {
+			// すでに完了済み（冪等）
+			return nil
+		}
+
+		m.RecordStep(step, action, notified, doneAt)
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "step", Value: m.Step},
+			{Path: "reminded_at", Value: m.RemindedAt},
+			{Path: "escalated_at", Value: m.EscalatedAt},
+		})
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return domain.ErrMentionNotFound
+		}
+		return fmt.Errorf("firestore: ステップ完了状態更新失敗 (docID=%s, step=%d): %w", docID, step, domain.ErrDatabaseError)
 	}
 
 	return nil
@@ -176,6 +588,7 @@ func (repo *FirestoreRepo) UpsertBotTokenSecret(ctx context.Context, teamID, sec
 		"team_id":               teamID,
 		"bot_token_secret_name": secretName,
 		"created_at":            createdAt,
+		"deactivated_at":        int64(0), // 再インストール時は再有効化
 	}
 
 	if _, err := docRef.Set(ctx, data, firestore.MergeAll); err != nil {
@@ -185,6 +598,102 @@ func (repo *FirestoreRepo) UpsertBotTokenSecret(ctx context.Context, teamID, sec
 	return nil
 }
 
+// ListActive は有効なテナント設定を全て取得します
+// deactivated_at を持たない旧レコードも対象にするため、全件を読み込んでから絞り込みます
+func (repo *FirestoreRepo) ListActive(ctx context.Context) ([]*domain.Tenant, error) {
+	snapshots, err := repo.cli.Collection(repo.tenantsCol).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("firestore: 有効なテナント一覧取得失敗: %w", domain.ErrDatabaseError)
+	}
+
+	tenants := make([]*domain.Tenant, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var t domain.Tenant
+		if err := snapshot.DataTo(&t); err != nil {
+			return nil, fmt.Errorf("firestore: テナント構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		if t.IsActive() {
+			tenants = append(tenants, &t)
+		}
+	}
+
+	return tenants, nil
+}
+
+// ClaimDigest は週次ダイジェストの送信権を取得し、送信日時を記録します
+// 同時に実行された定期実行のうち1つだけが送信するよう、トランザクションで読み取り→更新します
+func (repo *FirestoreRepo) ClaimDigest(ctx context.Context, teamID string, sentAt, notBefore int64) (bool, error) {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	claimed := false
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = false
+
+		snapshot, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var t domain.Tenant
+		if err := snapshot.DataTo(&t); err != nil {
+			return err
+		}
+		if t.DigestSentAt >= notBefore {
+			// 送信済み
+			return nil
+		}
+
+		claimed = true
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "digest_sent_at", Value: sentAt},
+		})
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, domain.ErrTenantNotRegistered
+		}
+		return false, fmt.Errorf("firestore: ダイジェスト送信記録失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	return claimed, nil
+}
+
+// Deactivate はテナントを無効化します
+func (repo *FirestoreRepo) Deactivate(ctx context.Context, teamID string, deactivatedAt int64) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 無効化日時を上書きしないようトランザクションで読み取り→更新
+	err := repo.cli.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(docRef)
+		if err != nil {
+			return err
+		}
+
+		var t domain.Tenant
+		if err := snapshot.DataTo(&t); err != nil {
+			return err
+		}
+		if !t.IsActive() {
+			// すでに無効化済み（冪等）
+			return nil
+		}
+
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "deactivated_at", Value: deactivatedAt},
+		})
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント無効化失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	return nil
+}
+
 // SetManager は上長ユーザーIDを設定します
 func (repo *FirestoreRepo) SetManager(ctx context.Context, teamID string, managerUserID *string) error {
 	docID := tenantDocID(teamID)
@@ -223,6 +732,244 @@ func (repo *FirestoreRepo) SetManager(ctx context.Context, teamID string, manage
 	return nil
 }
 
+// SetUserManager はメンバーごとの上長を設定します
+func (repo *FirestoreRepo) SetUserManager(ctx context.Context, teamID, userID string, managerUserID *string) error {
+	return repo.setManagerRule(ctx, teamID, "user_managers", userID, managerUserID)
+}
+
+// SetChannelManager はチャンネルごとの上長を設定します
+func (repo *FirestoreRepo) SetChannelManager(ctx context.Context, teamID, channelID string, managerUserID *string) error {
+	return repo.setManagerRule(ctx, teamID, "channel_managers", channelID, managerUserID)
+}
+
+// setManagerRule は上長ルールのマップ（field.key）を更新します
+// managerUserID が nil の場合はキーを削除します
+func (repo *FirestoreRepo) setManagerRule(ctx context.Context, teamID, field, key string, managerUserID *string) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 既存レコードを確認（存在しない場合はエラー）
+	if _, err := docRef.Get(ctx); err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント確認失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	var value interface{} = firestore.Delete
+	if managerUserID != nil {
+		value = *managerUserID
+	}
+
+	// ユーザーID・チャンネルIDをそのままキーにするため FieldPath で指定
+	if _, err := docRef.Update(ctx, []firestore.Update{
+		{FieldPath: firestore.FieldPath{field, key}, Value: value},
+	}); err != nil {
+		return fmt.Errorf("firestore: 上長ルール設定失敗 (docID=%s, field=%s): %w", docID, field, err)
+	}
+
+	return nil
+}
+
+// SetEscalationPolicy はエスカレーション手順を設定します
+func (repo *FirestoreRepo) SetEscalationPolicy(ctx context.Context, teamID string, policy *domain.EscalationPolicy) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 既存レコードを確認（存在しない場合はエラー）
+	if _, err := docRef.Get(ctx); err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント確認失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	// nil の場合はフィールドを削除してデフォルト手順に戻す
+	var value interface{} = firestore.Delete
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("firestore: エスカレーション手順検証失敗: %w", err)
+		}
+		value = *policy
+	}
+
+	if _, err := docRef.Update(ctx, []firestore.Update{
+		{Path: "escalation_policy", Value: value},
+	}); err != nil {
+		return fmt.Errorf("firestore: エスカレーション手順設定失敗 (docID=%s): %w", docID, err)
+	}
+
+	return nil
+}
+
+// SetWorkingCalendar は稼働カレンダーを設定します
+func (repo *FirestoreRepo) SetWorkingCalendar(ctx context.Context, teamID string, calendar *domain.WorkingCalendar) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 既存レコードを確認（存在しない場合はエラー）
+	if _, err := docRef.Get(ctx); err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント確認失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	// nil の場合はフィールドを削除して経過時間のみの計算に戻す
+	var value interface{} = firestore.Delete
+	if calendar != nil {
+		if err := calendar.Validate(); err != nil {
+			return fmt.Errorf("firestore: 稼働カレンダー検証失敗: %w", err)
+		}
+		value = calendar.Normalize()
+	}
+
+	if _, err := docRef.Update(ctx, []firestore.Update{
+		{Path: "working_calendar", Value: value},
+	}); err != nil {
+		return fmt.Errorf("firestore: 稼働カレンダー設定失敗 (docID=%s): %w", docID, err)
+	}
+
+	return nil
+}
+
+// SetReplyRule はワークスペース全体の返信判定ルールを設定します
+func (repo *FirestoreRepo) SetReplyRule(ctx context.Context, teamID string, rule *domain.ReplyRule) error {
+	return repo.setReplyRule(ctx, teamID, firestore.FieldPath{"reply_rule"}, rule)
+}
+
+// SetChannelReplyRule はチャンネルごとの返信判定ルールを設定します
+func (repo *FirestoreRepo) SetChannelReplyRule(ctx context.Context, teamID, channelID string, rule *domain.ReplyRule) error {
+	return repo.setReplyRule(ctx, teamID, firestore.FieldPath{"channel_reply_rules", channelID}, rule)
+}
+
+// setReplyRule は返信判定ルールのフィールドを更新します
+// rule が nil の場合はフィールドを削除します
+func (repo *FirestoreRepo) setReplyRule(ctx context.Context, teamID string, path firestore.FieldPath, rule *domain.ReplyRule) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 既存レコードを確認（存在しない場合はエラー）
+	if _, err := docRef.Get(ctx); err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント確認失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	var value interface{} = firestore.Delete
+	if rule != nil {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("firestore: 返信判定ルール検証失敗: %w", err)
+		}
+		value = *rule
+	}
+
+	if _, err := docRef.Update(ctx, []firestore.Update{
+		{FieldPath: path, Value: value},
+	}); err != nil {
+		return fmt.Errorf("firestore: 返信判定ルール設定失敗 (docID=%s): %w", docID, err)
+	}
+
+	return nil
+}
+
+// SetReactionWorkflow はリアクションによる状態更新の絵文字を設定します
+func (repo *FirestoreRepo) SetReactionWorkflow(ctx context.Context, teamID string, workflow *domain.ReactionWorkflow) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 既存レコードを確認（存在しない場合はエラー）
+	if _, err := docRef.Get(ctx); err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント確認失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	// nil の場合はフィールドを削除してデフォルトの絵文字に戻す
+	var value interface{} = firestore.Delete
+	if workflow != nil {
+		if err := workflow.Validate(); err != nil {
+			return fmt.Errorf("firestore: リアクション設定検証失敗: %w", err)
+		}
+		value = *workflow
+	}
+
+	if _, err := docRef.Update(ctx, []firestore.Update{
+		{Path: "reaction_workflow", Value: value},
+	}); err != nil {
+		return fmt.Errorf("firestore: リアクション設定失敗 (docID=%s): %w", docID, err)
+	}
+
+	return nil
+}
+
+// SetRetentionDays はメンション記録の保持期間を設定します
+func (repo *FirestoreRepo) SetRetentionDays(ctx context.Context, teamID string, days int) error {
+	docID := tenantDocID(teamID)
+	docRef := repo.cli.Collection(repo.tenantsCol).Doc(docID)
+
+	// 既存レコードを確認（存在しない場合はエラー）
+	if _, err := docRef.Get(ctx); err != nil {
+		if isNotFound(err) {
+			return domain.ErrTenantNotRegistered
+		}
+		return fmt.Errorf("firestore: テナント確認失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	// 0 の場合はフィールドを削除してデフォルトの保持期間に戻す
+	var value interface{} = firestore.Delete
+	if days != 0 {
+		if err := domain.ValidateRetentionDays(days); err != nil {
+			return fmt.Errorf("firestore: 保持期間検証失敗: %w", err)
+		}
+		value = days
+	}
+
+	if _, err := docRef.Update(ctx, []firestore.Update{
+		{Path: "retention_days", Value: value},
+	}); err != nil {
+		return fmt.Errorf("firestore: 保持期間設定失敗 (docID=%s): %w", docID, err)
+	}
+
+	return nil
+}
+
+// ===== EventRepository 実装 =====
+
+// ClaimEvent は event_id の処理記録を作成します（記録済みの場合は false）
+// 同時に届いた再送のうち1つだけが処理するよう、ドキュメントの新規作成（Create）で判定します
+func (repo *FirestoreRepo) ClaimEvent(ctx context.Context, teamID, eventID string, expireAt int64) (bool, error) {
+	docID := eventDocID(teamID, eventID)
+	docRef := repo.cli.Collection(repo.eventsCol).Doc(docID)
+
+	// expire_at は Firestore の TTL ポリシーで期限切れの記録を削除するためのタイムスタンプ
+	_, err := docRef.Create(ctx, map[string]interface{}{
+		"team_id":    teamID,
+		"event_id":   eventID,
+		"claimed_at": time.Now().Unix(),
+		"expire_at":  time.Unix(expireAt, 0),
+	})
+	if err != nil {
+		if status.Code(err) == codes.AlreadyExists {
+			return false, nil
+		}
+		return false, fmt.Errorf("firestore: イベント記録失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+
+	return true, nil
+}
+
+// ReleaseEvent は event_id の処理記録を削除します
+func (repo *FirestoreRepo) ReleaseEvent(ctx context.Context, teamID, eventID string) error {
+	docID := eventDocID(teamID, eventID)
+	if _, err := repo.cli.Collection(repo.eventsCol).Doc(docID).Delete(ctx); err != nil {
+		return fmt.Errorf("firestore: イベント記録削除失敗 (docID=%s): %w", docID, domain.ErrDatabaseError)
+	}
+	return nil
+}
+
 // Close は Firestore クライアントを閉じます
 func (repo *FirestoreRepo) Close() error {
 	if repo.cli != nil {
@@ -239,6 +986,12 @@ func mentionDocID(team, channel, ts, user string) string {
 	return fmt.Sprintf("%s:%s:%s:%s", team, channel, ts, user)
 }
 
+// eventDocID は Slack イベントの処理記録のドキュメントID を生成します
+// 形式: "team:event_id"
+func eventDocID(team, eventID string) string {
+	return fmt.Sprintf("%s:%s", team, eventID)
+}
+
 // tenantDocID はテナント設定のドキュメントID を生成します
 // 形式: "team"
 func tenantDocID(team string) string {
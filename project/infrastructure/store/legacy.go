@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"slack-bot/project/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 旧形式のメンションドキュメントは status・step を持たず、10分後のリマインド（reminded）と
+// 30分後の再リマインド＆上長通知（escalated）の完了フラグで進行状況を記録していました
+// 既定のエスカレーション手順では、リマインドが最初のステップ、再リマインドと上長DMが2・3番目のステップに当たります
+const (
+	// legacyRemindedStep は reminded のみ完了していた旧形式のメンションの次のステップです
+	legacyRemindedStep = 1
+
+	// legacyEscalatedStep は escalated まで完了していた旧形式のメンションの次のステップです
+	legacyEscalatedStep = 3
+)
+
+// decodeMention は Firestore ドキュメントを m に変換します
+// 旧形式のドキュメントは現在の形式に読み替えます
+func decodeMention(snapshot *firestore.DocumentSnapshot, m *domain.Mention) error {
+	if err := snapshot.DataTo(m); err != nil {
+		return err
+	}
+	upgradeLegacyMention(m, snapshot.Data())
+	return nil
+}
+
+// upgradeLegacyMention は data が旧形式（status なし）のドキュメントの場合に、m を監視中とし
+// reminded・escalated フラグから次のステップを設定して true を返します
+// 旧形式ではリマインド・エスカレーションの実行日時を記録していないため、RemindedAt・EscalatedAt は0のままです
+func upgradeLegacyMention(m *domain.Mention, data map[string]interface{}) bool {
+	if _, ok := data["status"]; ok {
+		return false
+	}
+
+	m.Status = domain.MentionStatusOpen
+	reminded, _ := data["reminded"].(bool)
+	escalated, _ := data["escalated"].(bool)
+	switch {
+	case escalated:
+		m.Step = max(m.Step, legacyEscalatedStep)
+	case reminded:
+		m.Step = max(m.Step, legacyRemindedStep)
+	}
+	return true
+}
+
+// BackfillLegacyMentions は旧形式のメンションドキュメントに status・step を書き込み、reminded・escalated フラグを削除します
+// 監視中メンションの一覧・一括中止は status で絞り込むため、status のない旧形式のドキュメントは書き換えるまで対象になりません
+// 書き換え済みのドキュメントは reminded を持たないため、何度実行しても同じ結果になります
+func (repo *FirestoreRepo) BackfillLegacyMentions(ctx context.Context) (int, error) {
+	// 現在の形式のドキュメントは reminded を書き込まないため、reminded を持つドキュメントが旧形式です
+	snapshots, err := repo.cli.Collection(repo.mentionsCol).
+		Where("reminded", "in", []bool{true, false}).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, fmt.Errorf("firestore: 旧形式メンション取得失敗: %w", domain.ErrDatabaseError)
+	}
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+
+	bw := repo.cli.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		updates, err := legacyMentionUpdates(snapshot)
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("firestore: メンション構造体変換失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		job, err := bw.Update(snapshot.Ref, updates)
+		if err != nil {
+			bw.End()
+			return 0, fmt.Errorf("firestore: 旧形式メンション更新登録失敗 (docID=%s): %w", snapshot.Ref.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	bw.End()
+
+	upgraded := 0
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return upgraded, fmt.Errorf("firestore: 旧形式メンション更新失敗: %w", domain.ErrDatabaseError)
+		}
+		upgraded++
+	}
+
+	return upgraded, nil
+}
+
+// legacyMentionUpdates は旧形式のドキュメントを現在の形式に書き換える更新内容を返します
+func legacyMentionUpdates(snapshot *firestore.DocumentSnapshot) ([]firestore.Update, error) {
+	var m domain.Mention
+	if err := decodeMention(snapshot, &m); err != nil {
+		return nil, err
+	}
+	return []firestore.Update{
+		{Path: "status", Value: string(m.Status)},
+		{Path: "step", Value: m.Step},
+		{Path: "reminded", Value: firestore.Delete},
+		{Path: "escalated", Value: firestore.Delete},
+	}, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"testing"
+
+	"slack-bot/project/domain"
+)
+
+func TestUpgradeLegacyMention(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       map[string]interface{}
+		m          domain.Mention
+		wantLegacy bool
+		wantStatus domain.MentionStatus
+		wantStep   int
+	}{
+		{
+			name:       "旧形式・未リマインド",
+			data:       map[string]interface{}{"reminded": false, "escalated": false},
+			wantLegacy: true,
+			wantStatus: domain.MentionStatusOpen,
+			wantStep:   0,
+		},
+		{
+			name:       "旧形式・リマインド済み",
+			data:       map[string]interface{}{"reminded": true, "escalated": false},
+			wantLegacy: true,
+			wantStatus: domain.MentionStatusOpen,
+			wantStep:   1,
+		},
+		{
+			name:       "旧形式・エスカレーション済み",
+			data:       map[string]interface{}{"reminded": true, "escalated": true},
+			wantLegacy: true,
+			wantStatus: domain.MentionStatusOpen,
+			wantStep:   3,
+		},
+		{
+			name:       "旧形式・フラグなし",
+			data:       map[string]interface{}{},
+			wantLegacy: true,
+			wantStatus: domain.MentionStatusOpen,
+			wantStep:   0,
+		},
+		{
+			// 書き換え途中で step だけ進んだドキュメントは巻き戻さない
+			name:       "旧形式・ステップ記録済み",
+			data:       map[string]interface{}{"reminded": true, "step": int64(2)},
+			m:          domain.Mention{Step: 2},
+			wantLegacy: true,
+			wantStatus: domain.MentionStatusOpen,
+			wantStep:   2,
+		},
+		{
+			name:       "現在の形式",
+			data:       map[string]interface{}{"status": "replied", "step": int64(1), "reminded": true, "escalated": true},
+			m:          domain.Mention{Status: domain.MentionStatusReplied, Step: 1},
+			wantLegacy: false,
+			wantStatus: domain.MentionStatusReplied,
+			wantStep:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.m
+			if got := upgradeLegacyMention(&m, tt.data); got != tt.wantLegacy {
+				t.Errorf("upgradeLegacyMention() = %v, want %v", got, tt.wantLegacy)
+			}
+			if m.Status != tt.wantStatus || m.Step != tt.wantStep {
+				t.Errorf("mention = status %q step %d, want %q %d", m.Status, m.Step, tt.wantStatus, tt.wantStep)
+			}
+		})
+	}
+}
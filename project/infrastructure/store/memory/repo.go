@@ -0,0 +1,616 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"slack-bot/project/domain"
+)
+
+// Repo は domain.MentionRepository・domain.TenantRepository・domain.EventRepository のインメモリ実装です
+// ローカル開発やサービス層のテストで GCP プロジェクトなしに動かすために使用します
+// プロセス終了時にデータは失われます
+type Repo struct {
+	mu       sync.RWMutex
+	mentions map[string]domain.Mention // mentionKey -> Mention
+	tenants  map[string]domain.Tenant  // teamID -> Tenant
+	events   map[string]int64          // "team:event_id" -> 記録の有効期限（Unix秒）
+}
+
+// NewRepo はインメモリリポジトリを初期化します
+func NewRepo() *Repo {
+	return &Repo{
+		mentions: make(map[string]domain.Mention),
+		tenants:  make(map[string]domain.Tenant),
+		events:   make(map[string]int64),
+	}
+}
+
+// ===== MentionRepository 実装 =====
+
+// Save はメンション監視対象を保存します（新規作成、または保存済みのレコードへのマージ）
+func (repo *Repo) Save(ctx context.Context, m *domain.Mention) error {
+	if err := m.Validate(); err != nil {
+		return fmt.Errorf("memory: Save検証失敗: %w", err)
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// 保存済みの作成日時・進行状況（ステップ完了・スヌーズなど）は保持する
+	// 呼び出し側の構造体を共有しないよう値コピーで保持
+	key := domain.MentionKey(m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID)
+	if saved, ok := repo.mentions[key]; ok {
+		repo.mentions[key] = m.MergeSaved(saved)
+	} else {
+		repo.mentions[key] = *m
+	}
+
+	return nil
+}
+
+// Find は指定キーのメンション監視対象を取得します
+func (repo *Repo) Find(ctx context.Context, teamID, channelID, messageTS, userID string) (*domain.Mention, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	m, ok := repo.mentions[domain.MentionKey(teamID, channelID, messageTS, userID)]
+	if !ok {
+		return nil, domain.ErrMentionNotFound
+	}
+
+	return &m, nil
+}
+
+// ListByMessage は指定メッセージに紐づくメンション監視対象を全て取得します
+func (repo *Repo) ListByMessage(ctx context.Context, teamID, channelID, messageTS string) ([]*domain.Mention, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	mentions := make([]*domain.Mention, 0)
+	for _, m := range repo.mentions {
+		if m.TeamID == teamID && m.ChannelID == channelID && m.MessageTS == messageTS {
+			mentions = append(mentions, &m)
+		}
+	}
+
+	return mentions, nil
+}
+
+// ListByThread は threadTS を親とするスレッドに紐づくメンション監視対象を全て取得します
+func (repo *Repo) ListByThread(ctx context.Context, teamID, channelID, threadTS string) ([]*domain.Mention, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	mentions := make([]*domain.Mention, 0)
+	for _, m := range repo.mentions {
+		if m.TeamID == teamID && m.ChannelID == channelID && m.ThreadRootTS() == threadTS {
+			mentions = append(mentions, &m)
+		}
+	}
+
+	return mentions, nil
+}
+
+// ListOpenByMentionee は userID が対象者である監視中のメンションを全て取得します
+func (repo *Repo) ListOpenByMentionee(ctx context.Context, teamID, userID string) ([]*domain.Mention, error) {
+	return repo.listOpen(func(m domain.Mention) bool {
+		return m.TeamID == teamID && m.MentionedUserID == userID
+	}), nil
+}
+
+// ListOpenByMentioner は userID がメンション送信者である監視中のメンションを全て取得します
+func (repo *Repo) ListOpenByMentioner(ctx context.Context, teamID, userID string) ([]*domain.Mention, error) {
+	return repo.listOpen(func(m domain.Mention) bool {
+		return m.TeamID == teamID && m.ParentUserID == userID
+	}), nil
+}
+
+// ListCreatedBetween は from 以上 to 未満に作成されたメンションを全て取得します
+func (repo *Repo) ListCreatedBetween(ctx context.Context, teamID string, from, to int64) ([]*domain.Mention, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	mentions := make([]*domain.Mention, 0)
+	for _, m := range repo.mentions {
+		if m.TeamID == teamID && m.CreatedAt >= from && m.CreatedAt < to {
+			mentions = append(mentions, &m)
+		}
+	}
+
+	return mentions, nil
+}
+
+// ListOpen はワークスペースの監視中のメンションを古い順に取得します
+func (repo *Repo) ListOpen(ctx context.Context, teamID string, q domain.OpenMentionQuery) ([]*domain.Mention, error) {
+	mentions := repo.listOpen(func(m domain.Mention) bool {
+		return m.TeamID == teamID &&
+			(q.ChannelID == "" || m.ChannelID == q.ChannelID) &&
+			(q.MentionedUserID == "" || m.MentionedUserID == q.MentionedUserID)
+	})
+
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].CreatedAt != mentions[j].CreatedAt {
+			return mentions[i].CreatedAt < mentions[j].CreatedAt
+		}
+		return domain.MentionKey(mentions[i].TeamID, mentions[i].ChannelID, mentions[i].MessageTS, mentions[i].MentionedUserID) <
+			domain.MentionKey(mentions[j].TeamID, mentions[j].ChannelID, mentions[j].MessageTS, mentions[j].MentionedUserID)
+	})
+
+	if q.Offset >= len(mentions) {
+		return []*domain.Mention{}, nil
+	}
+	mentions = mentions[q.Offset:]
+	if len(mentions) > q.Limit {
+		mentions = mentions[:q.Limit]
+	}
+	return mentions, nil
+}
+
+// listOpen は match に一致する監視中のメンションを全て返します
+func (repo *Repo) listOpen(match func(m domain.Mention) bool) []*domain.Mention {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	mentions := make([]*domain.Mention, 0)
+	for _, m := range repo.mentions {
+		if m.IsOpen() && match(m) {
+			mentions = append(mentions, &m)
+		}
+	}
+
+	return mentions
+}
+
+// MarkReplied は返信検知により監視を終了し、返信日時を記録します
+func (repo *Repo) MarkReplied(ctx context.Context, teamID, channelID, messageTS, userID string, repliedAt int64) error {
+	return repo.updateMention(teamID, channelID, messageTS, userID, func(m *domain.Mention) {
+		if !m.IsOpen() {
+			// すでに監視終了済み（冪等）
+			return
+		}
+		m.Close(domain.MentionStatusReplied, repliedAt)
+	})
+}
+
+// MarkStepDone は step 番目のエスカレーションステップを完了として記録します
+func (repo *Repo) MarkStepDone(ctx context.Context, teamID, channelID, messageTS, userID string, step int, action domain.EscalationAction, notified bool, doneAt int64) error {
+	return repo.updateMention(teamID, channelID, messageTS, userID, func(m *domain.Mention) {
+		if m.Step > step {
+			// すでに完了済み（冪等）
+			return
+		}
+		m.RecordStep(step, action, notified, doneAt)
+	})
+}
+
+// Resolve は対象者の回答（確認済み・担当外・対応済み）または期限切れにより監視を終了します
+func (repo *Repo) Resolve(ctx context.Context, teamID, channelID, messageTS, userID string, status domain.MentionStatus, resolvedAt int64) error {
+	return repo.updateMention(teamID, channelID, messageTS, userID, func(m *domain.Mention) {
+		if !m.IsOpen() {
+			// すでに監視終了済み（冪等）
+			return
+		}
+		m.Close(status, resolvedAt)
+	})
+}
+
+// Snooze は until までステップの実行を保留します
+func (repo *Repo) Snooze(ctx context.Context, teamID, channelID, messageTS, userID string, until int64) error {
+	return repo.updateMention(teamID, channelID, messageTS, userID, func(m *domain.Mention) {
+		if !m.IsOpen() {
+			return
+		}
+		m.SnoozedUntil = until
+	})
+}
+
+// SetAcknowledged は「確認中」の日時を記録します（0の場合は解除）
+func (repo *Repo) SetAcknowledged(ctx context.Context, teamID, channelID, messageTS, userID string, acknowledgedAt int64) error {
+	return repo.updateMention(teamID, channelID, messageTS, userID, func(m *domain.Mention) {
+		if !m.IsOpen() {
+			return
+		}
+		m.AcknowledgedAt = acknowledgedAt
+	})
+}
+
+// Reopen は status で監視終了したメンションを監視中に戻します
+func (repo *Repo) Reopen(ctx context.Context, teamID, channelID, messageTS, userID string, status domain.MentionStatus) (bool, error) {
+	reopened := false
+	err := repo.updateMention(teamID, channelID, messageTS, userID, func(m *domain.Mention) {
+		if m.Status != status {
+			return
+		}
+		m.Reopen()
+		reopened = true
+	})
+	return reopened, err
+}
+
+// CancelOpenByTeam は指定ワークスペースの監視中メンションを全て中止にします
+func (repo *Repo) CancelOpenByTeam(ctx context.Context, teamID string, cancelledAt int64) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	cancelled := 0
+	for key, m := range repo.mentions {
+		if m.TeamID != teamID || !m.IsOpen() {
+			continue
+		}
+		m.Close(domain.MentionStatusCancelled, cancelledAt)
+		repo.mentions[key] = m
+		cancelled++
+	}
+
+	return cancelled, nil
+}
+
+// DeleteClosedCreatedBefore は before より前に作成され、監視を終了したメンションを最大 limit 件削除します
+func (repo *Repo) DeleteClosedCreatedBefore(ctx context.Context, teamID string, before int64, limit int) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	deleted := 0
+	for key, m := range repo.mentions {
+		if deleted >= limit {
+			break
+		}
+		if m.TeamID != teamID || m.IsOpen() || m.CreatedAt >= before {
+			continue
+		}
+		delete(repo.mentions, key)
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// updateMention は既存メンションをロック下で更新します
+// 対象が存在しない場合は domain.ErrMentionNotFound を返します
+func (repo *Repo) updateMention(teamID, channelID, messageTS, userID string, fn func(m *domain.Mention)) error {
+	key := domain.MentionKey(teamID, channelID, messageTS, userID)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	m, ok := repo.mentions[key]
+	if !ok {
+		return domain.ErrMentionNotFound
+	}
+
+	fn(&m)
+	repo.mentions[key] = m
+
+	return nil
+}
+
+// ===== TenantRepository 実装 =====
+
+// Get はテナント設定を取得します
+func (repo *Repo) Get(ctx context.Context, teamID string) (*domain.Tenant, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	t, ok := repo.tenants[teamID]
+	if !ok {
+		return nil, domain.ErrTenantNotRegistered
+	}
+
+	return copyTenant(t), nil
+}
+
+// ListActive は有効なテナント設定を全て取得します
+func (repo *Repo) ListActive(ctx context.Context) ([]*domain.Tenant, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenants := make([]*domain.Tenant, 0)
+	for _, t := range repo.tenants {
+		if t.IsActive() {
+			tenants = append(tenants, copyTenant(t))
+		}
+	}
+
+	return tenants, nil
+}
+
+// ClaimDigest は週次ダイジェストの送信権を取得し、送信日時を記録します
+func (repo *Repo) ClaimDigest(ctx context.Context, teamID string, sentAt, notBefore int64) (bool, error) {
+	claimed := false
+	err := repo.updateTenant(teamID, func(t *domain.Tenant) {
+		if t.DigestSentAt >= notBefore {
+			// 送信済み
+			return
+		}
+		t.DigestSentAt = sentAt
+		claimed = true
+	})
+	return claimed, err
+}
+
+// copyTenant はポインタフィールドを呼び出し側と共有しないようテナントを複製します
+func copyTenant(t domain.Tenant) *domain.Tenant {
+	t.ManagerUserID = copyString(t.ManagerUserID)
+	t.EscalationPolicy = copyEscalationPolicy(t.EscalationPolicy)
+	t.UserManagers = copyStringMap(t.UserManagers)
+	t.ChannelManagers = copyStringMap(t.ChannelManagers)
+	if t.ReplyRule != nil {
+		r := *t.ReplyRule
+		t.ReplyRule = &r
+	}
+	if t.ReactionWorkflow != nil {
+		w := *t.ReactionWorkflow
+		t.ReactionWorkflow = &w
+	}
+	if t.ChannelReplyRules != nil {
+		rules := make(map[string]domain.ReplyRule, len(t.ChannelReplyRules))
+		for k, v := range t.ChannelReplyRules {
+			rules[k] = v
+		}
+		t.ChannelReplyRules = rules
+	}
+	if t.WorkingCalendar != nil {
+		c := t.WorkingCalendar.Normalize()
+		t.WorkingCalendar = &c
+	}
+
+	return &t
+}
+
+// UpsertBotTokenSecret は Botトークンシークレット名を保存します
+func (repo *Repo) UpsertBotTokenSecret(ctx context.Context, teamID, secretName string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	t, ok := repo.tenants[teamID]
+	if !ok || t.CreatedAt <= 0 {
+		// 新規作成時のみ CreatedAt を設定
+		t.CreatedAt = time.Now().Unix()
+	}
+	t.TeamID = teamID
+	t.BotTokenSecretName = secretName
+	t.DeactivatedAt = 0 // 再インストール時は再有効化
+
+	if err := t.Validate(); err != nil {
+		return fmt.Errorf("memory: テナント検証失敗: %w", err)
+	}
+
+	repo.tenants[teamID] = t
+
+	return nil
+}
+
+// Deactivate はテナントを無効化します
+func (repo *Repo) Deactivate(ctx context.Context, teamID string, deactivatedAt int64) error {
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		if !t.IsActive() {
+			// すでに無効化済み（冪等）
+			return
+		}
+		t.DeactivatedAt = deactivatedAt
+	})
+}
+
+// SetManager は上長ユーザーIDを設定します
+func (repo *Repo) SetManager(ctx context.Context, teamID string, managerUserID *string) error {
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		// nil の場合は上長設定を解除
+		t.ManagerUserID = copyString(managerUserID)
+	})
+}
+
+// SetUserManager はメンバーごとの上長を設定します
+func (repo *Repo) SetUserManager(ctx context.Context, teamID, userID string, managerUserID *string) error {
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.UserManagers = setOrDelete(t.UserManagers, userID, managerUserID)
+	})
+}
+
+// SetChannelManager はチャンネルごとの上長を設定します
+func (repo *Repo) SetChannelManager(ctx context.Context, teamID, channelID string, managerUserID *string) error {
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.ChannelManagers = setOrDelete(t.ChannelManagers, channelID, managerUserID)
+	})
+}
+
+// SetEscalationPolicy はエスカレーション手順を設定します
+func (repo *Repo) SetEscalationPolicy(ctx context.Context, teamID string, policy *domain.EscalationPolicy) error {
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("memory: エスカレーション手順検証失敗: %w", err)
+		}
+	}
+
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.EscalationPolicy = copyEscalationPolicy(policy)
+	})
+}
+
+// SetWorkingCalendar は稼働カレンダーを設定します
+func (repo *Repo) SetWorkingCalendar(ctx context.Context, teamID string, calendar *domain.WorkingCalendar) error {
+	var normalized *domain.WorkingCalendar
+	if calendar != nil {
+		if err := calendar.Validate(); err != nil {
+			return fmt.Errorf("memory: 稼働カレンダー検証失敗: %w", err)
+		}
+		// Normalize はスライスを新しく確保するため呼び出し側と共有しない
+		c := calendar.Normalize()
+		normalized = &c
+	}
+
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.WorkingCalendar = normalized
+	})
+}
+
+// SetReplyRule はワークスペース全体の返信判定ルールを設定します
+func (repo *Repo) SetReplyRule(ctx context.Context, teamID string, rule *domain.ReplyRule) error {
+	if rule != nil {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("memory: 返信判定ルール検証失敗: %w", err)
+		}
+		r := *rule
+		rule = &r
+	}
+
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.ReplyRule = rule
+	})
+}
+
+// SetChannelReplyRule はチャンネルごとの返信判定ルールを設定します
+func (repo *Repo) SetChannelReplyRule(ctx context.Context, teamID, channelID string, rule *domain.ReplyRule) error {
+	if rule != nil {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("memory: 返信判定ルール検証失敗: %w", err)
+		}
+	}
+
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		rules := make(map[string]domain.ReplyRule, len(t.ChannelReplyRules)+1)
+		for k, v := range t.ChannelReplyRules {
+			rules[k] = v
+		}
+		if rule != nil {
+			rules[channelID] = *rule
+		} else {
+			delete(rules, channelID)
+		}
+		t.ChannelReplyRules = rules
+	})
+}
+
+// SetReactionWorkflow はリアクションによる状態更新の絵文字を設定します
+func (repo *Repo) SetReactionWorkflow(ctx context.Context, teamID string, workflow *domain.ReactionWorkflow) error {
+	if workflow != nil {
+		if err := workflow.Validate(); err != nil {
+			return fmt.Errorf("memory: リアクション設定検証失敗: %w", err)
+		}
+		w := *workflow
+		workflow = &w
+	}
+
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.ReactionWorkflow = workflow
+	})
+}
+
+// SetRetentionDays はメンション記録の保持期間を設定します
+func (repo *Repo) SetRetentionDays(ctx context.Context, teamID string, days int) error {
+	// 0 の場合は設定を解除してデフォルトの保持期間に戻す
+	if days != 0 {
+		if err := domain.ValidateRetentionDays(days); err != nil {
+			return fmt.Errorf("memory: 保持期間検証失敗: %w", err)
+		}
+	}
+
+	return repo.updateTenant(teamID, func(t *domain.Tenant) {
+		t.RetentionDays = days
+	})
+}
+
+// updateTenant は既存テナントをロック下で更新します
+// 対象が存在しない場合は domain.ErrTenantNotRegistered を返します
+func (repo *Repo) updateTenant(teamID string, fn func(t *domain.Tenant)) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	t, ok := repo.tenants[teamID]
+	if !ok {
+		return domain.ErrTenantNotRegistered
+	}
+
+	fn(&t)
+	repo.tenants[teamID] = t
+
+	return nil
+}
+
+// Close は Firestore 実装とインターフェースを揃えるためのもので、何もしません
+func (repo *Repo) Close() error {
+	return nil
+}
+
+// ===== EventRepository 実装 =====
+
+// ClaimEvent は event_id の処理記録を作成します（有効期限内の記録がある場合は false）
+func (repo *Repo) ClaimEvent(ctx context.Context, teamID, eventID string, expireAt int64) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now().Unix()
+	key := teamID + ":" + eventID
+	if exp, ok := repo.events[key]; ok && exp > now {
+		return false, nil
+	}
+
+	// 期限切れの記録を破棄（Firestore の TTL の代わり）
+	for k, exp := range repo.events {
+		if exp <= now {
+			delete(repo.events, k)
+		}
+	}
+
+	repo.events[key] = expireAt
+	return true, nil
+}
+
+// ReleaseEvent は event_id の処理記録を削除します
+func (repo *Repo) ReleaseEvent(ctx context.Context, teamID, eventID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	delete(repo.events, teamID+":"+eventID)
+	return nil
+}
+
+// ===== ヘルパー関数 =====
+
+// copyString は文字列ポインタの複製を返します
+func copyString(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+// copyEscalationPolicy はエスカレーション手順の複製を返します
+func copyEscalationPolicy(p *domain.EscalationPolicy) *domain.EscalationPolicy {
+	if p == nil {
+		return nil
+	}
+	c := domain.EscalationPolicy{Steps: append([]domain.EscalationStep(nil), p.Steps...)}
+	return &c
+}
+
+// copyStringMap は文字列マップの複製を返します
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// setOrDelete は value が nil ならキーを削除し、そうでなければ値を設定した新しいマップを返します
+// Get で返したマップと共有しないよう常に複製します
+func setOrDelete(m map[string]string, key string, value *string) map[string]string {
+	c := copyStringMap(m)
+	if value == nil {
+		delete(c, key)
+		return c
+	}
+	if c == nil {
+		c = make(map[string]string)
+	}
+	c[key] = *value
+	return c
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"slack-bot/project/domain"
+)
+
+// newMention は監視中のテスト用メンションを返します
+func newMention(channelID, messageTS, userID string, createdAt int64) *domain.Mention {
+	return &domain.Mention{
+		TeamID:          "T1",
+		ChannelID:       channelID,
+		MessageTS:       messageTS,
+		MentionedUserID: userID,
+		ParentUserID:    "U0",
+		CreatedAt:       createdAt,
+		Status:          domain.MentionStatusOpen,
+	}
+}
+
+func TestRepoSaveMergesProgress(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepo()
+
+	if err := repo.Save(ctx, newMention("C1", "100.0", "U1", 1000)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := repo.MarkStepDone(ctx, "T1", "C1", "100.0", "U1", 0, domain.EscalationActionThreadReminder, true, 1600); err != nil {
+		t.Fatalf("MarkStepDone() error = %v", err)
+	}
+	if err := repo.Snooze(ctx, "T1", "C1", "100.0", "U1", 1800); err != nil {
+		t.Fatalf("Snooze() error = %v", err)
+	}
+
+	// 同じメンションを保存し直しても作成日時・進行状況は失われない
+	if err := repo.Save(ctx, newMention("C1", "100.0", "U1", 1200)); err != nil {
+		t.Fatalf("Save() (resave) error = %v", err)
+	}
+
+	m, err := repo.Find(ctx, "T1", "C1", "100.0", "U1")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if m.CreatedAt != 1000 || m.Step != 1 || m.RemindedAt != 1600 || m.SnoozedUntil != 1800 {
+		t.Errorf("after resave = created_at %d step %d reminded_at %d snoozed_until %d, want 1000 1 1600 1800",
+			m.CreatedAt, m.Step, m.RemindedAt, m.SnoozedUntil)
+	}
+}
+
+func TestRepoSaveCopiesMention(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepo()
+
+	m := newMention("C1", "100.0", "U1", 1000)
+	if err := repo.Save(ctx, m); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	m.Step = 5
+
+	found, err := repo.Find(ctx, "T1", "C1", "100.0", "U1")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	found.Status = domain.MentionStatusReplied
+
+	again, err := repo.Find(ctx, "T1", "C1", "100.0", "U1")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if again.Step != 0 || !again.IsOpen() {
+		t.Errorf("stored mention = step %d status %s, want step 0 open (caller changes must not leak)", again.Step, again.Status)
+	}
+}
+
+func TestRepoSaveInvalid(t *testing.T) {
+	m := newMention("C1", "100.0", "", 1000)
+	if err := NewRepo().Save(context.Background(), m); !errors.Is(err, domain.ErrInvalid) {
+		t.Errorf("Save() error = %v, want %v", err, domain.ErrInvalid)
+	}
+}
+
+func TestRepoMarkStepDoneDoesNotRewind(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepo()
+	if err := repo.Save(ctx, newMention("C1", "100.0", "U1", 1000)); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// 次のステップが先に完了を記録した後に、前のステップの完了が記録されても巻き戻さない
+	for _, step := range []int{1, 0} {
+		if err := repo.MarkStepDone(ctx, "T1", "C1", "100.0", "U1", step, domain.EscalationActionThreadReminder, true, 1600); err != nil {
+			t.Fatalf("MarkStepDone(%d) error = %v", step, err)
+		}
+	}
+
+	m, err := repo.Find(ctx, "T1", "C1", "100.0", "U1")
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if m.Step != 2 {
+		t.Errorf("Step = %d, want 2", m.Step)
+	}
+
+	if err := repo.MarkStepDone(ctx, "T1", "C1", "200.0", "U1", 0, domain.EscalationActionThreadReminder, true, 1600); !errors.Is(err, domain.ErrMentionNotFound) {
+		t.Errorf("MarkStepDone() on missing mention error = %v, want %v", err, domain.ErrMentionNotFound)
+	}
+}
+
+func TestRepoListOpen(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepo()
+
+	for _, m := range []*domain.Mention{
+		newMention("C1", "100.0", "U1", 1003),
+		newMention("C1", "101.0", "U2", 1001),
+		newMention("C2", "102.0", "U1", 1002),
+		newMention("C2", "103.0", "U2", 1004),
+	} {
+		if err := repo.Save(ctx, m); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	// 監視を終了したメンションは含めない
+	if err := repo.Resolve(ctx, "T1", "C2", "103.0", "U2", domain.MentionStatusAcknowledged, 2000); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		teamID string
+		q      domain.OpenMentionQuery
+		want   []string // MessageTS（古い順）
+	}{
+		{"全件を古い順", "T1", domain.OpenMentionQuery{Limit: 10}, []string{"101.0", "102.0", "100.0"}},
+		{"チャンネルで絞り込み", "T1", domain.OpenMentionQuery{ChannelID: "C1", Limit: 10}, []string{"101.0", "100.0"}},
+		{"対象者で絞り込み", "T1", domain.OpenMentionQuery{MentionedUserID: "U1", Limit: 10}, []string{"102.0", "100.0"}},
+		{"件数の上限", "T1", domain.OpenMentionQuery{Limit: 2}, []string{"101.0", "102.0"}},
+		{"オフセット", "T1", domain.OpenMentionQuery{Offset: 2, Limit: 2}, []string{"100.0"}},
+		{"オフセットが件数以上", "T1", domain.OpenMentionQuery{Offset: 3, Limit: 2}, []string{}},
+		{"別のワークスペース", "T2", domain.OpenMentionQuery{Limit: 10}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mentions, err := repo.ListOpen(ctx, tt.teamID, tt.q)
+			if err != nil {
+				t.Fatalf("ListOpen() error = %v", err)
+			}
+			got := make([]string, 0, len(mentions))
+			for _, m := range mentions {
+				got = append(got, m.MessageTS)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ListOpen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoTenantNotRegistered(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepo()
+
+	if _, err := repo.Get(ctx, "T1"); !errors.Is(err, domain.ErrTenantNotRegistered) {
+		t.Errorf("Get() error = %v, want %v", err, domain.ErrTenantNotRegistered)
+	}
+	manager := "M1"
+	if err := repo.SetManager(ctx, "T1", &manager); !errors.Is(err, domain.ErrTenantNotRegistered) {
+		t.Errorf("SetManager() error = %v, want %v", err, domain.ErrTenantNotRegistered)
+	}
+
+	if err := repo.UpsertBotTokenSecret(ctx, "T1", "slack_token_T1"); err != nil {
+		t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+	}
+	if err := repo.SetManager(ctx, "T1", &manager); err != nil {
+		t.Fatalf("SetManager() error = %v", err)
+	}
+
+	// 取得したテナントを変更しても保存済みの設定は変わらない
+	tenant, err := repo.Get(ctx, "T1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	*tenant.ManagerUserID = "M2"
+
+	again, err := repo.Get(ctx, "T1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if again.ManagerUserID == nil || *again.ManagerUserID != "M1" {
+		t.Errorf("ManagerUserID = %v, want M1", again.ManagerUserID)
+	}
+}
+
+func TestRepoClaimEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepo()
+	expireAt := time.Now().Add(time.Hour).Unix()
+
+	claim := func(teamID, eventID string, expireAt int64) bool {
+		t.Helper()
+		ok, err := repo.ClaimEvent(ctx, teamID, eventID, expireAt)
+		if err != nil {
+			t.Fatalf("ClaimEvent() error = %v", err)
+		}
+		return ok
+	}
+
+	if !claim("T1", "Ev1", expireAt) {
+		t.Error("first ClaimEvent() = false, want true")
+	}
+	// 再送されたイベントは処理しない
+	if claim("T1", "Ev1", expireAt) {
+		t.Error("duplicate ClaimEvent() = true, want false")
+	}
+	// event_id はワークスペースごとに区別する
+	if !claim("T2", "Ev1", expireAt) {
+		t.Error("ClaimEvent() for another team = false, want true")
+	}
+
+	// 処理に失敗して記録を削除した場合は再送で再び処理できる
+	if err := repo.ReleaseEvent(ctx, "T1", "Ev1"); err != nil {
+		t.Fatalf("ReleaseEvent() error = %v", err)
+	}
+	if !claim("T1", "Ev1", expireAt) {
+		t.Error("ClaimEvent() after release = false, want true")
+	}
+
+	// 有効期限切れの記録は無視する
+	if !claim("T1", "Ev2", time.Now().Add(-time.Second).Unix()) {
+		t.Fatal("ClaimEvent() = false, want true")
+	}
+	if !claim("T1", "Ev2", expireAt) {
+		t.Error("ClaimEvent() after expiry = false, want true")
+	}
+}
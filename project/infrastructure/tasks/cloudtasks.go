@@ -4,16 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/metrics"
+	"slack-bot/project/infrastructure/tracing"
 	"slack-bot/project/service"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	"cloud.google.com/go/cloudtasks/apiv2/cloudtaskspb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// tracer はタスク登録のスパンを記録します
+var tracer = otel.Tracer("slack-bot/project/infrastructure/tasks")
+
 // CloudTasksClient は service.TaskPort の Cloud Tasks 実装です
 type CloudTasksClient struct {
 	client   *cloudtasks.Client
@@ -25,7 +36,7 @@ type CloudTasksClient struct {
 
 // NewCloudTasksClient は Cloud Tasks クライアントを初期化します
 func NewCloudTasksClient(ctx context.Context, cfg *config.Config) (*CloudTasksClient, error) {
-	client, err := cloudtasks.NewClient(ctx)
+	client, err := cloudtasks.NewClient(ctx, metrics.ClientOptions("cloudtasks")...)
 	if err != nil {
 		return nil, fmt.Errorf("cloudtasks: クライアント初期化失敗: %w", err)
 	}
@@ -41,31 +52,54 @@ func NewCloudTasksClient(ctx context.Context, cfg *config.Config) (*CloudTasksCl
 
 // EnqueueRemind は10分後のリマインドタスクをキューに登録します
 func (ct *CloudTasksClient) EnqueueRemind(ctx context.Context, runAtUnix int64, payload *service.TaskPayload) error {
-	queueName := fmt.Sprintf("projects/%s/locations/%s/queues/%s", ct.project, ct.region, "remind-queue")
-	return ct.enqueueTask(ctx, queueName, "/check/remind", runAtUnix, payload)
+	return ct.enqueueTask(ctx, ct.queueName("remind-queue"), "/check/remind", payload.TaskName(runAtUnix), runAtUnix, payload)
 }
 
 // EnqueueEscalate は30分後のエスカレーションタスクをキューに登録します
 func (ct *CloudTasksClient) EnqueueEscalate(ctx context.Context, runAtUnix int64, payload *service.TaskPayload) error {
-	queueName := fmt.Sprintf("projects/%s/locations/%s/queues/%s", ct.project, ct.region, "escalate-queue")
-	return ct.enqueueTask(ctx, queueName, "/check/escalate", runAtUnix, payload)
+	return ct.enqueueTask(ctx, ct.queueName("escalate-queue"), "/check/escalate", payload.TaskName(runAtUnix), runAtUnix, payload)
+}
+
+// EnqueueEvent は受信した Slack イベントを直ちに処理するタスクをキューに登録します
+// タスク名は event_id から決まるため、Slack が再送した同じイベントはキューが ALREADY_EXISTS で拒否します（成功として扱う）
+func (ct *CloudTasksClient) EnqueueEvent(ctx context.Context, task *service.EventTask) error {
+	return ct.enqueueTask(ctx, ct.queueName("event-queue"), "/tasks/event", task.TaskName(), time.Now().Unix(), task)
+}
+
+// queueName はキューのリソース名を返します
+func (ct *CloudTasksClient) queueName(queue string) string {
+	return fmt.Sprintf("projects/%s/locations/%s/queues/%s", ct.project, ct.region, queue)
 }
 
 // enqueueTask はタスクを指定されたキューに登録します
-func (ct *CloudTasksClient) enqueueTask(ctx context.Context, queueName, path string, runAtUnix int64, payload *service.TaskPayload) error {
+// タスク名（taskID）はメンション・ステップ・実行時刻、またはイベントから決まるため、同じタスクの重複登録はキューが ALREADY_EXISTS で拒否します（成功として扱う）
+// タスクの HTTP ヘッダーに traceparent を付与し、タスクの実行（/check/*・/tasks/event）を登録時のスパンの子として記録します
+func (ct *CloudTasksClient) enqueueTask(ctx context.Context, queueName, path, taskID string, runAtUnix int64, payload any) (err error) {
+	ctx, span := tracer.Start(ctx, "CloudTasks.Enqueue", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		attribute.String("cloudtasks.queue", queueName),
+		attribute.String("cloudtasks.path", path),
+		attribute.Int64("cloudtasks.schedule_time", runAtUnix),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	// ペイロードを JSON に変換
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("cloudtasks: ペイロード JSON 化失敗: %w", err)
 	}
 
+	headers := map[string]string{"Content-Type": "application/json"}
+	tracing.Inject(ctx, headers)
+
 	// タスクリクエストを構築
+	name := fmt.Sprintf("%s/tasks/%s", queueName, taskID)
 	task := &cloudtaskspb.Task{
+		Name: name,
 		MessageType: &cloudtaskspb.Task_HttpRequest{
 			HttpRequest: &cloudtaskspb.HttpRequest{
 				Url:        fmt.Sprintf("%s%s", ct.audience, path),
 				HttpMethod: cloudtaskspb.HttpMethod_POST,
-				Headers:    map[string]string{"Content-Type": "application/json"},
+				Headers:    headers,
 				Body:       payloadBytes,
 				AuthorizationHeader: &cloudtaskspb.HttpRequest_OidcToken{
 					OidcToken: &cloudtaskspb.OidcToken{
@@ -85,6 +119,10 @@ func (ct *CloudTasksClient) enqueueTask(ctx context.Context, queueName, path str
 	}
 
 	_, err = ct.client.CreateTask(ctx, req)
+	if status.Code(err) == codes.AlreadyExists {
+		slog.DebugContext(ctx, "登録済みのタスクのため重複登録をスキップ", "task", name)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("cloudtasks: タスク作成失敗 (queue=%s, path=%s): %w", queueName, path, err)
 	}
@@ -0,0 +1,430 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/httpsec"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/infrastructure/tracing"
+	"slack-bot/project/service"
+)
+
+const (
+	// localMaxAttempts はジョブ配送の最大試行回数です（超えたジョブは破棄）
+	localMaxAttempts = 5
+
+	// localRetryBase は配送失敗時の再試行間隔の基準値です（試行ごとに倍増）
+	localRetryBase = 10 * time.Second
+
+	// localRetryMax は再試行間隔の上限です
+	localRetryMax = 10 * time.Minute
+
+	// localDispatchTimeout は /check/*・/tasks/event への 1 回の配送のタイムアウトです
+	localDispatchTimeout = 30 * time.Second
+
+	// localCronTimeout は /cron/* の 1 回の呼び出しのタイムアウトです
+	localCronTimeout = 5 * time.Minute
+)
+
+// localCronJob は LocalScheduler が定期的に呼び出す /cron/* エンドポイントです（Cloud Scheduler の代わり）
+type localCronJob struct {
+	path     string
+	interval time.Duration
+}
+
+// localCronJobs は定期実行するエンドポイントの一覧です
+var localCronJobs = []localCronJob{
+	// 今週送信済みかどうかはサービス側で判定するため、1時間ごとに呼び出しても週1回だけ送信されます
+	{path: "/cron/weekly-digest", interval: time.Hour},
+	// 保持期間を過ぎた記録をバッチで削除します（1回で削除しきれない分は次回に削除）
+	{path: "/cron/retention-sweep", interval: time.Hour},
+}
+
+// localJob は LocalScheduler が保持する予約ジョブです
+type localJob struct {
+	ID       string               `json:"id"`
+	Path     string               `json:"path"` // "/check/remind"・"/check/escalate"・"/tasks/event"
+	RunAt    int64                `json:"run_at"`
+	Payload  *service.TaskPayload `json:"payload,omitempty"` // /check/* のペイロード
+	Event    *service.EventTask   `json:"event,omitempty"`   // /tasks/event のペイロード
+	Attempts int                  `json:"attempts"`
+	Headers  map[string]string    `json:"headers,omitempty"` // 配送時に付与するヘッダー（traceparent など）
+}
+
+// body は配送するリクエスト本体を返します
+func (job *localJob) body() ([]byte, error) {
+	if job.Event != nil {
+		return json.Marshal(job.Event)
+	}
+	return json.Marshal(job.Payload)
+}
+
+// correlationID はジョブの相関 ID を返します（ログ用）
+func (job *localJob) correlationID() string {
+	switch {
+	case job.Event != nil:
+		return job.Event.CorrelationID
+	case job.Payload != nil:
+		return job.Payload.CorrelationID
+	}
+	return ""
+}
+
+// LocalScheduler は service.TaskPort のプロセス内実装です
+// Cloud Tasks を使わずに単一 VM や docker-compose で動かすためのもので、
+// 予約ジョブ・受信した Slack イベントを JSON ファイルに永続化し、再起動後も runAt を過ぎたジョブを /check/*・/tasks/event へ POST します
+type LocalScheduler struct {
+	mu     sync.Mutex
+	jobs   map[string]*localJob
+	path   string // 永続化ファイルのパス
+	target string // 配送先のベース URL（例: http://127.0.0.1:8080）
+	client *http.Client
+	secret string // /check/*・/tasks/event への配送に付与する HMAC 署名のシークレット
+
+	adminToken string // /cron/* の Bearer トークン（空の場合は定期実行を行わない）
+	cronWG     sync.WaitGroup
+
+	wakeCh chan struct{}
+	stopCh chan struct{}
+	doneCh chan struct{}
+	start  sync.Once
+	stop   sync.Once
+}
+
+// NewLocalScheduler はローカルスケジューラを初期化し、永続化ファイルから未実行ジョブを復元します
+func NewLocalScheduler(cfg *config.Config) (*LocalScheduler, error) {
+	ls := &LocalScheduler{
+		jobs:   make(map[string]*localJob),
+		path:   cfg.LocalTasksFile,
+		target: cfg.LocalTasksTarget,
+		client: &http.Client{Timeout: localDispatchTimeout},
+		secret: cfg.TasksCallbackSecret,
+
+		adminToken: cfg.AdminAPIToken,
+		wakeCh:     make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+
+	if err := ls.load(); err != nil {
+		return nil, err
+	}
+
+	return ls, nil
+}
+
+// Start はジョブ実行ループをバックグラウンドで開始します
+// ADMIN_API_TOKEN が設定されている場合は /cron/* の定期実行（Cloud Scheduler の代わり）も開始します
+func (ls *LocalScheduler) Start() {
+	ls.start.Do(func() {
+		go ls.run()
+
+		if ls.adminToken != "" {
+			for _, job := range localCronJobs {
+				ls.cronWG.Add(1)
+				go ls.runCron(job)
+			}
+		}
+	})
+}
+
+// EnqueueRemind は指定時刻に /check/remind を実行するジョブを登録します
+func (ls *LocalScheduler) EnqueueRemind(ctx context.Context, runAtUnix int64, payload *service.TaskPayload) error {
+	return ls.enqueue(ctx, &localJob{Path: "/check/remind", RunAt: runAtUnix, Payload: payload}, payload.TaskName(runAtUnix))
+}
+
+// EnqueueEscalate は指定時刻に /check/escalate を実行するジョブを登録します
+func (ls *LocalScheduler) EnqueueEscalate(ctx context.Context, runAtUnix int64, payload *service.TaskPayload) error {
+	return ls.enqueue(ctx, &localJob{Path: "/check/escalate", RunAt: runAtUnix, Payload: payload}, payload.TaskName(runAtUnix))
+}
+
+// EnqueueEvent は受信した Slack イベントを直ちに /tasks/event へ配送するジョブを登録します
+func (ls *LocalScheduler) EnqueueEvent(ctx context.Context, task *service.EventTask) error {
+	return ls.enqueue(ctx, &localJob{Path: "/tasks/event", RunAt: time.Now().Unix(), Event: task}, task.TaskName())
+}
+
+// enqueue はジョブを登録して永続化し、実行ループを起こします
+// Cloud Tasks と同様に、ジョブ ID はタスク名（メンション・ステップ・実行時刻、またはイベント）とし、未実行の同じジョブは重複して登録しません
+// また、登録時のトレースコンテキストを配送時のヘッダーとして引き継ぎます
+func (ls *LocalScheduler) enqueue(ctx context.Context, job *localJob, taskName string) error {
+	job.Headers = make(map[string]string)
+	tracing.Inject(ctx, job.Headers)
+	job.ID = job.Path + "/" + taskName
+
+	ls.mu.Lock()
+	if _, ok := ls.jobs[job.ID]; ok {
+		ls.mu.Unlock()
+		slog.DebugContext(ctx, "local tasks: 登録済みのジョブのため重複登録をスキップ", "id", job.ID)
+		return nil
+	}
+	ls.jobs[job.ID] = job
+
+	if err := ls.persistLocked(); err != nil {
+		delete(ls.jobs, job.ID)
+		ls.mu.Unlock()
+		return fmt.Errorf("local tasks: ジョブ永続化失敗 (path=%s): %w", job.Path, err)
+	}
+	ls.mu.Unlock()
+
+	ls.wake()
+	return nil
+}
+
+// run はジョブ実行ループ本体です
+func (ls *LocalScheduler) run() {
+	defer close(ls.doneCh)
+
+	for {
+		var timerC <-chan time.Time
+		var timer *time.Timer
+		if next, ok := ls.nextRunAt(); ok {
+			timer = time.NewTimer(time.Until(time.Unix(next, 0)))
+			timerC = timer.C
+		}
+
+		select {
+		case <-ls.stopCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-ls.wakeCh:
+			// 新しいジョブが登録されたので次回実行時刻を再計算
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-timerC:
+			ls.runDue()
+		}
+	}
+}
+
+// runCron は job.interval ごとに job.path を呼び出します
+func (ls *LocalScheduler) runCron(job localCronJob) {
+	defer ls.cronWG.Done()
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ls.stopCh:
+			return
+		case <-ticker.C:
+			if err := ls.triggerCron(job.path); err != nil {
+				slog.Error("local tasks: 定期実行の呼び出し失敗", "path", job.path, "error", err)
+			}
+		}
+	}
+}
+
+// triggerCron は /cron/* を Bearer トークン付きで POST します
+func (ls *LocalScheduler) triggerCron(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), localCronTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ls.target+path, nil)
+	if err != nil {
+		return fmt.Errorf("リクエスト作成失敗: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+ls.adminToken)
+
+	// 全ワークスペースを処理するため時間がかかる。配送用クライアントのタイムアウトは使わない
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("リクエスト送信失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("予期しないステータス: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// runDue は実行時刻を過ぎたジョブを配送します
+func (ls *LocalScheduler) runDue() {
+	now := time.Now().Unix()
+
+	ls.mu.Lock()
+	var due []*localJob
+	for _, job := range ls.jobs {
+		if job.RunAt <= now {
+			due = append(due, job)
+		}
+	}
+	ls.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt < due[j].RunAt })
+
+	for _, job := range due {
+		select {
+		case <-ls.stopCh:
+			return
+		default:
+		}
+
+		err := ls.dispatch(job)
+
+		ls.mu.Lock()
+		if err == nil {
+			delete(ls.jobs, job.ID)
+		} else {
+			job.Attempts++
+			if job.Attempts >= localMaxAttempts {
+				slog.Error("local tasks: 最大試行回数に達したためジョブを破棄します", "id", job.ID, "path", job.Path, logging.CorrelationIDKey, job.correlationID(), "error", err)
+				delete(ls.jobs, job.ID)
+			} else {
+				slog.Warn("local tasks: ジョブ配送失敗、再試行します", "id", job.ID, "path", job.Path, "attempts", job.Attempts, logging.CorrelationIDKey, job.correlationID(), "error", err)
+				job.RunAt = time.Now().Add(retryDelay(job.Attempts)).Unix()
+			}
+		}
+		if err := ls.persistLocked(); err != nil {
+			slog.Error("local tasks: ジョブ永続化失敗", "error", err)
+		}
+		ls.mu.Unlock()
+	}
+}
+
+// dispatch はジョブを /check/*・/tasks/event エンドポイントへ POST します
+func (ls *LocalScheduler) dispatch(job *localJob) error {
+	body, err := job.body()
+	if err != nil {
+		return fmt.Errorf("ペイロード JSON 化失敗: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), localDispatchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ls.target+job.Path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("リクエスト作成失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range job.Headers {
+		req.Header.Set(k, v)
+	}
+	// 再試行でも時刻の許容範囲に収まるよう、配送のたびに署名する
+	httpsec.SignTaskRequest(ls.secret, req, body, time.Now())
+
+	resp, err := ls.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("リクエスト送信失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("予期しないステータス: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// nextRunAt は最も早いジョブの実行時刻を返します
+func (ls *LocalScheduler) nextRunAt() (int64, bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	var next int64
+	found := false
+	for _, job := range ls.jobs {
+		if !found || job.RunAt < next {
+			next = job.RunAt
+			found = true
+		}
+	}
+	return next, found
+}
+
+// wake は実行ループに次回実行時刻の再計算を促します
+func (ls *LocalScheduler) wake() {
+	select {
+	case ls.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// load は永続化ファイルからジョブを復元します（ファイルがなければ空で開始）
+func (ls *LocalScheduler) load() error {
+	data, err := os.ReadFile(ls.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("local tasks: ジョブファイル読み込み失敗 (path=%s): %w", ls.path, err)
+	}
+
+	var jobs []*localJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("local tasks: ジョブファイル解析失敗 (path=%s): %w", ls.path, err)
+	}
+
+	for _, job := range jobs {
+		ls.jobs[job.ID] = job
+	}
+	slog.Info("local tasks: 未実行ジョブを復元しました", "count", len(jobs))
+
+	return nil
+}
+
+// persistLocked は全ジョブをファイルへ書き出します（ls.mu を保持した状態で呼び出すこと）
+// 一時ファイルへ書き込んでからリネームし、書き込み途中のクラッシュでファイルが壊れないようにします
+func (ls *LocalScheduler) persistLocked() error {
+	jobs := make([]*localJob, 0, len(ls.jobs))
+	for _, job := range ls.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].RunAt < jobs[j].RunAt })
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(ls.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := ls.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ls.path)
+}
+
+// Close は実行ループを停止します（未実行ジョブはファイルに残り、次回起動時に再開されます）
+func (ls *LocalScheduler) Close() error {
+	ls.stop.Do(func() {
+		close(ls.stopCh)
+	})
+
+	// Start されていない場合はループが存在しないため、ここで完了扱いにする
+	ls.start.Do(func() { close(ls.doneCh) })
+	<-ls.doneCh
+	ls.cronWG.Wait()
+	return nil
+}
+
+// retryDelay は試行回数に応じた再試行間隔を返します
+func retryDelay(attempts int) time.Duration {
+	d := localRetryBase << (attempts - 1)
+	if d > localRetryMax || d <= 0 {
+		return localRetryMax
+	}
+	return d
+}
@@ -0,0 +1,175 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/service"
+)
+
+// newTestScheduler は dir のジョブファイルを使い、target へ配送するローカルスケジューラを作成します
+func newTestScheduler(t *testing.T, dir, target string) *LocalScheduler {
+	t.Helper()
+	ls, err := NewLocalScheduler(&config.Config{
+		LocalTasksFile:      filepath.Join(dir, "tasks.json"),
+		LocalTasksTarget:    target,
+		TasksCallbackSecret: "callback-secret",
+	})
+	if err != nil {
+		t.Fatalf("NewLocalScheduler() error = %v", err)
+	}
+	return ls
+}
+
+func testPayload(step int) *service.TaskPayload {
+	return &service.TaskPayload{TeamID: "T1", ChannelID: "C1", MessageTS: "1700000000.000100", UserID: "U1", ParentUserID: "U0", Step: step}
+}
+
+func TestLocalSchedulerPersistsJobs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	runAt := time.Now().Add(time.Hour).Unix()
+
+	ls := newTestScheduler(t, dir, "http://127.0.0.1:0")
+	if err := ls.EnqueueRemind(ctx, runAt, testPayload(0)); err != nil {
+		t.Fatalf("EnqueueRemind() error = %v", err)
+	}
+	if err := ls.EnqueueEscalate(ctx, runAt+1800, testPayload(1)); err != nil {
+		t.Fatalf("EnqueueEscalate() error = %v", err)
+	}
+	// 同じタスク（メンション・ステップ・実行時刻）は重複して登録しない
+	if err := ls.EnqueueRemind(ctx, runAt, testPayload(0)); err != nil {
+		t.Fatalf("EnqueueRemind() (duplicate) error = %v", err)
+	}
+	ls.Close()
+
+	// 再起動後も未実行のジョブを復元する
+	restored := newTestScheduler(t, dir, "http://127.0.0.1:0")
+	defer restored.Close()
+
+	if len(restored.jobs) != 2 {
+		t.Fatalf("restored jobs = %d, want 2", len(restored.jobs))
+	}
+	for _, job := range restored.jobs {
+		want := map[string]int64{"/check/remind": runAt, "/check/escalate": runAt + 1800}[job.Path]
+		if job.RunAt != want || job.Payload == nil || job.Payload.TeamID != "T1" {
+			t.Errorf("restored job = %+v, want path %s run_at %d with payload", job, job.Path, want)
+		}
+	}
+}
+
+func TestLocalSchedulerDispatchesDueJobs(t *testing.T) {
+	received := make(chan *service.TaskPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/check/escalate" {
+			t.Errorf("path = %s, want /check/escalate", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var p service.TaskPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("Unmarshal() error = %v", err)
+		}
+		received <- &p
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ls := newTestScheduler(t, dir, srv.URL)
+	ls.Start()
+	defer ls.Close()
+
+	if err := ls.EnqueueEscalate(context.Background(), time.Now().Unix(), testPayload(2)); err != nil {
+		t.Fatalf("EnqueueEscalate() error = %v", err)
+	}
+
+	select {
+	case p := <-received:
+		if p.Step != 2 || p.UserID != "U1" {
+			t.Errorf("payload = %+v, want step 2 for U1", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not dispatched")
+	}
+
+	// 配送に成功したジョブはファイルからも削除される
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		restored := newTestScheduler(t, dir, srv.URL)
+		n := len(restored.jobs)
+		restored.Close()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("persisted jobs = %d after dispatch, want 0", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLocalSchedulerRetriesFailedJobs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ls := newTestScheduler(t, t.TempDir(), srv.URL)
+	defer ls.Close()
+
+	if err := ls.EnqueueRemind(context.Background(), time.Now().Unix(), testPayload(0)); err != nil {
+		t.Fatalf("EnqueueRemind() error = %v", err)
+	}
+
+	// 失敗したジョブは間隔を空けて再試行し、最大試行回数に達したら破棄する
+	for attempt := 1; attempt <= localMaxAttempts; attempt++ {
+		for _, job := range ls.jobs {
+			job.RunAt = time.Now().Unix()
+		}
+		before := time.Now()
+		ls.runDue()
+
+		if attempt == localMaxAttempts {
+			if len(ls.jobs) != 0 {
+				t.Errorf("jobs = %d after %d attempts, want 0", len(ls.jobs), attempt)
+			}
+			break
+		}
+		if len(ls.jobs) != 1 {
+			t.Fatalf("jobs = %d after attempt %d, want 1", len(ls.jobs), attempt)
+		}
+		for _, job := range ls.jobs {
+			if job.Attempts != attempt {
+				t.Errorf("Attempts = %d, want %d", job.Attempts, attempt)
+			}
+			if earliest := before.Add(retryDelay(attempt)).Unix() - 1; job.RunAt < earliest {
+				t.Errorf("RunAt = %d, want >= %d", job.RunAt, earliest)
+			}
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{7, localRetryMax}, // 640秒は上限（10分）を超える
+		{100, localRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"slack-bot/project/infrastructure/config"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultServiceName は OTEL_SERVICE_NAME 未設定時のサービス名です
+const defaultServiceName = "slack-reminder-bot"
+
+// Setup は TRACES_EXPORTER に応じてトレースの出力先を設定し、W3C Trace Context の伝播を有効にします
+// Firestore・Cloud Tasks のクライアントライブラリはグローバルの TracerProvider に RPC のスパンを記録するため、
+// ここで設定するだけでそれらのスパンも出力されます
+// 戻り値の関数で未送信のスパンを送信して終了します
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	// 出力しない場合も、受信した traceparent をタスクに引き継げるよう伝播は有効にする
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.TracesExporter {
+	case config.TracesExporterOTLP:
+		// 送信先・ヘッダーなどは OTEL_EXPORTER_OTLP_* 環境変数で指定
+		exporter, err = otlptracegrpc.New(ctx)
+	case config.TracesExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: エクスポーター初期化失敗 (exporter=%s): %w", cfg.TracesExporter, err)
+	}
+
+	// サービス名・リソース属性は OTEL_SERVICE_NAME・OTEL_RESOURCE_ATTRIBUTES で上書きできる
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", defaultServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, fmt.Errorf("tracing: リソース初期化失敗: %w", err)
+	}
+
+	// サンプリングは OTEL_TRACES_SAMPLER・OTEL_TRACES_SAMPLER_ARG で指定（未指定時は親に従い、ルートは全件）
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	return tp.Shutdown, nil
+}
+
+// Handler は next（ServeMux）の処理を HTTP サーバーのスパンで囲むミドルウェアを返します
+// 受信した traceparent（Cloud Tasks のタスクに付与したもの）を親とし、スパン名は ServeMux が照合したルートにします
+func Handler(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			if r.Pattern != "" {
+				return r.Pattern
+			}
+			return operation
+		}),
+	)
+}
+
+// Transport は Slack Web API の呼び出しを HTTP クライアントのスパンで囲む http.RoundTripper を返します
+// トレースコンテキストは外部サービスに送る必要がないため、リクエストヘッダーには付与しません
+func Transport(base http.RoundTripper, spanName func(r *http.Request) string) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithPropagators(propagation.NewCompositeTextMapPropagator()),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return spanName(r)
+		}),
+	)
+}
+
+// Inject は ctx のトレースコンテキストを traceparent などのヘッダーとして headers に追加します
+// 予約したタスクの実行（/check/*）を、予約元のスパンの子として記録するために使います
+func Inject(ctx context.Context, headers map[string]string) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+}
+
+// End はスパンを終了します。err があればスパンにエラーとして記録します
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"slack-bot/project/domain"
+)
+
+// AnalyticsService はメンションへの応答時間の集計を担当します
+type AnalyticsService interface {
+	// Stats は from 以上 to 未満（Unix秒）に作成されたメンションの応答時間を
+	// ワークスペース全体・対象者ごと・チャンネルごとに集計します
+	// 閲覧できるのはワークスペースの管理者・オーナーと、上長に設定されているユーザーのみです
+	// 権限がない場合は domain.ErrInsufficientPermission を返します
+	Stats(ctx context.Context, teamID, viewerUserID string, from, to int64) (*ResponseStats, error)
+
+	// Export は from 以上 to 未満に作成されたメンションを作成日時の古い順に返します（CSV エクスポート用）
+	// 権限の確認は呼び出し元で行います
+	Export(ctx context.Context, teamID string, from, to int64) ([]*domain.Mention, error)
+}
+
+// analyticsService は AnalyticsService の実装です
+type analyticsService struct {
+	mr domain.MentionRepository
+	tr domain.TenantRepository
+	sp SlackPort
+}
+
+// NewAnalyticsService は AnalyticsService のインスタンスを作成します
+func NewAnalyticsService(mr domain.MentionRepository, tr domain.TenantRepository, sp SlackPort) AnalyticsService {
+	return &analyticsService{
+		mr: mr,
+		tr: tr,
+		sp: sp,
+	}
+}
+
+// Stats はメンションの応答時間を集計します
+func (as *analyticsService) Stats(ctx context.Context, teamID, viewerUserID string, from, to int64) (*ResponseStats, error) {
+	tenant, err := findTenant(ctx, as.tr, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: %w", err)
+	}
+	if err := authorizeViewer(ctx, as.sp, tenant, teamID, viewerUserID); err != nil {
+		return nil, fmt.Errorf("Stats: %w", err)
+	}
+
+	mentions, err := as.mr.ListCreatedBetween(ctx, teamID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Stats: メンション取得失敗: %w", err)
+	}
+
+	return aggregateResponseStats(mentions, from, to), nil
+}
+
+// Export は期間内のメンションを作成日時順に返します
+func (as *analyticsService) Export(ctx context.Context, teamID string, from, to int64) ([]*domain.Mention, error) {
+	mentions, err := as.mr.ListCreatedBetween(ctx, teamID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("Export: メンション取得失敗: %w", err)
+	}
+
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].CreatedAt != mentions[j].CreatedAt {
+			return mentions[i].CreatedAt < mentions[j].CreatedAt
+		}
+		return mentions[i].MentionedUserID < mentions[j].MentionedUserID
+	})
+	return mentions, nil
+}
+
+// aggregateResponseStats はメンションをワークスペース全体・対象者ごと・チャンネルごとに集計します
+// 対象者・チャンネルごとの集計は応答時間の p90 が長い順（応答のないものは最後）に並べます
+func aggregateResponseStats(mentions []*domain.Mention, from, to int64) *ResponseStats {
+	team := newStatsAccumulator()
+	byUser := make(map[string]*statsAccumulator)
+	byChannel := make(map[string]*statsAccumulator)
+
+	for _, m := range mentions {
+		team.add(m)
+		accumulatorFor(byUser, m.MentionedUserID).add(m)
+		accumulatorFor(byChannel, m.ChannelID).add(m)
+	}
+
+	return &ResponseStats{
+		From:      from,
+		To:        to,
+		Team:      team.group(""),
+		ByUser:    sortedStatsGroups(byUser),
+		ByChannel: sortedStatsGroups(byChannel),
+	}
+}
+
+// statsAccumulator は集計途中の件数と応答時間を保持します
+type statsAccumulator struct {
+	total     int
+	open      int
+	outcomes  map[domain.MentionOutcome]int
+	responses []int64
+}
+
+// newStatsAccumulator は空の集計を作成します
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{outcomes: make(map[domain.MentionOutcome]int)}
+}
+
+// accumulatorFor は key の集計を返します（なければ作成）
+func accumulatorFor(accumulators map[string]*statsAccumulator, key string) *statsAccumulator {
+	acc, ok := accumulators[key]
+	if !ok {
+		acc = newStatsAccumulator()
+		accumulators[key] = acc
+	}
+	return acc
+}
+
+// add はメンションを集計に加えます
+func (acc *statsAccumulator) add(m *domain.Mention) {
+	acc.total++
+	if m.IsOpen() {
+		acc.open++
+	}
+	if m.Outcome != "" {
+		acc.outcomes[m.Outcome]++
+	}
+	if seconds, ok := m.ResponseSeconds(); ok {
+		acc.responses = append(acc.responses, seconds)
+	}
+}
+
+// group は集計結果を key の StatsGroup に変換します
+func (acc *statsAccumulator) group(key string) StatsGroup {
+	sort.Slice(acc.responses, func(i, j int) bool { return acc.responses[i] < acc.responses[j] })
+	return StatsGroup{
+		Key:           key,
+		Total:         acc.total,
+		Open:          acc.open,
+		Responded:     len(acc.responses),
+		Outcomes:      acc.outcomes,
+		MedianSeconds: percentile(acc.responses, 0.5),
+		P90Seconds:    percentile(acc.responses, 0.9),
+	}
+}
+
+// sortedStatsGroups は key ごとの集計を p90 の長い順（同じなら件数の多い順・key 順）に並べて返します
+func sortedStatsGroups(accumulators map[string]*statsAccumulator) []StatsGroup {
+	groups := make([]StatsGroup, 0, len(accumulators))
+	for key, acc := range accumulators {
+		groups = append(groups, acc.group(key))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].P90Seconds != groups[j].P90Seconds {
+			return groups[i].P90Seconds > groups[j].P90Seconds
+		}
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// percentile は昇順に並んだ値の p 分位数（最近接順位法）を返します。値がない場合は0
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
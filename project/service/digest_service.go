@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/metrics"
+)
+
+const (
+	// digestPeriod は週次ダイジェストの集計期間です
+	digestPeriod = 7 * 24 * time.Hour
+
+	// digestMinInterval は同じワークスペースに続けてダイジェストを送信するまでの最短間隔です
+	// 定期実行の時刻のずれを許容するため、集計期間より少し短くしています
+	digestMinInterval = digestPeriod - time.Hour
+
+	// digestOpenScanLimit はダイジェスト作成時に読み込む監視中メンションの上限です（古い順）
+	digestOpenScanLimit = 500
+
+	// digestSlowestLimit は「返信待ちが長いメンション」に表示する件数です
+	digestSlowestLimit = 5
+
+	// digestNonResponderLimit は「未応答が続いているメンバー」に表示する人数です
+	digestNonResponderLimit = 5
+
+	// digestRepeatThreshold は「未応答が続いている」とみなすエスカレーション・期限切れの件数です
+	digestRepeatThreshold = 2
+)
+
+// DigestService は上長向けの週次ダイジェストの作成・送信を担当します
+type DigestService interface {
+	// SendWeeklyDigests は有効な全ワークスペースの上長に、担当範囲の週次ダイジェストをDMで送信します
+	// 前回の送信から digestMinInterval 経過していないワークスペースはスキップするため、定期実行の重複・再試行でも二重送信しません
+	// 送信したDMの件数と、失敗したワークスペースのエラーをまとめて返します
+	SendWeeklyDigests(ctx context.Context, nowUnix int64) (int, error)
+}
+
+// digestService は DigestService の実装です
+type digestService struct {
+	mr domain.MentionRepository
+	tr domain.TenantRepository
+	sp SlackPort
+}
+
+// NewDigestService は DigestService のインスタンスを作成します
+func NewDigestService(mr domain.MentionRepository, tr domain.TenantRepository, sp SlackPort) DigestService {
+	return &digestService{
+		mr: mr,
+		tr: tr,
+		sp: sp,
+	}
+}
+
+// SendWeeklyDigests は全ワークスペースの週次ダイジェストを送信します
+func (ds *digestService) SendWeeklyDigests(ctx context.Context, nowUnix int64) (int, error) {
+	tenants, err := ds.tr.ListActive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("SendWeeklyDigests: テナント一覧取得失敗: %w", err)
+	}
+
+	sent := 0
+	var errs []error
+	for _, tenant := range tenants {
+		n, err := ds.sendTeamDigests(ctx, tenant, nowUnix)
+		sent += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SendWeeklyDigests (team=%s): %w", tenant.TeamID, err))
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// sendTeamDigests はワークスペースの各上長にダイジェストを送信します
+// 集計に必要なデータを読み込んでから送信権を取得するため、読み込みに失敗した場合は次回の定期実行で再試行されます
+// 送信権の取得後に DM の送信に失敗した上長には、その週のダイジェストは再送しません
+func (ds *digestService) sendTeamDigests(ctx context.Context, tenant *domain.Tenant, nowUnix int64) (int, error) {
+	managers := tenant.Managers()
+	if len(managers) == 0 {
+		return 0, nil
+	}
+
+	notBefore := nowUnix - int64(digestMinInterval/time.Second)
+	if tenant.DigestSentAt >= notBefore {
+		// 今週は送信済み
+		return 0, nil
+	}
+
+	from := nowUnix - int64(digestPeriod/time.Second)
+	lastFrom := from - int64(digestPeriod/time.Second)
+
+	recent, err := ds.mr.ListCreatedBetween(ctx, tenant.TeamID, lastFrom, nowUnix)
+	if err != nil {
+		return 0, fmt.Errorf("メンション取得失敗: %w", err)
+	}
+	open, err := ds.mr.ListOpen(ctx, tenant.TeamID, domain.OpenMentionQuery{Limit: digestOpenScanLimit})
+	if err != nil {
+		return 0, fmt.Errorf("監視中メンション取得失敗: %w", err)
+	}
+
+	digests := make([]*WeeklyDigest, 0, len(managers))
+	for _, managerUserID := range managers {
+		d := buildWeeklyDigest(tenant, managerUserID, recent, open, from, nowUnix)
+		if d.ThisWeek.Mentions == 0 && d.LastWeek.Mentions == 0 && len(d.SlowestOpen) == 0 {
+			// 報告することがない上長には送信しない
+			continue
+		}
+		digests = append(digests, d)
+	}
+
+	// 報告する内容がなくても送信権を取得し、週1回の周期を保つ
+	claimed, err := ds.tr.ClaimDigest(ctx, tenant.TeamID, nowUnix, notBefore)
+	if err != nil {
+		return 0, fmt.Errorf("送信記録失敗: %w", err)
+	}
+	if !claimed {
+		return 0, nil
+	}
+
+	sent := 0
+	var errs []error
+	for _, d := range digests {
+		if err := ds.sp.PostDM(ctx, tenant.TeamID, d.ManagerUserID, renderWeeklyDigest(tenant.TeamID, d, nowUnix)); err != nil {
+			errs = append(errs, fmt.Errorf("ダイジェスト送信失敗 (manager=%s): %w", d.ManagerUserID, err))
+			continue
+		}
+		metrics.DigestSent(tenant.TeamID)
+		sent++
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+// buildWeeklyDigest は上長の担当範囲のメンションから週次ダイジェストを作成します
+// recent は先週の開始以降に作成されたメンション、open は古い順の監視中メンションです
+func buildWeeklyDigest(tenant *domain.Tenant, managerUserID string, recent, open []*domain.Mention, from, to int64) *WeeklyDigest {
+	var thisWeek, lastWeek []*domain.Mention
+	for _, m := range recent {
+		if !inManagerScope(tenant, managerUserID, m) {
+			continue
+		}
+		if m.CreatedAt >= from {
+			thisWeek = append(thisWeek, m)
+		} else {
+			lastWeek = append(lastWeek, m)
+		}
+	}
+
+	var slowest []*domain.Mention
+	for _, m := range open {
+		if len(slowest) >= digestSlowestLimit {
+			break
+		}
+		if inManagerScope(tenant, managerUserID, m) {
+			slowest = append(slowest, m)
+		}
+	}
+
+	return &WeeklyDigest{
+		ManagerUserID: managerUserID,
+		From:          from,
+		To:            to,
+		ThisWeek:      summarizeDigestWeek(thisWeek),
+		LastWeek:      summarizeDigestWeek(lastWeek),
+		SlowestOpen:   slowest,
+		NonResponders: repeatedNonResponders(thisWeek),
+	}
+}
+
+// inManagerScope はメンションが上長の担当範囲かどうかを返します
+// ワークスペース全体の上長は全メンション、それ以外は上長DMの送信先になるメンションが対象です
+func inManagerScope(tenant *domain.Tenant, managerUserID string, m *domain.Mention) bool {
+	if tenant.ManagerUserID != nil && *tenant.ManagerUserID == managerUserID {
+		return true
+	}
+	return slices.Contains(tenant.EscalationTargets(m.ChannelID, m.MentionedUserID), managerUserID)
+}
+
+// summarizeDigestWeek は1週間分のメンションを集計します
+func summarizeDigestWeek(mentions []*domain.Mention) DigestWeek {
+	acc := newStatsAccumulator()
+	escalations := 0
+	for _, m := range mentions {
+		acc.add(m)
+		if m.EscalatedAt > 0 {
+			escalations++
+		}
+	}
+
+	group := acc.group("")
+	return DigestWeek{
+		Mentions:      group.Total,
+		Escalations:   escalations,
+		Expired:       group.Outcomes[domain.MentionOutcomeExpired],
+		Responded:     group.Responded,
+		MedianSeconds: group.MedianSeconds,
+	}
+}
+
+// repeatedNonResponders はエスカレーション・期限切れになったメンションが digestRepeatThreshold 件以上あるメンバーを件数の多い順に返します
+func repeatedNonResponders(mentions []*domain.Mention) []NonResponder {
+	counts := make(map[string]int)
+	for _, m := range mentions {
+		if m.EscalatedAt > 0 || m.Outcome == domain.MentionOutcomeExpired {
+			counts[m.MentionedUserID]++
+		}
+	}
+
+	var nonResponders []NonResponder
+	for userID, count := range counts {
+		if count >= digestRepeatThreshold {
+			nonResponders = append(nonResponders, NonResponder{UserID: userID, Count: count})
+		}
+	}
+	sort.Slice(nonResponders, func(i, j int) bool {
+		if nonResponders[i].Count != nonResponders[j].Count {
+			return nonResponders[i].Count > nonResponders[j].Count
+		}
+		return nonResponders[i].UserID < nonResponders[j].UserID
+	})
+
+	if len(nonResponders) > digestNonResponderLimit {
+		nonResponders = nonResponders[:digestNonResponderLimit]
+	}
+	return nonResponders
+}
+
+// renderWeeklyDigest はダイジェストを DM の文面（mrkdwn）に変換します
+// 日付は Slack の日付書式で表示し、上長のタイムゾーンで表示されるようにします
+func renderWeeklyDigest(teamID string, d *WeeklyDigest, nowUnix int64) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "*:bar_chart: 週次メンションダイジェスト*（%s〜%s）\n", slackDate(d.From), slackDate(d.To))
+	fmt.Fprintf(&b, "• メンション: %d件%s\n", d.ThisWeek.Mentions, formatTrend(d.ThisWeek.Mentions, d.LastWeek.Mentions))
+	fmt.Fprintf(&b, "• エスカレーション: %d件%s\n", d.ThisWeek.Escalations, formatTrend(d.ThisWeek.Escalations, d.LastWeek.Escalations))
+	fmt.Fprintf(&b, "• 期限切れ: %d件%s\n", d.ThisWeek.Expired, formatTrend(d.ThisWeek.Expired, d.LastWeek.Expired))
+	fmt.Fprintf(&b, "• 応答時間の中央値: %s（先週 %s）\n", formatDigestMedian(d.ThisWeek), formatDigestMedian(d.LastWeek))
+
+	if len(d.SlowestOpen) > 0 {
+		b.WriteString("\n*返信待ちが長いメンション*\n")
+		for _, m := range d.SlowestOpen {
+			fmt.Fprintf(&b, "• <@%s>（<@%s> から）<#%s> ・ %s経過 ・ <%s|スレッドを開く>\n",
+				m.MentionedUserID, m.ParentUserID, m.ChannelID,
+				formatDigestDuration(nowUnix-m.CreatedAt), threadURL(teamID, m.ChannelID, m.ThreadRootTS()))
+		}
+	}
+
+	if len(d.NonResponders) > 0 {
+		b.WriteString("\n*未応答が続いているメンバー*（今週のエスカレーション・期限切れ）\n")
+		for _, r := range d.NonResponders {
+			fmt.Fprintf(&b, "• <@%s> %d件\n", r.UserID, r.Count)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// slackDate は Unix 秒を Slack の日付書式（閲覧者のタイムゾーンで表示）に変換します
+func slackDate(unix int64) string {
+	return fmt.Sprintf("<!date^%d^{date_short}|%s>", unix, time.Unix(unix, 0).UTC().Format("2006-01-02"))
+}
+
+// formatTrend は先週との比較を表示用の文字列に変換します
+func formatTrend(current, last int) string {
+	diff := current - last
+	switch {
+	case diff > 0:
+		return fmt.Sprintf("（先週 %d件、+%d）", last, diff)
+	case diff < 0:
+		return fmt.Sprintf("（先週 %d件、%d）", last, diff)
+	default:
+		return fmt.Sprintf("（先週 %d件、増減なし）", last)
+	}
+}
+
+// formatDigestMedian は応答時間の中央値を表示用の文字列に変換します（応答がない場合は "-"）
+func formatDigestMedian(w DigestWeek) string {
+	if w.Responded == 0 {
+		return "-"
+	}
+	return formatDigestDuration(w.MedianSeconds)
+}
+
+// formatDigestDuration は秒数を「3日」「5時間」「12分」のような概算の表示に変換します
+func formatDigestDuration(seconds int64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d < time.Minute:
+		return "1分未満"
+	case d < time.Hour:
+		return fmt.Sprintf("%d分", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%d時間", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%d日", int(d/(24*time.Hour)))
+	}
+}
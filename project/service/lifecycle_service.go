@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/config"
+)
+
+// LifecycleService はアプリのアンインストール・トークン失効などワークスペースのライフサイクルを管理するサービスです
+type LifecycleService interface {
+	// OnAppUninstalled は app_uninstalled イベントで呼ばれ、テナントを無効化し、
+	// Bot トークンと監視中メンションを破棄します
+	OnAppUninstalled(ctx context.Context, teamID string, nowUnix int64) error
+
+	// OnTokensRevoked は tokens_revoked イベントで呼ばれます
+	// Bot トークンが失効した場合はアンインストールと同様に処理し、ユーザートークンのみの場合は何もしません
+	OnTokensRevoked(ctx context.Context, teamID string, botTokensRevoked bool, nowUnix int64) error
+}
+
+// lifecycleService は LifecycleService の実装です
+type lifecycleService struct {
+	cfg   *config.Config
+	mr    domain.MentionRepository
+	tr    domain.TenantRepository
+	sp    SecretPort
+	cache TokenCachePort
+}
+
+// NewLifecycleService は LifecycleService のインスタンスを作成します
+func NewLifecycleService(
+	cfg *config.Config,
+	mr domain.MentionRepository,
+	tr domain.TenantRepository,
+	sp SecretPort,
+	cache TokenCachePort,
+) LifecycleService {
+	return &lifecycleService{
+		cfg:   cfg,
+		mr:    mr,
+		tr:    tr,
+		sp:    sp,
+		cache: cache,
+	}
+}
+
+// OnAppUninstalled はテナントを無効化し、トークンと監視中メンションを破棄します
+func (ls *lifecycleService) OnAppUninstalled(ctx context.Context, teamID string, nowUnix int64) error {
+	if err := ls.deactivate(ctx, teamID, nowUnix); err != nil {
+		return fmt.Errorf("OnAppUninstalled: %w", err)
+	}
+	return nil
+}
+
+// OnTokensRevoked は Bot トークンが失効した場合にテナントを無効化します
+func (ls *lifecycleService) OnTokensRevoked(ctx context.Context, teamID string, botTokensRevoked bool, nowUnix int64) error {
+	if !botTokensRevoked {
+		// このアプリはユーザートークンを使用しないため対象外
+		return nil
+	}
+	if err := ls.deactivate(ctx, teamID, nowUnix); err != nil {
+		return fmt.Errorf("OnTokensRevoked: %w", err)
+	}
+	return nil
+}
+
+// deactivate はテナント無効化・監視中メンションの中止・トークン破棄・キャッシュ破棄を行います
+// app_uninstalled と tokens_revoked は続けて届くため、全ての処理は冪等です
+// 途中で失敗しても残りの処理は続け、失敗をまとめて返します
+func (ls *lifecycleService) deactivate(ctx context.Context, teamID string, nowUnix int64) error {
+	// 古いトークンを使い続けないよう最初にキャッシュを破棄
+	ls.cache.Evict(teamID)
+
+	secretName := ls.cfg.SecretTokenPrefix + teamID
+	var errs []error
+
+	tenant, err := ls.tr.Get(ctx, teamID)
+	switch {
+	case err == nil:
+		if tenant.BotTokenSecretName != "" {
+			secretName = tenant.BotTokenSecretName
+		}
+		// 以降のタスク・イベントはテナント無効としてスキップされる
+		if err := ls.tr.Deactivate(ctx, teamID, nowUnix); err != nil {
+			errs = append(errs, fmt.Errorf("テナント無効化失敗: %w", err))
+		}
+	case errors.Is(err, domain.ErrTenantNotRegistered), errors.Is(err, domain.ErrNotFound):
+		// テナント未登録でもトークンと監視中メンションは破棄する
+	default:
+		errs = append(errs, fmt.Errorf("テナント取得失敗: %w", err))
+	}
+
+	if _, err := ls.mr.CancelOpenByTeam(ctx, teamID, nowUnix); err != nil {
+		errs = append(errs, fmt.Errorf("監視中メンション中止失敗: %w", err))
+	}
+
+	if err := ls.sp.DestroySecretVersions(ctx, secretName); err != nil {
+		errs = append(errs, fmt.Errorf("トークン破棄失敗: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/store/memory"
+)
+
+// fakeSecrets は破棄したシークレットを記録する SecretPort のテスト用実装です
+type fakeSecrets struct {
+	destroyed []string
+	err       error
+}
+
+func (s *fakeSecrets) DestroySecretVersions(ctx context.Context, secretName string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.destroyed = append(s.destroyed, secretName)
+	return nil
+}
+
+// fakeTokenCache は破棄したキャッシュを記録する TokenCachePort のテスト用実装です
+type fakeTokenCache struct {
+	evicted []string
+}
+
+func (c *fakeTokenCache) Evict(teamID string) {
+	c.evicted = append(c.evicted, teamID)
+}
+
+func TestLifecycleDeactivate(t *testing.T) {
+	errSecret := errors.New("secretmanager: unavailable")
+
+	tests := []struct {
+		name          string
+		registered    bool
+		revoke        func(ls LifecycleService, ctx context.Context) error
+		secretErr     error
+		wantErr       error
+		wantCancelled bool
+		wantInactive  bool
+		wantDestroyed []string
+	}{
+		{
+			name:       "アンインストールでテナントを無効化し、監視中メンションとトークンを破棄する",
+			registered: true,
+			revoke: func(ls LifecycleService, ctx context.Context) error {
+				return ls.OnAppUninstalled(ctx, testTeamID, 2000)
+			},
+			wantCancelled: true,
+			wantInactive:  true,
+			wantDestroyed: []string{"custom_token_T1"},
+		},
+		{
+			name: "テナント未登録でも既定のシークレット名のトークンと監視中メンションを破棄する",
+			revoke: func(ls LifecycleService, ctx context.Context) error {
+				return ls.OnAppUninstalled(ctx, testTeamID, 2000)
+			},
+			wantCancelled: true,
+			wantDestroyed: []string{"slack_token_T1"},
+		},
+		{
+			name:       "Bot トークンの失効はアンインストールと同様に処理する",
+			registered: true,
+			revoke: func(ls LifecycleService, ctx context.Context) error {
+				return ls.OnTokensRevoked(ctx, testTeamID, true, 2000)
+			},
+			wantCancelled: true,
+			wantInactive:  true,
+			wantDestroyed: []string{"custom_token_T1"},
+		},
+		{
+			name:       "ユーザートークンのみの失効は何もしない",
+			registered: true,
+			revoke: func(ls LifecycleService, ctx context.Context) error {
+				return ls.OnTokensRevoked(ctx, testTeamID, false, 2000)
+			},
+		},
+		{
+			name:       "トークンの破棄に失敗しても無効化・中止は行いエラーを返す",
+			registered: true,
+			revoke: func(ls LifecycleService, ctx context.Context) error {
+				return ls.OnAppUninstalled(ctx, testTeamID, 2000)
+			},
+			secretErr:     errSecret,
+			wantErr:       errSecret,
+			wantCancelled: true,
+			wantInactive:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewRepo()
+			secrets := &fakeSecrets{err: tt.secretErr}
+			cache := &fakeTokenCache{}
+			ls := NewLifecycleService(&config.Config{SecretTokenPrefix: "slack_token_"}, repo, repo, secrets, cache)
+
+			if tt.registered {
+				if err := repo.UpsertBotTokenSecret(ctx, testTeamID, "custom_token_T1"); err != nil {
+					t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+				}
+			}
+			saveTestMention(t, repo, 1000, 1)
+
+			err := tt.revoke(ls, ctx)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			m := findTestMention(t, repo)
+			if cancelled := m.Status == domain.MentionStatusCancelled; cancelled != tt.wantCancelled {
+				t.Errorf("Status = %s, want cancelled = %v", m.Status, tt.wantCancelled)
+			}
+			if tt.registered {
+				tenant, err := repo.Get(ctx, testTeamID)
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				if tenant.IsActive() == tt.wantInactive {
+					t.Errorf("IsActive() = %v, want %v", tenant.IsActive(), !tt.wantInactive)
+				}
+			}
+			if !slices.Equal(secrets.destroyed, tt.wantDestroyed) {
+				t.Errorf("destroyed secrets = %v, want %v", secrets.destroyed, tt.wantDestroyed)
+			}
+			// 処理した場合は古いトークンを使い続けないようキャッシュを破棄する
+			if wantEvicted := tt.wantCancelled; slices.Contains(cache.evicted, testTeamID) != wantEvicted {
+				t.Errorf("evicted = %v, want evicted = %v", cache.evicted, wantEvicted)
+			}
+		})
+	}
+}
+
+func TestLifecycleDeactivateIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepo()
+	secrets := &fakeSecrets{}
+	ls := NewLifecycleService(&config.Config{SecretTokenPrefix: "slack_token_"}, repo, repo, secrets, &fakeTokenCache{})
+
+	if err := repo.UpsertBotTokenSecret(ctx, testTeamID, "slack_token_T1"); err != nil {
+		t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+	}
+	saveTestMention(t, repo, 1000, 1)
+
+	// app_uninstalled と tokens_revoked は続けて届く
+	if err := ls.OnAppUninstalled(ctx, testTeamID, 2000); err != nil {
+		t.Fatalf("OnAppUninstalled() error = %v", err)
+	}
+	if err := ls.OnTokensRevoked(ctx, testTeamID, true, 2001); err != nil {
+		t.Fatalf("OnTokensRevoked() error = %v", err)
+	}
+
+	m := findTestMention(t, repo)
+	if m.Status != domain.MentionStatusCancelled || m.ClosedAt != 2000 {
+		t.Errorf("mention = status %s closed_at %d, want cancelled at 2000", m.Status, m.ClosedAt)
+	}
+}
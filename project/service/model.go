@@ -1,5 +1,17 @@
 package service
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"slack-bot/project/domain"
+)
+
 // MentionEvent はSlackメンションイベントを表します
 type MentionEvent struct {
 	// TeamID はSlackワークスペースのID
@@ -20,10 +32,219 @@ type MentionEvent struct {
 	// ParentUserID はメンションを投稿したユーザーID（メンション返信判定に使用）
 	ParentUserID string
 
+	// ThreadTS はメンションがスレッド内の返信の場合のスレッド親メッセージのタイムスタンプ（スレッド外の投稿では空）
+	ThreadTS string
+
 	// NowUnix はイベント発生時刻（Unix秒）
 	NowUnix int64
 }
 
+// ReplyCheck はスレッドの返信確認結果を表します
+type ReplyCheck struct {
+	// Replied は返信条件を満たすメッセージが見つかったかどうか
+	Replied bool
+
+	// ReplyTS は返信条件を満たしたメッセージのタイムスタンプ（未返信の場合は空）
+	ReplyTS string
+
+	// PagesScanned は読み込んだ conversations.replies のページ数
+	PagesScanned int
+
+	// Complete はスレッドを最後まで確認できたかどうか
+	// ページ数の上限に達して途中で打ち切った場合は false（未返信と断定できない）
+	Complete bool
+}
+
+// MessageEvent はSlackの通常メッセージイベント（スレッド返信の検知用）を表します
+type MessageEvent struct {
+	// TeamID はSlackワークスペースのID
+	TeamID string
+
+	// ChannelID はメッセージが投稿されたチャンネルのID
+	ChannelID string
+
+	// MessageTS はメッセージのタイムスタンプ
+	MessageTS string
+
+	// ThreadTS はスレッド親メッセージのタイムスタンプ（スレッド外の投稿では空）
+	ThreadTS string
+
+	// UserID はメッセージを投稿したユーザーID
+	UserID string
+
+	// Text はメッセージのテキスト（メンション返信判定に使用）
+	Text string
+
+	// NowUnix はイベント発生時刻（Unix秒）
+	NowUnix int64
+}
+
+// ReactionEvent はメッセージへのリアクション追加・削除イベントを表します
+type ReactionEvent struct {
+	// TeamID はSlackワークスペースのID
+	TeamID string
+
+	// ChannelID はリアクションされたメッセージのチャンネルID
+	ChannelID string
+
+	// MessageTS はリアクションされたメッセージのタイムスタンプ
+	MessageTS string
+
+	// UserID はリアクションしたユーザーID
+	UserID string
+
+	// Reaction は絵文字名（コロンなし）
+	Reaction string
+
+	// Removed はリアクションの削除（reaction_removed）かどうか
+	Removed bool
+
+	// NowUnix はイベント発生時刻（Unix秒）
+	NowUnix int64
+}
+
+// MyMentions はユーザーに関係する監視中のメンション一覧を表します
+type MyMentions struct {
+	// Owed はユーザーが返信を期待されている（対象者である）メンション
+	Owed []MentionSummary
+
+	// Waiting はユーザーが送信し、返信を待っているメンション
+	Waiting []MentionSummary
+}
+
+// MentionSummary は一覧表示用のメンションと次の通知予定を表します
+type MentionSummary struct {
+	// Mention は監視対象のメンション
+	Mention *domain.Mention
+
+	// URL はメンションを含むスレッドの URL
+	URL string
+
+	// NextStepAt は次のエスカレーションステップの実行予定時刻（Unix秒）。残りのステップがない場合は0
+	NextStepAt int64
+
+	// NextAction は次のエスカレーションステップの通知の種類（残りのステップがない場合は空）
+	NextAction domain.EscalationAction
+}
+
+// PendingQuery はワークスペースの監視中メンション一覧の取得条件です
+type PendingQuery struct {
+	// ChannelID はチャンネルでの絞り込み（空の場合は全チャンネル）
+	ChannelID string
+
+	// UserID は対象者での絞り込み（空の場合は全員）
+	UserID string
+
+	// Page はページ番号（1始まり。0以下は1ページ目）
+	Page int
+}
+
+// PendingPage はワークスペースの監視中メンション一覧の1ページを表します
+type PendingPage struct {
+	// Mentions は古い順のメンション
+	Mentions []MentionSummary
+
+	// Page はページ番号（1始まり）
+	Page int
+
+	// HasNext は次のページがあるかどうか
+	HasNext bool
+}
+
+// ResponseStats はメンションへの応答時間の集計結果を表します
+type ResponseStats struct {
+	// From は集計期間の開始（Unix秒。この時刻以降に作成されたメンションが対象）
+	From int64
+
+	// To は集計期間の終了（Unix秒。この時刻より前に作成されたメンションが対象）
+	To int64
+
+	// Team はワークスペース全体の集計（Key は空）
+	Team StatsGroup
+
+	// ByUser は対象者ごとの集計（Key は対象者のユーザーID）。p90 の長い順
+	ByUser []StatsGroup
+
+	// ByChannel はチャンネルごとの集計（Key はチャンネルID）。p90 の長い順
+	ByChannel []StatsGroup
+}
+
+// StatsGroup は1つの集計単位（ワークスペース・対象者・チャンネル）の応答時間を表します
+type StatsGroup struct {
+	// Key は集計単位の識別子（ユーザーID・チャンネルID）
+	Key string
+
+	// Total は期間内に作成されたメンション数
+	Total int
+
+	// Open は監視中（未応答）のメンション数
+	Open int
+
+	// Responded は対象者が応答して監視を終了したメンション数（応答時間の集計対象）
+	Responded int
+
+	// Outcomes は監視終了時の結果ごとの件数
+	Outcomes map[domain.MentionOutcome]int
+
+	// MedianSeconds は応答までの時間の中央値（秒）。応答がない場合は0
+	MedianSeconds int64
+
+	// P90Seconds は応答までの時間の90パーセンタイル（秒）。応答がない場合は0
+	P90Seconds int64
+}
+
+// WeeklyDigest は上長に送信する週次ダイジェストの内容を表します
+// 集計対象は上長の担当範囲（ワークスペース全体の上長は全メンション、メンバー・チャンネルごとの上長は上長DMの送信先になるメンション）です
+type WeeklyDigest struct {
+	// ManagerUserID は送信先の上長のユーザーID
+	ManagerUserID string
+
+	// From は今週の集計期間の開始（Unix秒）
+	From int64
+
+	// To は今週の集計期間の終了（Unix秒）
+	To int64
+
+	// ThisWeek は今週作成されたメンションの集計
+	ThisWeek DigestWeek
+
+	// LastWeek は先週作成されたメンションの集計（傾向の比較用）
+	LastWeek DigestWeek
+
+	// SlowestOpen は返信待ちが長い（古い順の）監視中メンション
+	SlowestOpen []*domain.Mention
+
+	// NonResponders は今週エスカレーション・期限切れになったメンションが複数あるメンバー（件数の多い順）
+	NonResponders []NonResponder
+}
+
+// DigestWeek は週次ダイジェストの1週間分の集計を表します
+type DigestWeek struct {
+	// Mentions は期間内に作成されたメンション数
+	Mentions int
+
+	// Escalations はエスカレーション（上長DM・チャンネル投稿）まで進んだメンション数
+	Escalations int
+
+	// Expired は期限切れで監視を終了したメンション数
+	Expired int
+
+	// Responded は対象者が応答したメンション数
+	Responded int
+
+	// MedianSeconds は応答までの時間の中央値（秒）。応答がない場合は0
+	MedianSeconds int64
+}
+
+// NonResponder は未応答が続いているメンバーを表します
+type NonResponder struct {
+	// UserID はメンバーのユーザーID
+	UserID string
+
+	// Count はエスカレーション・期限切れになったメンション数
+	Count int
+}
+
 // TaskPayload はCloud Tasksのジョブペイロードを表します
 type TaskPayload struct {
 	// TeamID はSlackワークスペースのID
@@ -40,4 +261,119 @@ type TaskPayload struct {
 
 	// ParentUserID はメンションを投稿したユーザーID
 	ParentUserID string
+
+	// Step は実行するエスカレーションステップのインデックス
+	Step int
+
+	// CorrelationID はメンションを検知した Slack イベント（またはスヌーズなどの操作）の相関 ID
+	// 以降のステップのタスクにも引き継ぎ、イベントから派生したログをまとめて検索できるようにします
+	CorrelationID string
+}
+
+// logAttrs はタスクの対象メンションを表すログ属性に extra を加えて返します
+func (p *TaskPayload) logAttrs(extra ...any) []any {
+	attrs := []any{"team_id", p.TeamID, "channel_id", p.ChannelID, "message_ts", p.MessageTS, "user_id", p.UserID, "step", p.Step}
+	return append(attrs, extra...)
+}
+
+// TaskName はメンション・ステップ・実行時刻（runAt）から決まるタスク名を返します
+// Slack イベントの再送やタスクの再実行で同じタスクが重複して予約されても、スケジューラが同名のタスクとして拒否できます
+// Cloud Tasks のタスク名に使える文字（英数字・ハイフン・アンダースコア）に収めるため SHA-256 の16進表記にします
+func (p *TaskPayload) TaskName(runAt int64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%s:%s:%d:%d", p.TeamID, p.ChannelID, p.MessageTS, p.UserID, p.Step, runAt)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReminderRef はリマインドメッセージのボタンに埋め込む監視対象メンションの識別子です
+type ReminderRef struct {
+	// TeamID はSlackワークスペースのID
+	TeamID string
+
+	// ChannelID はメンションが投稿されたチャンネルのID
+	ChannelID string
+
+	// MessageTS はメンションが投稿されたメッセージのタイムスタンプ
+	MessageTS string
+
+	// UserID は監視対象（ボタンを操作できる）ユーザーID
+	UserID string
+}
+
+// reminderRefSep は ReminderRef を文字列化する際の区切り文字です
+// Slack の選択肢の value は 150 文字までのため JSON ではなく区切り文字形式にしています
+const reminderRefSep = "|"
+
+// Encode はボタンの value に埋め込む文字列に変換します
+func (r ReminderRef) Encode() string {
+	return strings.Join([]string{r.TeamID, r.ChannelID, r.MessageTS, r.UserID}, reminderRefSep)
+}
+
+// SnoozeValue はスヌーズ選択肢の value（識別子とスヌーズ秒数）を生成します
+func (r ReminderRef) SnoozeValue(d time.Duration) string {
+	return r.Encode() + reminderRefSep + strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// ParseReminderRef は Encode で生成した文字列から ReminderRef を復元します
+func ParseReminderRef(s string) (ReminderRef, error) {
+	parts := strings.Split(s, reminderRefSep)
+	if len(parts) != 4 {
+		return ReminderRef{}, fmt.Errorf("リマインド識別子の形式が不正です: %s", s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return ReminderRef{}, fmt.Errorf("リマインド識別子の形式が不正です: %s", s)
+		}
+	}
+	return ReminderRef{TeamID: parts[0], ChannelID: parts[1], MessageTS: parts[2], UserID: parts[3]}, nil
+}
+
+// ParseSnoozeValue は SnoozeValue で生成した文字列から ReminderRef とスヌーズ期間を復元します
+func ParseSnoozeValue(s string) (ReminderRef, time.Duration, error) {
+	i := strings.LastIndex(s, reminderRefSep)
+	if i < 0 {
+		return ReminderRef{}, 0, fmt.Errorf("スヌーズ指定の形式が不正です: %s", s)
+	}
+
+	ref, err := ParseReminderRef(s[:i])
+	if err != nil {
+		return ReminderRef{}, 0, err
+	}
+
+	seconds, err := strconv.ParseInt(s[i+1:], 10, 64)
+	if err != nil || seconds <= 0 {
+		return ReminderRef{}, 0, fmt.Errorf("スヌーズ期間の形式が不正です: %s", s)
+	}
+
+	return ref, time.Duration(seconds) * time.Second, nil
+}
+
+// EventTask は受信した Slack イベントをタスクキュー経由で処理するジョブのペイロードを表します
+type EventTask struct {
+	// TeamID はSlackワークスペースのID
+	TeamID string
+
+	// EventID は Slack イベントのID（再送されたイベントの重複排除に使用）
+	EventID string
+
+	// EventType はイベントの種類（ログ・メトリクス用）
+	EventType string
+
+	// Body は署名検証済みの Slack Events API のリクエスト本体
+	Body json.RawMessage
+
+	// CorrelationID はイベント受信時に発行した相関 ID
+	CorrelationID string
+}
+
+// TaskName はイベント（event_id）から決まるタスク名を返します
+// Slack がイベントを再送しても同じタスク名になるため、スケジューラが重複した登録を拒否できます
+func (t *EventTask) TaskName() string {
+	key := t.EventID
+	if key == "" {
+		// event_id のないイベントはリクエスト本体で識別する
+		sum := sha256.Sum256(t.Body)
+		key = hex.EncodeToString(sum[:])
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("event:%s:%s", t.TeamID, key)))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTaskPayloadTaskName(t *testing.T) {
+	base := testPayload(1)
+	name := base.TaskName(1000)
+
+	// 同じメンション・ステップ・実行時刻なら同じタスク名になる（相関 ID は含めない）
+	same := testPayload(1)
+	same.CorrelationID = "other"
+	if got := same.TaskName(1000); got != name {
+		t.Errorf("TaskName() = %s, want %s", got, name)
+	}
+
+	tests := []struct {
+		name    string
+		payload *TaskPayload
+		runAt   int64
+	}{
+		{"実行時刻が異なる", testPayload(1), 1001},
+		{"ステップが異なる", testPayload(2), 1000},
+		{"対象者が異なる", &TaskPayload{TeamID: testTeamID, ChannelID: testChannelID, MessageTS: testMessageTS, UserID: "U2", Step: 1}, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.TaskName(tt.runAt); got == name {
+				t.Errorf("TaskName() = %s, want different from %s", got, name)
+			}
+		})
+	}
+}
+
+func TestEventTaskTaskName(t *testing.T) {
+	body := json.RawMessage(`{"event_id":"Ev1"}`)
+	name := (&EventTask{TeamID: testTeamID, EventID: "Ev1", Body: body}).TaskName()
+
+	tests := []struct {
+		name     string
+		task     *EventTask
+		wantSame bool
+	}{
+		{"再送されたイベントは同じタスク名", &EventTask{TeamID: testTeamID, EventID: "Ev1", EventType: "app_mention", Body: json.RawMessage(`{"event_id":"Ev1","retry":1}`), CorrelationID: "c2"}, true},
+		{"event_id が異なる", &EventTask{TeamID: testTeamID, EventID: "Ev2", Body: body}, false},
+		{"ワークスペースが異なる", &EventTask{TeamID: "T2", EventID: "Ev1", Body: body}, false},
+		{"event_id がなければ本体で識別する", &EventTask{TeamID: testTeamID, Body: body}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.TaskName(); (got == name) != tt.wantSame {
+				t.Errorf("TaskName() = %s, base %s, want same = %v", got, name, tt.wantSame)
+			}
+		})
+	}
+
+	// event_id のないイベントも本体が同じなら同じタスク名になる
+	a := (&EventTask{TeamID: testTeamID, Body: body}).TaskName()
+	b := (&EventTask{TeamID: testTeamID, Body: body}).TaskName()
+	if a != b {
+		t.Errorf("TaskName() without event_id = %s and %s, want equal", a, b)
+	}
+}
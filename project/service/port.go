@@ -4,27 +4,59 @@ import "context"
 
 // SlackPort は Slack API 呼び出しのポートです
 type SlackPort interface {
-	// HasUserReplied は指定されたユーザーが指定されたメッセージ以降に返信しているかを判定します
-	// oldest パラメータはメッセージの検索開始点を示します（通常は親メッセージの TS）
-	HasUserReplied(ctx context.Context, teamID, channelID, messageTS, userID, oldest string) (bool, error)
-
 	// HasUserRepliedWithMention は対象ユーザーが送信元ユーザーへメンション付きで返信しているか判定します
+	// threadTS: スレッド親メッセージのTS（メンション自体がスレッド内の返信の場合も親メッセージのTS）
+	// messageTS: メンションを含むメッセージのTS（これより後の返信のみ対象）
 	// userID: チェック対象のユーザー（メンションされた人）
-	// parentUserID: トリガーメッセージ送信者のユーザーID（メンションした人）
-	HasUserRepliedWithMention(ctx context.Context, teamID, channelID, messageTS, userID, parentUserID, oldest string) (bool, error)
+	// parentUserID: トリガーメッセージ送信者のユーザーID（メンションした人）。空の場合はメンションの有無を問わない
+	HasUserRepliedWithMention(ctx context.Context, teamID, channelID, threadTS, messageTS, userID, parentUserID string) (ReplyCheck, error)
+
+	// HasUserPostedInChannel は対象ユーザーが oldest より後にチャンネルへ（スレッド外で）投稿しているか判定します
+	HasUserPostedInChannel(ctx context.Context, teamID, channelID, oldest, userID string) (ReplyCheck, error)
+
+	// HasUserReacted は対象ユーザーがメッセージに指定のリアクション（絵文字名）をつけているか判定します
+	HasUserReacted(ctx context.Context, teamID, channelID, messageTS, userID, reaction string) (ReplyCheck, error)
 
 	// PostThreadMessage はスレッドにメッセージを投稿します
 	PostThreadMessage(ctx context.Context, teamID, channelID, messageTS, text string) error
 
+	// PostThreadReminder はスレッドに操作ボタン（確認済み・スヌーズ・担当外）付きのリマインドを投稿します
+	PostThreadReminder(ctx context.Context, teamID, channelID, messageTS, text string, ref ReminderRef) error
+
+	// PostDMReminder は対象者に操作ボタン（確認済み・スヌーズ・担当外）付きのリマインドをDMで送信します
+	PostDMReminder(ctx context.Context, teamID, userID, text string, ref ReminderRef) error
+
 	// PostDM は指定されたユーザーにDMを送信します
 	PostDM(ctx context.Context, teamID, userID, text string) error
+
+	// PostChannelMessage はチャンネルに（スレッド外で）メッセージを投稿します
+	PostChannelMessage(ctx context.Context, teamID, channelID, text string) error
+
+	// IsWorkspaceAdmin はユーザーがワークスペースの管理者またはオーナーかどうかを返します
+	IsWorkspaceAdmin(ctx context.Context, teamID, userID string) (bool, error)
 }
 
 // TaskPort は Cloud Tasks へのジョブ予約のポートです
 type TaskPort interface {
-	// EnqueueRemind は指定時刻に CheckRemind を実行するジョブをキューに登録します
+	// EnqueueRemind は指定時刻に CheckRemind（最初のステップ）を実行するジョブをキューに登録します
 	EnqueueRemind(ctx context.Context, runAt int64, payload *TaskPayload) error
 
-	// EnqueueEscalate は指定時刻に CheckEscalate を実行するジョブをキューに登録します
+	// EnqueueEscalate は指定時刻に CheckEscalate（2番目以降のステップ）を実行するジョブをキューに登録します
 	EnqueueEscalate(ctx context.Context, runAt int64, payload *TaskPayload) error
+
+	// EnqueueEvent は受信した Slack イベントを直ちに処理するジョブをキューに登録します
+	// 同じイベント（event_id）のジョブがすでに登録されている場合は登録せずに成功として扱います
+	EnqueueEvent(ctx context.Context, task *EventTask) error
+}
+
+// SecretPort は Secret Manager 操作のポートです
+type SecretPort interface {
+	// DestroySecretVersions はシークレットの全バージョンを破棄します（存在しない場合は成功）
+	DestroySecretVersions(ctx context.Context, secretName string) error
+}
+
+// TokenCachePort はワークスペースごとの Slack クライアント（Bot トークン）キャッシュのポートです
+type TokenCachePort interface {
+	// Evict は teamID のキャッシュ済みクライアントを破棄します
+	Evict(teamID string)
 }
@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"slack-bot/project/domain"
 	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/logging"
+	"slack-bot/project/infrastructure/metrics"
 )
 
 // ReminderService はメンション監視とリマインド通知を管理するサービスです
@@ -16,11 +22,37 @@ type ReminderService interface {
 	// OnMention はメンション検知時に呼ばれ、監視レコードを保存し、定期チェックタスクをキューに登録します
 	OnMention(ctx context.Context, ev *MentionEvent) error
 
-	// CheckRemind は10分後の定期チェックで呼ばれ、返信がなければリマインドを送信します
+	// OnMessage はスレッド返信のメッセージイベントで呼ばれ、対応する監視レコードを返信済みにします
+	OnMessage(ctx context.Context, ev *MessageEvent) error
+
+	// OnReaction はメンションを含むメッセージへのリアクション追加・削除で呼ばれ、監視状態を更新します
+	// 「確認中」は上長DMを抑止し、「対応済み」は監視を終了します（対象者またはメンション送信者のリアクションのみ）
+	OnReaction(ctx context.Context, ev *ReactionEvent) error
+
+	// CheckRemind はエスカレーション手順の最初のステップで呼ばれ、返信がなければ通知を送信します
 	CheckRemind(ctx context.Context, p *TaskPayload) error
 
-	// CheckEscalate は30分後の定期チェックで呼ばれ、返信がなければ再通知と上長DMを送信します
+	// CheckEscalate はエスカレーション手順の2番目以降のステップで呼ばれ、返信がなければ通知を送信します
 	CheckEscalate(ctx context.Context, p *TaskPayload) error
+
+	// Acknowledge は対象者の「確認しました」操作で呼ばれ、以後のエスカレーションを停止します
+	Acknowledge(ctx context.Context, ref ReminderRef, actorUserID string, nowUnix int64) error
+
+	// Snooze は対象者のスヌーズ操作で呼ばれ、d 後（稼働カレンダー考慮）まで通知を保留し、その時刻に再実行を予約します
+	// 保留の期限（Unix秒）を返します
+	Snooze(ctx context.Context, ref ReminderRef, actorUserID string, d time.Duration, nowUnix int64) (int64, error)
+
+	// Decline は対象者の「担当外です」操作で呼ばれ、監視を終了してメンション送信者に通知します
+	Decline(ctx context.Context, ref ReminderRef, actorUserID string, nowUnix int64) error
+
+	// ListMine は userID が返信を期待されているメンションと、userID が返信を待っているメンションを
+	// それぞれ古い順に返します（監視中のもののみ）
+	ListMine(ctx context.Context, teamID, userID string, nowUnix int64) (*MyMentions, error)
+
+	// ListPending はワークスペースの監視中のメンションを古い順にページ単位で返します
+	// 閲覧できるのはワークスペースの管理者・オーナーと、上長に設定されているユーザーのみです
+	// 権限がない場合は domain.ErrInsufficientPermission を返します
+	ListPending(ctx context.Context, teamID, viewerUserID string, q PendingQuery, nowUnix int64) (*PendingPage, error)
 }
 
 // reminderService は ReminderService の実装です
@@ -40,13 +72,13 @@ func NewReminderService(
 	sp SlackPort,
 	tp TaskPort,
 ) ReminderService {
-	return &reminderService{
+	return &tracedReminderService{next: &reminderService{
 		cfg: cfg,
 		mr:  mr,
 		tr:  tr,
 		sp:  sp,
 		tp:  tp,
-	}
+	}}
 }
 
 // OnMention はメンション検知時に監視レコード保存とタスク予約を行います
@@ -57,6 +89,22 @@ func (rs *reminderService) OnMention(ctx context.Context, ev *MentionEvent) erro
 		return nil // Bot以外にメンション対象がないためスキップ
 	}
 
+	// テナント設定（エスカレーション手順・稼働カレンダー）
+	tenant, err := rs.getTenant(ctx, ev.TeamID)
+	if err != nil {
+		return fmt.Errorf("OnMention: %w", err)
+	}
+	if tenant != nil && !tenant.IsActive() {
+		return nil // アンインストール済みのワークスペースは監視しない
+	}
+	policy := rs.policyFor(tenant)
+
+	// スレッド内の返信でのメンションは、スレッド親メッセージを返信確認・リマインド投稿の対象にする
+	threadTS := ""
+	if ev.ThreadTS != ev.MessageTS {
+		threadTS = ev.ThreadTS
+	}
+
 	// 各メンション対象者について監視レコード作成とタスク予約
 	for _, userID := range mentionedUserIDs {
 		// ドメインエンティティ作成
@@ -64,10 +112,13 @@ func (rs *reminderService) OnMention(ctx context.Context, ev *MentionEvent) erro
 			TeamID:          ev.TeamID,
 			ChannelID:       ev.ChannelID,
 			MessageTS:       ev.MessageTS,
+			ThreadTS:        threadTS,
 			MentionedUserID: userID,
+			ParentUserID:    ev.ParentUserID,
 			CreatedAt:       ev.NowUnix,
-			Reminded:        false,
-			Escalated:       false,
+			Step:            0,
+			Status:          domain.MentionStatusOpen,
+			RetainUntil:     rs.retainUntil(tenant, ev.NowUnix),
 		}
 
 		// バリデーション
@@ -82,147 +133,831 @@ func (rs *reminderService) OnMention(ctx context.Context, ev *MentionEvent) erro
 			}
 			return fmt.Errorf("OnMention: メンション保存失敗: %w", err)
 		}
+		metrics.MentionTracked(ev.TeamID)
+		slog.InfoContext(ctx, "メンション監視開始",
+			"team_id", ev.TeamID, "channel_id", ev.ChannelID, "message_ts", ev.MessageTS, "user_id", userID)
 
 		// タスクペイロード
 		payload := &TaskPayload{
-			TeamID:       ev.TeamID,
-			ChannelID:    ev.ChannelID,
-			MessageTS:    ev.MessageTS,
-			UserID:       userID,
-			ParentUserID: ev.ParentUserID,
+			TeamID:        ev.TeamID,
+			ChannelID:     ev.ChannelID,
+			MessageTS:     ev.MessageTS,
+			UserID:        userID,
+			ParentUserID:  ev.ParentUserID,
+			Step:          0,
+			CorrelationID: logging.CorrelationID(ctx),
 		}
 
-		// 実行時刻計算
-		t0 := time.Unix(ev.NowUnix, 0)
-		runAt10 := t0.Add(rs.cfg.RemindDuration)
-		runAt30 := t0.Add(rs.cfg.EscalateDuration)
+		// 最初のステップのタスク登録（以降のステップは実行時に順次登録）
+		runAt := stepRunAt(tenant, ev.NowUnix, policy.Steps[0].Delay())
+		if err := rs.tp.EnqueueRemind(ctx, runAt.Unix(), payload); err != nil {
+			return fmt.Errorf("OnMention: リマインドタスク登録失敗: %w", err)
+		}
+	}
+
+	return nil
+}
 
-		// 10分後リマインドタスク登録
-		if err := rs.tp.EnqueueRemind(ctx, runAt10.Unix(), payload); err != nil {
-			return fmt.Errorf("OnMention: 10分後リマインドタスク登録失敗: %w", err)
+// OnMessage はスレッド返信を監視中のメンションと照合し、返信条件を満たせば返信済みにします
+// これによりリマインド・エスカレーションのタスクは Slack API を呼ばずに終了します
+func (rs *reminderService) OnMessage(ctx context.Context, ev *MessageEvent) error {
+	// スレッド外の投稿（親メッセージ自体を含む）は、チャンネルへの投稿で返信完了とするルールの場合のみ対象
+	if ev.ThreadTS == "" || ev.ThreadTS == ev.MessageTS {
+		return rs.onChannelPost(ctx, ev)
+	}
+
+	mentions, err := rs.mr.ListByThread(ctx, ev.TeamID, ev.ChannelID, ev.ThreadTS)
+	if err != nil {
+		return fmt.Errorf("OnMessage: メンション取得失敗: %w", err)
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	// テナント・チャンネルの返信判定ルール
+	tenant, err := rs.getTenant(ctx, ev.TeamID)
+	if err != nil {
+		return fmt.Errorf("OnMessage: %w", err)
+	}
+	checker := replyCheckerFor(tenant, ev.ChannelID)
+
+	for _, m := range mentions {
+		if !m.IsOpen() || m.MentionedUserID != ev.UserID {
+			continue
 		}
 
-		// 30分後エスカレーションタスク登録
-		if err := rs.tp.EnqueueEscalate(ctx, runAt30.Unix(), payload); err != nil {
-			return fmt.Errorf("OnMention: 30分後エスカレーションタスク登録失敗: %w", err)
+		// スレッド内のメンションより前の投稿は返信として扱わない
+		if !slackTSAfter(ev.MessageTS, m.MessageTS) {
+			continue
+		}
+
+		if !checker.MatchesThreadReply(m, ev) {
+			continue
+		}
+
+		if err := rs.markRepliedByMessage(ctx, m, ev); err != nil {
+			return fmt.Errorf("OnMessage: %w", err)
 		}
 	}
 
 	return nil
 }
 
-// CheckRemind は10分後のチェックで返信がなければリマインドを送信します
-func (rs *reminderService) CheckRemind(ctx context.Context, p *TaskPayload) error {
-	// 監視レコード取得
-	m, err := rs.mr.Find(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID)
+// onChannelPost は対象者のスレッド外の投稿を、同じチャンネルのメンションへの返信として記録します
+// チャンネルの返信判定ルールがチャンネルへの投稿を返信とみなす（channel）場合のみ対象です
+func (rs *reminderService) onChannelPost(ctx context.Context, ev *MessageEvent) error {
+	tenant, err := rs.getTenant(ctx, ev.TeamID)
+	if err != nil {
+		return fmt.Errorf("OnMessage: %w", err)
+	}
+	if !replyCheckerFor(tenant, ev.ChannelID).MatchesChannelPost(ev) {
+		return nil
+	}
+
+	mentions, err := rs.mr.ListOpenByMentionee(ctx, ev.TeamID, ev.UserID)
 	if err != nil {
+		return fmt.Errorf("OnMessage: メンション取得失敗: %w", err)
+	}
+
+	for _, m := range mentions {
+		// 同じチャンネルのメンションより後の投稿のみ返信として扱う
+		if m.ChannelID != ev.ChannelID || !slackTSAfter(ev.MessageTS, m.MessageTS) {
+			continue
+		}
+
+		if err := rs.markRepliedByMessage(ctx, m, ev); err != nil {
+			return fmt.Errorf("OnMessage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// markRepliedByMessage は message イベントで検知した返信を記録します
+func (rs *reminderService) markRepliedByMessage(ctx context.Context, m *domain.Mention, ev *MessageEvent) error {
+	if err := rs.mr.MarkReplied(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID, ev.NowUnix); err != nil {
 		if err == domain.ErrMentionNotFound {
-			// 古いタスクなのでスキップ
+			// 既に削除されているため無視
 			return nil
 		}
-		return fmt.Errorf("CheckRemind: メンション取得失敗: %w", err)
+		return fmt.Errorf("返信状態更新失敗: %w", err)
 	}
+	metrics.ReplyDetected(m.TeamID, "event")
+	slog.InfoContext(ctx, "返信を検知しました",
+		"team_id", m.TeamID, "channel_id", m.ChannelID, "message_ts", m.MessageTS, "user_id", m.MentionedUserID, "source", "event")
+	return nil
+}
 
-	// すでにリマインド済みなら冪等性保証
-	if m.Reminded {
+// OnReaction はリアクションに応じて監視中メンションの状態を更新します
+func (rs *reminderService) OnReaction(ctx context.Context, ev *ReactionEvent) error {
+	mentions, err := rs.mr.ListByMessage(ctx, ev.TeamID, ev.ChannelID, ev.MessageTS)
+	if err != nil {
+		return fmt.Errorf("OnReaction: メンション取得失敗: %w", err)
+	}
+	if len(mentions) == 0 {
 		return nil
 	}
 
-	// 返信確認（メンション返信の判定）
-	replied, err := rs.sp.HasUserRepliedWithMention(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID, p.ParentUserID, p.MessageTS)
+	tenant, err := rs.getTenant(ctx, ev.TeamID)
 	if err != nil {
-		return fmt.Errorf("CheckRemind: 返信判定失敗: %w", err)
+		return fmt.Errorf("OnReaction: %w", err)
 	}
-	if replied {
-		// すでにメンション付き返信済み
+	if tenant != nil && !tenant.IsActive() {
 		return nil
 	}
 
-	// リマインドメッセージ投稿
-	text := fmt.Sprintf("<@%s> さん、お手すきの際にご返信お願いします🙏（自動リマインド）", p.UserID)
-	if err := rs.sp.PostThreadMessage(ctx, p.TeamID, p.ChannelID, p.MessageTS, text); err != nil {
-		return fmt.Errorf("CheckRemind: リマインドメッセージ投稿失敗: %w", err)
+	for _, m := range mentions {
+		// 対象者またはメンション送信者のリアクションのみ扱う
+		if ev.UserID != m.MentionedUserID && ev.UserID != m.ParentUserID {
+			continue
+		}
+
+		if err := rs.applyReaction(ctx, m, ev, tenant); err != nil {
+			return fmt.Errorf("OnReaction: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyReaction は1件のメンションにリアクションを反映します
+// 同じリアクションが複数の用途に該当する場合は「対応済み」→「確認中」→「返信判定」の順に最初の用途だけを適用します
+// （設定コマンドでは重複を拒否するため、通常は該当しません）
+func (rs *reminderService) applyReaction(ctx context.Context, m *domain.Mention, ev *ReactionEvent, tenant *domain.Tenant) error {
+	workflow := domain.ReactionWorkflow{}
+	replyRule := domain.DefaultReplyRule()
+	if tenant != nil {
+		workflow = tenant.ReactionWorkflowOrDefault()
+		replyRule = tenant.ReplyRuleFor(m.ChannelID)
 	}
 
-	// リマインド完了フラグ更新
-	if err := rs.mr.MarkReminded(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID); err != nil {
-		if err == domain.ErrMentionNotFound {
-			// 既に削除されているため無視
+	switch {
+	case domain.SameReaction(ev.Reaction, workflow.DoneName()):
+		if !ev.Removed {
+			if err := rs.mr.Resolve(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID, domain.MentionStatusDone, ev.NowUnix); err != nil && !errors.Is(err, domain.ErrMentionNotFound) {
+				return fmt.Errorf("対応済み更新失敗: %w", err)
+			}
+			return nil
+		}
+
+		// 対応済みの取り消し: 監視を再開し、未実行のステップを予約し直す
+		reopened, err := rs.mr.Reopen(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID, domain.MentionStatusDone)
+		if err != nil {
+			if errors.Is(err, domain.ErrMentionNotFound) {
+				return nil
+			}
+			return fmt.Errorf("監視再開失敗: %w", err)
+		}
+		if !reopened {
+			return nil
+		}
+		next, ok := rs.nextRunAt(tenant, rs.policyFor(tenant), m, m.Step)
+		if !ok {
 			return nil
 		}
-		return fmt.Errorf("CheckRemind: リマインドフラグ更新失敗: %w", err)
+		runAt := max(next.Unix(), ev.NowUnix)
+		if err := rs.enqueueStep(ctx, m, runAt); err != nil {
+			return fmt.Errorf("監視再開のタスク登録失敗: %w", err)
+		}
+		return nil
+
+	case domain.SameReaction(ev.Reaction, workflow.AckName()):
+		acknowledgedAt := ev.NowUnix
+		if ev.Removed {
+			acknowledgedAt = 0
+		}
+		if err := rs.mr.SetAcknowledged(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID, acknowledgedAt); err != nil && !errors.Is(err, domain.ErrMentionNotFound) {
+			return fmt.Errorf("確認中更新失敗: %w", err)
+		}
+		return nil
+
+	case replyRule.Policy == domain.ReplyPolicyReaction && domain.SameReaction(ev.Reaction, replyRule.ReactionName()):
+		// 返信判定ルールがリアクションの場合は、対象者のリアクションを返信として記録
+		if ev.Removed || ev.UserID != m.MentionedUserID {
+			return nil
+		}
+		if err := rs.mr.MarkReplied(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID, ev.NowUnix); err != nil {
+			if errors.Is(err, domain.ErrMentionNotFound) {
+				return nil
+			}
+			return fmt.Errorf("返信状態更新失敗: %w", err)
+		}
+		metrics.ReplyDetected(m.TeamID, "reaction")
+		slog.InfoContext(ctx, "返信を検知しました",
+			"team_id", m.TeamID, "channel_id", m.ChannelID, "message_ts", m.MessageTS, "user_id", m.MentionedUserID, "source", "reaction")
+		return nil
 	}
 
 	return nil
 }
 
-// CheckEscalate は30分後のチェックで返信がなければ再通知と上長DMを送信します
+// CheckRemind はエスカレーション手順の最初のステップを実行します
+func (rs *reminderService) CheckRemind(ctx context.Context, p *TaskPayload) error {
+	if err := rs.runStep(ctx, p); err != nil {
+		return fmt.Errorf("CheckRemind: %w", err)
+	}
+	return nil
+}
+
+// CheckEscalate はエスカレーション手順の2番目以降のステップを実行します
 func (rs *reminderService) CheckEscalate(ctx context.Context, p *TaskPayload) error {
+	if p.Step == 0 {
+		// ステップを持たない旧形式のエスカレーションタスク（30分後の再リマインド＆上長通知）は2番目のステップとして実行する
+		p.Step = 1
+	}
+	if err := rs.runStep(ctx, p); err != nil {
+		return fmt.Errorf("CheckEscalate: %w", err)
+	}
+	return nil
+}
+
+// stepEarlyTolerance はステップの実行予定より早く届いたタスクを実行してよい誤差です（スケジューラとの時刻のずれを吸収）
+const stepEarlyTolerance = 5 * time.Second
+
+// runStep は p.Step 番目のステップについて、返信がなければ通知を送信し、次のステップを予約します
+func (rs *reminderService) runStep(ctx context.Context, p *TaskPayload) error {
 	// 監視レコード取得
 	m, err := rs.mr.Find(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID)
 	if err != nil {
 		if err == domain.ErrMentionNotFound {
 			// 古いタスクなのでスキップ
+			slog.DebugContext(ctx, "ステップをスキップ（メンション記録なし）", p.logAttrs()...)
 			return nil
 		}
-		return fmt.Errorf("CheckEscalate: メンション取得失敗: %w", err)
+		return fmt.Errorf("メンション取得失敗: %w", err)
 	}
 
-	// すでにエスカレート済みなら冪等性保証
-	if m.Escalated {
+	// 返信検知済みなど監視終了していればスキップ
+	if !m.IsOpen() {
+		slog.DebugContext(ctx, "ステップをスキップ（監視終了済み）", p.logAttrs("status", string(m.Status))...)
 		return nil
 	}
 
-	// 返信確認（メンション返信の判定）
-	replied, err := rs.sp.HasUserRepliedWithMention(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID, p.ParentUserID, p.MessageTS)
+	// スヌーズ中はスキップ（スヌーズ期限に予約したタスクで実行する）
+	if m.IsSnoozed(time.Now().Unix()) {
+		slog.DebugContext(ctx, "ステップをスキップ（スヌーズ中）", p.logAttrs("snoozed_until", m.SnoozedUntil)...)
+		return nil
+	}
+
+	// すでにこのステップを実行済みなら冪等性保証
+	if m.Step > p.Step {
+		slog.DebugContext(ctx, "ステップをスキップ（実行済み）", p.logAttrs()...)
+		return nil
+	}
+
+	// テナント設定（上長・エスカレーション手順）
+	tenant, err := rs.getTenant(ctx, p.TeamID)
 	if err != nil {
-		return fmt.Errorf("CheckEscalate: 返信判定失敗: %w", err)
+		return err
 	}
-	if replied {
-		// すでにメンション付き返信済み
+	if tenant != nil && !tenant.IsActive() {
+		// アンインストール・トークン失効済みのワークスペースは通知しない
+		slog.DebugContext(ctx, "ステップをスキップ（ワークスペース無効）", p.logAttrs()...)
 		return nil
 	}
+	policy := rs.policyFor(tenant)
+	if p.Step >= len(policy.Steps) {
+		// 全ステップの実行後（手順が短く変更された場合を含む）は期限切れを判定
+		return rs.expire(ctx, p, m, tenant)
+	}
+	step := policy.Steps[p.Step]
 
-	// 30分再通知（スレッド投稿）
-	text30 := fmt.Sprintf("<@%s> さん、まだ未返信のようです。目安だけでもご共有ください🙏（自動リマインド）", p.UserID)
-	if err := rs.sp.PostThreadMessage(ctx, p.TeamID, p.ChannelID, p.MessageTS, text30); err != nil {
-		return fmt.Errorf("CheckEscalate: 30分再通知投稿失敗: %w", err)
+	// メンションからの本来の実行予定より前には実行しない（予定時刻に予約し直す）
+	if due := stepRunAt(tenant, m.CreatedAt, step.Delay()); time.Now().Add(stepEarlyTolerance).Before(due) {
+		slog.DebugContext(ctx, "ステップを予定時刻に再予約（予定より早い実行）", p.logAttrs("due", due.Unix())...)
+		if err := rs.enqueuePayload(ctx, p, due.Unix()); err != nil {
+			return fmt.Errorf("ステップ%dの再予約失敗: %w", p.Step+1, err)
+		}
+		return nil
 	}
 
-	// 上長取得と上長DM送信
-	tenant, err := rs.tr.Get(ctx, p.TeamID)
+	// 返信確認（テナント・チャンネルの返信判定ルールに従う）
+	check, err := replyCheckerFor(tenant, p.ChannelID).Check(ctx, rs.sp, m)
 	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			// テナント未設定なため上長DMはスキップ（エラーにしない）
-		} else {
-			return fmt.Errorf("CheckEscalate: テナント取得失敗: %w", err)
-		}
-	} else if tenant.ManagerUserID != nil {
-		// 上長DM送信
-		dmText := fmt.Sprintf(
-			"【エスカレーション】<@%s> さんが未返信です。対象スレッド: https://app.slack.com/client/%s/%s/thread/%s",
-			p.UserID,
-			p.TeamID,
-			p.ChannelID,
-			p.MessageTS,
-		)
-		if err := rs.sp.PostDM(ctx, p.TeamID, *tenant.ManagerUserID, dmText); err != nil {
-			return fmt.Errorf("CheckEscalate: 上長DM送信失敗: %w", err)
-		}
-	}
-
-	// エスカレート完了フラグ更新
-	if err := rs.mr.MarkEscalated(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID); err != nil {
+		return fmt.Errorf("返信判定失敗: %w", err)
+	}
+	if check.Replied {
+		// すでに返信済み（イベント取りこぼし分を記録）
+		repliedAt := slackTSUnix(check.ReplyTS)
+		if repliedAt == 0 {
+			repliedAt = time.Now().Unix()
+		}
+		if err := rs.mr.MarkReplied(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID, repliedAt); err != nil {
+			if err == domain.ErrMentionNotFound {
+				return nil
+			}
+			return fmt.Errorf("返信状態更新失敗: %w", err)
+		}
+		metrics.ReplyDetected(p.TeamID, "check")
+		slog.InfoContext(ctx, "返信を検知しました", p.logAttrs("source", "check")...)
+		return nil
+	}
+	if !check.Complete {
+		// スレッドが長く最後まで確認できなかった場合は、誤ったリマインド・上長へのエスカレーションを避けるため通知しない
+		// 返信は message イベント（OnMessage）で引き続き検知される
+		slog.WarnContext(ctx, "スレッドを最後まで確認できないため通知を見送りました", p.logAttrs()...)
+		return nil
+	}
+
+	// ステップの通知を実行
+	notified, err := rs.executeStep(ctx, p, m, tenant, step)
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "ステップを実行しました", p.logAttrs("action", string(step.Action), "notified", notified)...)
+	case isPermanentSlackError(err):
+		// 投稿先がない・書き込みのサーバーエラー（投稿済みか不明）などは再試行しても解決しない（再試行すると重複投稿になり得る）ため、
+		// このステップは通知なしで完了とし、以降のステップを続ける
+		slog.WarnContext(ctx, "ステップの通知に失敗したため通知なしで完了とします", p.logAttrs("action", string(step.Action), "error", err)...)
+		notified = false
+	default:
+		return fmt.Errorf("ステップ%d (%s) 実行失敗: %w", p.Step+1, step.Action, err)
+	}
+
+	// 次のステップ（最後のステップの後は期限切れ判定）を予約（メンション発生時刻からの経過時間で計算）
+	// ステップ完了の記録より先に予約し、予約に失敗した場合はエラーを返してこのタスクを再試行させる
+	// （完了を先に記録すると、再試行が「実行済み」としてスキップされ以降のステップが予約されなくなる。同じタスクの重複予約はタスク名で排除される）
+	next := p.Step + 1
+	if runAt, ok := rs.nextRunAt(tenant, policy, m, next); ok {
+		if now := time.Now(); runAt.Before(now) {
+			runAt = now
+		}
+		nextPayload := *p
+		nextPayload.Step = next
+		if err := rs.tp.EnqueueEscalate(ctx, runAt.Unix(), &nextPayload); err != nil {
+			return fmt.Errorf("ステップ%dのタスク登録失敗: %w", next+1, err)
+		}
+	}
+
+	// ステップ完了を記録（通知を送らなかった場合はリマインド・エスカレーションの実行日時を記録しない）
+	// 次のステップが先に実行されて完了を記録済みの場合も、ストア側で巻き戻さない
+	if err := rs.mr.MarkStepDone(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID, p.Step, step.Action, notified, time.Now().Unix()); err != nil {
 		if err == domain.ErrMentionNotFound {
 			// 既に削除されているため無視
 			return nil
 		}
-		return fmt.Errorf("CheckEscalate: エスカレートフラグ更新失敗: %w", err)
+		return fmt.Errorf("ステップ完了状態更新失敗: %w", err)
 	}
 
 	return nil
 }
 
+// isPermanentSlackError は再試行しても成功しない Slack API のエラーかどうかを返します
+func isPermanentSlackError(err error) bool {
+	return errors.Is(err, domain.ErrSlackAPIFailed) && !errors.Is(err, domain.ErrTemporary)
+}
+
+// Acknowledge は対象者の「確認しました」操作により監視を終了します
+func (rs *reminderService) Acknowledge(ctx context.Context, ref ReminderRef, actorUserID string, nowUnix int64) error {
+	if _, err := rs.findActionable(ctx, ref, actorUserID); err != nil {
+		return fmt.Errorf("Acknowledge: %w", err)
+	}
+
+	if err := rs.mr.Resolve(ctx, ref.TeamID, ref.ChannelID, ref.MessageTS, ref.UserID, domain.MentionStatusAcknowledged, nowUnix); err != nil {
+		return fmt.Errorf("Acknowledge: 状態更新失敗: %w", err)
+	}
+
+	return nil
+}
+
+// Snooze は対象者のスヌーズ操作により通知を保留し、保留期限に現在のステップを再予約します
+func (rs *reminderService) Snooze(ctx context.Context, ref ReminderRef, actorUserID string, d time.Duration, nowUnix int64) (int64, error) {
+	m, err := rs.findActionable(ctx, ref, actorUserID)
+	if err != nil {
+		return 0, fmt.Errorf("Snooze: %w", err)
+	}
+
+	tenant, err := rs.getTenant(ctx, ref.TeamID)
+	if err != nil {
+		return 0, fmt.Errorf("Snooze: %w", err)
+	}
+	until := stepRunAt(tenant, nowUnix, d).Unix()
+
+	if err := rs.mr.Snooze(ctx, ref.TeamID, ref.ChannelID, ref.MessageTS, ref.UserID, until); err != nil {
+		return 0, fmt.Errorf("Snooze: 状態更新失敗: %w", err)
+	}
+
+	// 未実行の次のステップを保留期限に予約（元の予約はスヌーズ中のためスキップされる）
+	// ステップ本来の実行予定より前には予約しない（短いスヌーズでエスカレーションが早まらないようにする）
+	runAt := until
+	if scheduled, ok := rs.nextRunAt(tenant, rs.policyFor(tenant), m, m.Step); ok {
+		runAt = max(runAt, scheduled.Unix())
+	}
+	if err := rs.enqueueStep(ctx, m, runAt); err != nil {
+		return 0, fmt.Errorf("Snooze: タスク登録失敗: %w", err)
+	}
+
+	return until, nil
+}
+
+// enqueueStep はメンションの未実行のステップ（m.Step）を runAt（Unix秒）に予約します
+func (rs *reminderService) enqueueStep(ctx context.Context, m *domain.Mention, runAt int64) error {
+	payload := &TaskPayload{
+		TeamID:        m.TeamID,
+		ChannelID:     m.ChannelID,
+		MessageTS:     m.MessageTS,
+		UserID:        m.MentionedUserID,
+		ParentUserID:  m.ParentUserID,
+		Step:          m.Step,
+		CorrelationID: logging.CorrelationID(ctx),
+	}
+	return rs.enqueuePayload(ctx, payload, runAt)
+}
+
+// enqueuePayload はタスクを runAt（Unix秒）に予約します（最初のステップはリマインド、以降はエスカレーションのキュー）
+func (rs *reminderService) enqueuePayload(ctx context.Context, p *TaskPayload, runAt int64) error {
+	if p.Step == 0 {
+		return rs.tp.EnqueueRemind(ctx, runAt, p)
+	}
+	return rs.tp.EnqueueEscalate(ctx, runAt, p)
+}
+
+// Decline は対象者の「担当外です」操作により監視を終了し、メンション送信者にスレッドで通知します
+func (rs *reminderService) Decline(ctx context.Context, ref ReminderRef, actorUserID string, nowUnix int64) error {
+	m, err := rs.findActionable(ctx, ref, actorUserID)
+	if err != nil {
+		return fmt.Errorf("Decline: %w", err)
+	}
+
+	if err := rs.mr.Resolve(ctx, ref.TeamID, ref.ChannelID, ref.MessageTS, ref.UserID, domain.MentionStatusDeclined, nowUnix); err != nil {
+		return fmt.Errorf("Decline: 状態更新失敗: %w", err)
+	}
+
+	text := fmt.Sprintf("<@%s> さんから「担当外」との回答がありました。別の方への依頼をご検討ください🙏", m.MentionedUserID)
+	if m.ParentUserID != "" {
+		text = fmt.Sprintf("<@%s> ", m.ParentUserID) + text
+	}
+	if err := rs.sp.PostThreadMessage(ctx, m.TeamID, m.ChannelID, m.ThreadRootTS(), text); err != nil {
+		return fmt.Errorf("Decline: 送信者への通知失敗: %w", err)
+	}
+
+	return nil
+}
+
+// findActionable はリマインド操作の対象メンションを取得し、操作可能か検証します
+// 対象者本人以外の操作は domain.ErrInsufficientPermission、監視終了済みは domain.ErrInvalidMentionState を返します
+func (rs *reminderService) findActionable(ctx context.Context, ref ReminderRef, actorUserID string) (*domain.Mention, error) {
+	if actorUserID != ref.UserID {
+		return nil, domain.ErrInsufficientPermission
+	}
+
+	m, err := rs.mr.Find(ctx, ref.TeamID, ref.ChannelID, ref.MessageTS, ref.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrMentionNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("メンション取得失敗: %w", err)
+	}
+
+	if !m.IsOpen() {
+		return nil, domain.ErrInvalidMentionState
+	}
+
+	return m, nil
+}
+
+// executeStep はステップのアクションに応じて通知を送信し、通知を1件でも送信したかどうかを返します
+// 上長DMで送信先がない・確認中の場合など、何も送らなかったときは false を返します
+func (rs *reminderService) executeStep(ctx context.Context, p *TaskPayload, m *domain.Mention, tenant *domain.Tenant, step domain.EscalationStep) (bool, error) {
+	threadTS := m.ThreadRootTS()
+	text := renderStepText(step.MessageTemplate(), p, threadTS)
+
+	ref := ReminderRef{TeamID: p.TeamID, ChannelID: p.ChannelID, MessageTS: p.MessageTS, UserID: p.UserID}
+
+	switch step.Action {
+	case domain.EscalationActionThreadReminder:
+		if err := rs.sp.PostThreadReminder(ctx, p.TeamID, p.ChannelID, threadTS, text, ref); err != nil {
+			return false, err
+		}
+		metrics.ReminderSent(p.TeamID, string(step.Action))
+		return true, nil
+
+	case domain.EscalationActionDMMentionee:
+		if err := rs.sp.PostDMReminder(ctx, p.TeamID, p.UserID, text, ref); err != nil {
+			return false, err
+		}
+		metrics.ReminderSent(p.TeamID, string(step.Action))
+		return true, nil
+
+	case domain.EscalationActionDMManager:
+		if tenant == nil {
+			// テナント未設定のため上長DMはスキップ（エラーにしない）
+			return false, nil
+		}
+		if m.IsAcknowledged() {
+			// 対象者・送信者が「確認中」のリアクションをつけているため上長DMは送らない
+			return false, nil
+		}
+		// メンバー・チャンネルごとのルール → ワークスペース全体の上長 の順に送信先を解決
+		// 送信先がなければスキップ（エラーにしない）
+		// 1人への送信失敗で他の上長への送信を止めないよう、送信先ごとに独立して送信する
+		sent := false
+		var errs []error
+		for _, managerUserID := range tenant.EscalationTargets(p.ChannelID, p.UserID) {
+			if err := rs.sp.PostDM(ctx, p.TeamID, managerUserID, text); err != nil {
+				errs = append(errs, fmt.Errorf("上長DM送信失敗 (manager=%s): %w", managerUserID, err))
+				continue
+			}
+			metrics.EscalationSent(p.TeamID, string(step.Action))
+			sent = true
+		}
+		if len(errs) == 0 {
+			return sent, nil
+		}
+		if sent {
+			// 一部の上長には送信済みのため、再試行で重複してDMしないようステップは完了とし、失敗した送信先は記録のみ行う
+			slog.WarnContext(ctx, "一部の上長へのDM送信に失敗しました", p.logAttrs("error", errors.Join(errs...))...)
+			return true, nil
+		}
+		// 誰にも送信できなかった場合はエラーを返し、一時的な障害であればタスクの再試行で送り直す
+		return false, errors.Join(errs...)
+
+	case domain.EscalationActionChannelPost:
+		if err := rs.sp.PostChannelMessage(ctx, p.TeamID, p.ChannelID, text); err != nil {
+			return false, err
+		}
+		metrics.EscalationSent(p.TeamID, string(step.Action))
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("%w: 不明なアクション: %s", domain.ErrInvalid, step.Action)
+	}
+}
+
+// ListMine は userID に関係する監視中のメンションを一覧します
+func (rs *reminderService) ListMine(ctx context.Context, teamID, userID string, nowUnix int64) (*MyMentions, error) {
+	tenant, err := rs.getTenant(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("ListMine: %w", err)
+	}
+	policy := rs.policyFor(tenant)
+
+	owed, err := rs.mr.ListOpenByMentionee(ctx, teamID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListMine: 対象メンション取得失敗: %w", err)
+	}
+	waiting, err := rs.mr.ListOpenByMentioner(ctx, teamID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ListMine: 送信メンション取得失敗: %w", err)
+	}
+
+	return &MyMentions{
+		Owed:    summarizeMentions(tenant, policy, owed, nowUnix),
+		Waiting: summarizeMentions(tenant, policy, waiting, nowUnix),
+	}, nil
+}
+
+// pendingPageSize は ListPending の1ページあたりの件数です
+const pendingPageSize = 20
+
+// ListPending はワークスペースの監視中のメンションをページ単位で一覧します
+func (rs *reminderService) ListPending(ctx context.Context, teamID, viewerUserID string, q PendingQuery, nowUnix int64) (*PendingPage, error) {
+	tenant, err := rs.getTenant(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("ListPending: %w", err)
+	}
+
+	if err := authorizeViewer(ctx, rs.sp, tenant, teamID, viewerUserID); err != nil {
+		return nil, fmt.Errorf("ListPending: %w", err)
+	}
+
+	page := max(q.Page, 1)
+	// 次ページの有無を判定するため1件多く取得する
+	mentions, err := rs.mr.ListOpen(ctx, teamID, domain.OpenMentionQuery{
+		ChannelID:       q.ChannelID,
+		MentionedUserID: q.UserID,
+		Offset:          (page - 1) * pendingPageSize,
+		Limit:           pendingPageSize + 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ListPending: メンション一覧取得失敗: %w", err)
+	}
+
+	hasNext := len(mentions) > pendingPageSize
+	if hasNext {
+		mentions = mentions[:pendingPageSize]
+	}
+
+	return &PendingPage{
+		Mentions: summarizeMentions(tenant, rs.policyFor(tenant), mentions, nowUnix),
+		Page:     page,
+		HasNext:  hasNext,
+	}, nil
+}
+
+// authorizeViewer はワークスペース全体のメンション一覧・集計を閲覧できるユーザーか確認します
+// 上長に設定されているユーザーは Slack API を呼ばずに許可し、それ以外はワークスペースの管理者・オーナーか確認します
+func authorizeViewer(ctx context.Context, sp SlackPort, tenant *domain.Tenant, teamID, userID string) error {
+	if tenant != nil && tenant.IsManager(userID) {
+		return nil
+	}
+
+	admin, err := sp.IsWorkspaceAdmin(ctx, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("管理者確認失敗: %w", err)
+	}
+	if !admin {
+		return fmt.Errorf("%w: 管理者・上長のみ閲覧できます (user=%s)", domain.ErrInsufficientPermission, userID)
+	}
+	return nil
+}
+
+// summarizeMentions はメンションを古い順に並べ、次の通知予定を付けて返します
+func summarizeMentions(tenant *domain.Tenant, policy domain.EscalationPolicy, mentions []*domain.Mention, nowUnix int64) []MentionSummary {
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].CreatedAt != mentions[j].CreatedAt {
+			return mentions[i].CreatedAt < mentions[j].CreatedAt
+		}
+		return mentions[i].MentionedUserID < mentions[j].MentionedUserID
+	})
+
+	summaries := make([]MentionSummary, 0, len(mentions))
+	for _, m := range mentions {
+		summary := MentionSummary{
+			Mention: m,
+			URL:     threadURL(m.TeamID, m.ChannelID, m.ThreadRootTS()),
+		}
+		if m.Step < len(policy.Steps) {
+			runAt := scheduledStepRunAt(tenant, policy, m, m.Step)
+			if m.IsSnoozed(nowUnix) && m.SnoozedUntil > runAt.Unix() {
+				// スヌーズ中のステップはスヌーズ明けに実行される
+				runAt = time.Unix(m.SnoozedUntil, 0)
+			}
+			summary.NextStepAt = runAt.Unix()
+			summary.NextAction = policy.Steps[m.Step].Action
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// policyFor はテナントのエスカレーション手順を返します（未設定ならデフォルト手順）
+func (rs *reminderService) policyFor(tenant *domain.Tenant) domain.EscalationPolicy {
+	if tenant != nil && tenant.EscalationPolicy != nil && len(tenant.EscalationPolicy.Steps) > 0 {
+		return *tenant.EscalationPolicy
+	}
+	return domain.DefaultEscalationPolicy(rs.cfg.RemindDuration, rs.cfg.EscalateDuration)
+}
+
+// stepRunAt はメンション発生時刻から delay 経過後のステップ実行時刻を返します
+// テナントに稼働カレンダーが設定されていれば稼働時間のみを数えます
+func stepRunAt(tenant *domain.Tenant, createdAt int64, delay time.Duration) time.Time {
+	start := time.Unix(createdAt, 0)
+	if tenant == nil || tenant.WorkingCalendar == nil {
+		return start.Add(delay)
+	}
+	return tenant.WorkingCalendar.AddBusinessDuration(start, delay)
+}
+
+// expire は全ステップの実行後に呼ばれ、返信がないまま期限（メンションから ExpireDuration）を過ぎていれば
+// 期限切れとして監視を終了します。期限前であれば期限に判定を予約し直します
+func (rs *reminderService) expire(ctx context.Context, p *TaskPayload, m *domain.Mention, tenant *domain.Tenant) error {
+	if rs.cfg.ExpireDuration <= 0 {
+		// 期限切れ判定は無効
+		return nil
+	}
+
+	now := time.Now()
+	expireAt := time.Unix(m.CreatedAt, 0).Add(rs.cfg.ExpireDuration)
+	if now.Before(expireAt) {
+		if err := rs.tp.EnqueueEscalate(ctx, expireAt.Unix(), p); err != nil {
+			return fmt.Errorf("期限切れ判定のタスク登録失敗: %w", err)
+		}
+		return nil
+	}
+
+	// 取りこぼした返信を記録してから期限切れにする（確認しきれない場合も通知はしないため期限切れとする）
+	check, err := replyCheckerFor(tenant, p.ChannelID).Check(ctx, rs.sp, m)
+	if err != nil {
+		return fmt.Errorf("返信判定失敗: %w", err)
+	}
+	if check.Replied {
+		repliedAt := slackTSUnix(check.ReplyTS)
+		if repliedAt == 0 {
+			repliedAt = now.Unix()
+		}
+		if err := rs.mr.MarkReplied(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID, repliedAt); err != nil {
+			if err == domain.ErrMentionNotFound {
+				return nil
+			}
+			return fmt.Errorf("返信状態更新失敗: %w", err)
+		}
+		metrics.ReplyDetected(p.TeamID, "check")
+		slog.InfoContext(ctx, "返信を検知しました", p.logAttrs("source", "check")...)
+		return nil
+	}
+
+	if err := rs.mr.Resolve(ctx, p.TeamID, p.ChannelID, p.MessageTS, p.UserID, domain.MentionStatusExpired, now.Unix()); err != nil {
+		if err == domain.ErrMentionNotFound {
+			return nil
+		}
+		return fmt.Errorf("期限切れ状態更新失敗: %w", err)
+	}
+	slog.InfoContext(ctx, "返信がないまま期限切れになりました", p.logAttrs()...)
+	return nil
+}
+
+// nextRunAt は step 番目のステップの実行予定時刻を返します
+// 全ステップの実行後（step が手順の長さ以上）は期限切れ判定の時刻を返し、期限切れ判定が無効なら false を返します
+func (rs *reminderService) nextRunAt(tenant *domain.Tenant, policy domain.EscalationPolicy, m *domain.Mention, step int) (time.Time, bool) {
+	if step < len(policy.Steps) {
+		return scheduledStepRunAt(tenant, policy, m, step), true
+	}
+	if rs.cfg.ExpireDuration <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(m.CreatedAt, 0).Add(rs.cfg.ExpireDuration), true
+}
+
+// scheduledStepRunAt は step 番目のステップの実行予定時刻を返します
+// スヌーズ後は、スヌーズ明けから本来のステップ間隔（前のステップとの差）を空けて実行します
+func scheduledStepRunAt(tenant *domain.Tenant, policy domain.EscalationPolicy, m *domain.Mention, step int) time.Time {
+	runAt := stepRunAt(tenant, m.CreatedAt, policy.Steps[step].Delay())
+	if m.SnoozedUntil > 0 && step > 0 {
+		gap := policy.Steps[step].Delay() - policy.Steps[step-1].Delay()
+		if resumed := stepRunAt(tenant, m.SnoozedUntil, gap); resumed.After(runAt) {
+			runAt = resumed
+		}
+	}
+	return runAt
+}
+
+// retainUntil は createdAt に作成したメンション記録の保持期限（Unix秒）を返します。保持期間が無効（0）の場合は0
+func (rs *reminderService) retainUntil(tenant *domain.Tenant, createdAt int64) int64 {
+	period := rs.cfg.RetentionPeriod
+	if tenant != nil {
+		period = tenant.RetentionPeriod(period)
+	}
+	if period <= 0 {
+		return 0
+	}
+	return createdAt + int64(period/time.Second)
+}
+
+// getTenant はテナント設定を取得します。未登録の場合は (nil, nil) を返します
+func (rs *reminderService) getTenant(ctx context.Context, teamID string) (*domain.Tenant, error) {
+	return findTenant(ctx, rs.tr, teamID)
+}
+
+// findTenant はテナント設定を取得します。未登録の場合は (nil, nil) を返します
+func findTenant(ctx context.Context, tr domain.TenantRepository, teamID string) (*domain.Tenant, error) {
+	tenant, err := tr.Get(ctx, teamID)
+	if err != nil {
+		if errors.Is(err, domain.ErrTenantNotRegistered) || errors.Is(err, domain.ErrNotFound) {
+			// テナント未設定のためデフォルト動作（エラーにしない）
+			return nil, nil
+		}
+		return nil, fmt.Errorf("テナント取得失敗: %w", err)
+	}
+	return tenant, nil
+}
+
+// renderStepText は通知文面のプレースホルダを置換します
+func renderStepText(template string, p *TaskPayload, threadTS string) string {
+	return strings.NewReplacer(
+		"{mentionee}", fmt.Sprintf("<@%s>", p.UserID),
+		"{mentioner}", fmt.Sprintf("<@%s>", p.ParentUserID),
+		"{thread_url}", threadURL(p.TeamID, p.ChannelID, threadTS),
+	).Replace(template)
+}
+
+// threadURL は対象スレッドの URL を生成します
+func threadURL(teamID, channelID, messageTS string) string {
+	return fmt.Sprintf("https://app.slack.com/client/%s/%s/thread/%s", teamID, channelID, messageTS)
+}
+
+// slackTSUnix は Slack のメッセージタイムスタンプ（"秒.マイクロ秒"）を Unix秒に変換します
+// 形式が不正な場合は0を返します
+func slackTSUnix(ts string) int64 {
+	sec, _, _ := strings.Cut(ts, ".")
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// slackTSAfter は Slack のメッセージタイムスタンプ a が b より後かどうかを返します
+func slackTSAfter(a, b string) bool {
+	aSec, aFrac, _ := strings.Cut(a, ".")
+	bSec, bFrac, _ := strings.Cut(b, ".")
+	if len(aSec) != len(bSec) {
+		return len(aSec) > len(bSec)
+	}
+	if aSec != bSec {
+		return aSec > bSec
+	}
+	return aFrac > bFrac
+}
+
+// isReplyToParent は返信テキストがメンション送信元への返信条件を満たすか判定します
+// 送信元が不明な旧レコードの場合は、対象者のスレッド投稿であれば返信とみなします
+func isReplyToParent(text, parentUserID string) bool {
+	if parentUserID == "" {
+		return true
+	}
+	return strings.Contains(text, fmt.Sprintf("<@%s>", parentUserID))
+}
+
 // parseMentionedUserIDs はテキストからSlackメンション（<@USERID>形式）を抽出し、
 // BotUserIDを除外したユーザーID一覧を返します
 func parseMentionedUserIDs(text, botUserID string) []string {
@@ -0,0 +1,824 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/store/memory"
+)
+
+const (
+	testTeamID    = "T1"
+	testChannelID = "C1"
+	testMessageTS = "1700000000.000100"
+	testUserID    = "U1"
+	testParentID  = "U0"
+)
+
+// fakeSlack は送信したメッセージを記録する SlackPort のテスト用実装です
+// 返信確認は常に「未返信（最後まで確認済み）」を返します
+type fakeSlack struct {
+	threadPosts []string
+	dms         []string         // DM を送信できたユーザーID
+	failDM      map[string]error // ユーザーIDごとの PostDM のエラー
+	failThread  error            // PostThreadReminder のエラー
+}
+
+func (s *fakeSlack) HasUserRepliedWithMention(ctx context.Context, teamID, channelID, threadTS, messageTS, userID, parentUserID string) (ReplyCheck, error) {
+	return ReplyCheck{PagesScanned: 1, Complete: true}, nil
+}
+
+func (s *fakeSlack) HasUserPostedInChannel(ctx context.Context, teamID, channelID, oldest, userID string) (ReplyCheck, error) {
+	return ReplyCheck{PagesScanned: 1, Complete: true}, nil
+}
+
+func (s *fakeSlack) HasUserReacted(ctx context.Context, teamID, channelID, messageTS, userID, reaction string) (ReplyCheck, error) {
+	return ReplyCheck{PagesScanned: 1, Complete: true}, nil
+}
+
+func (s *fakeSlack) PostThreadMessage(ctx context.Context, teamID, channelID, messageTS, text string) error {
+	s.threadPosts = append(s.threadPosts, text)
+	return nil
+}
+
+func (s *fakeSlack) PostThreadReminder(ctx context.Context, teamID, channelID, messageTS, text string, ref ReminderRef) error {
+	if s.failThread != nil {
+		return s.failThread
+	}
+	s.threadPosts = append(s.threadPosts, text)
+	return nil
+}
+
+func (s *fakeSlack) PostDMReminder(ctx context.Context, teamID, userID, text string, ref ReminderRef) error {
+	s.dms = append(s.dms, userID)
+	return nil
+}
+
+func (s *fakeSlack) PostDM(ctx context.Context, teamID, userID, text string) error {
+	if err := s.failDM[userID]; err != nil {
+		return err
+	}
+	s.dms = append(s.dms, userID)
+	return nil
+}
+
+func (s *fakeSlack) PostChannelMessage(ctx context.Context, teamID, channelID, text string) error {
+	return nil
+}
+
+func (s *fakeSlack) IsWorkspaceAdmin(ctx context.Context, teamID, userID string) (bool, error) {
+	return false, nil
+}
+
+// enqueued は予約されたタスクです
+type enqueued struct {
+	kind  string // "remind" / "escalate"
+	runAt int64
+	step  int
+}
+
+// fakeTasks は予約したタスクを記録する TaskPort のテスト用実装です
+type fakeTasks struct {
+	tasks []enqueued
+	err   error // EnqueueRemind・EnqueueEscalate のエラー
+}
+
+func (t *fakeTasks) EnqueueRemind(ctx context.Context, runAt int64, payload *TaskPayload) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.tasks = append(t.tasks, enqueued{kind: "remind", runAt: runAt, step: payload.Step})
+	return nil
+}
+
+func (t *fakeTasks) EnqueueEscalate(ctx context.Context, runAt int64, payload *TaskPayload) error {
+	if t.err != nil {
+		return t.err
+	}
+	t.tasks = append(t.tasks, enqueued{kind: "escalate", runAt: runAt, step: payload.Step})
+	return nil
+}
+
+func (t *fakeTasks) EnqueueEvent(ctx context.Context, task *EventTask) error {
+	return nil
+}
+
+// newTestReminderService はデフォルトのエスカレーション手順（10分後リマインド・30分後再リマインドと上長DM・1週間で期限切れ）のサービスを作成します
+func newTestReminderService(repo *memory.Repo, sp *fakeSlack, tp *fakeTasks) *reminderService {
+	cfg := &config.Config{
+		RemindDuration:   10 * time.Minute,
+		EscalateDuration: 30 * time.Minute,
+		ExpireDuration:   7 * 24 * time.Hour,
+	}
+	return &reminderService{cfg: cfg, mr: repo, tr: repo, sp: sp, tp: tp}
+}
+
+// saveTestMention は createdAt に作成された、step 番目のステップが未実行のメンションを保存します
+func saveTestMention(t *testing.T, repo *memory.Repo, createdAt int64, step int) {
+	t.Helper()
+	m := &domain.Mention{
+		TeamID:          testTeamID,
+		ChannelID:       testChannelID,
+		MessageTS:       testMessageTS,
+		MentionedUserID: testUserID,
+		ParentUserID:    testParentID,
+		CreatedAt:       createdAt,
+		Step:            step,
+		Status:          domain.MentionStatusOpen,
+	}
+	if err := repo.Save(context.Background(), m); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+}
+
+func findTestMention(t *testing.T, repo *memory.Repo) *domain.Mention {
+	t.Helper()
+	m, err := repo.Find(context.Background(), testTeamID, testChannelID, testMessageTS, testUserID)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	return m
+}
+
+func testPayload(step int) *TaskPayload {
+	return &TaskPayload{
+		TeamID:       testTeamID,
+		ChannelID:    testChannelID,
+		MessageTS:    testMessageTS,
+		UserID:       testUserID,
+		ParentUserID: testParentID,
+		Step:         step,
+	}
+}
+
+func TestOnMentionSchedulesFirstStep(t *testing.T) {
+	repo := memory.NewRepo()
+	tp := &fakeTasks{}
+	rs := newTestReminderService(repo, &fakeSlack{}, tp)
+
+	createdAt := int64(1_700_000_000)
+	ev := &MentionEvent{
+		TeamID:       testTeamID,
+		ChannelID:    testChannelID,
+		MessageTS:    testMessageTS,
+		Text:         "<@UBOT> <@U1> 確認お願いします",
+		BotUserID:    "UBOT",
+		ParentUserID: testParentID,
+		NowUnix:      createdAt,
+	}
+	if err := rs.OnMention(context.Background(), ev); err != nil {
+		t.Fatalf("OnMention() error = %v", err)
+	}
+
+	want := []enqueued{{kind: "remind", runAt: createdAt + 600, step: 0}}
+	if !slices.Equal(tp.tasks, want) {
+		t.Errorf("tasks = %+v, want %+v", tp.tasks, want)
+	}
+	if m := findTestMention(t, repo); m.Step != 0 || !m.IsOpen() {
+		t.Errorf("mention = step %d status %s, want step 0 open", m.Step, m.Status)
+	}
+}
+
+func TestOnMentionResavePreservesProgress(t *testing.T) {
+	repo := memory.NewRepo()
+	sp := &fakeSlack{}
+	rs := newTestReminderService(repo, sp, &fakeTasks{})
+	ctx := context.Background()
+
+	createdAt := time.Now().Add(-11 * time.Minute).Unix()
+	ev := &MentionEvent{
+		TeamID:       testTeamID,
+		ChannelID:    testChannelID,
+		MessageTS:    testMessageTS,
+		Text:         "<@U1>",
+		ParentUserID: testParentID,
+		NowUnix:      createdAt,
+	}
+	if err := rs.OnMention(ctx, ev); err != nil {
+		t.Fatalf("OnMention() error = %v", err)
+	}
+	if err := rs.CheckRemind(ctx, testPayload(0)); err != nil {
+		t.Fatalf("CheckRemind() error = %v", err)
+	}
+	reminded := findTestMention(t, repo)
+
+	// 編集などで同じメンションが再度保存されても、実行済みのステップ・リマインド日時は失われない
+	if err := rs.OnMention(ctx, ev); err != nil {
+		t.Fatalf("OnMention() (resave) error = %v", err)
+	}
+	m := findTestMention(t, repo)
+	if m.Step != 1 || m.RemindedAt != reminded.RemindedAt || m.RemindedAt == 0 {
+		t.Errorf("after resave step = %d reminded_at = %d, want step 1 reminded_at %d", m.Step, m.RemindedAt, reminded.RemindedAt)
+	}
+}
+
+func TestOnMessageThreadReply(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      domain.ReplyPolicy // C1 の返信判定ルール（空の場合は未設定＝デフォルト）
+		threadTS    string
+		messageTS   string
+		userID      string
+		text        string
+		wantReplied bool
+	}{
+		{
+			name:        "メンション送信者へのメンション付きのスレッド返信で返信完了",
+			threadTS:    testMessageTS,
+			messageTS:   "1700000100.000100",
+			userID:      testUserID,
+			text:        "<@U0> 確認しました",
+			wantReplied: true,
+		},
+		{
+			name:      "デフォルトのルールではメンションのない返信は返信としない",
+			threadTS:  testMessageTS,
+			messageTS: "1700000100.000100",
+			userID:    testUserID,
+			text:      "確認しました",
+		},
+		{
+			name:        "thread_reply ルールではメンションのない返信でも返信完了",
+			policy:      domain.ReplyPolicyThreadReply,
+			threadTS:    testMessageTS,
+			messageTS:   "1700000100.000100",
+			userID:      testUserID,
+			text:        "確認しました",
+			wantReplied: true,
+		},
+		{
+			name:      "対象者以外の返信は返信としない",
+			threadTS:  testMessageTS,
+			messageTS: "1700000100.000100",
+			userID:    "U2",
+			text:      "<@U0> 代わりに確認しました",
+		},
+		{
+			name:      "別のスレッドへの返信は返信としない",
+			threadTS:  "1700000050.000100",
+			messageTS: "1700000100.000100",
+			userID:    testUserID,
+			text:      "<@U0> 確認しました",
+		},
+		{
+			name:      "reaction ルールではスレッド返信を返信としない",
+			policy:    domain.ReplyPolicyReaction,
+			threadTS:  testMessageTS,
+			messageTS: "1700000100.000100",
+			userID:    testUserID,
+			text:      "<@U0> 確認しました",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewRepo()
+			rs := newTestReminderService(repo, &fakeSlack{}, &fakeTasks{})
+			saveTestMention(t, repo, 1_700_000_000, 0)
+
+			if tt.policy != "" {
+				if err := repo.UpsertBotTokenSecret(ctx, testTeamID, "slack_token_T1"); err != nil {
+					t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+				}
+				if err := repo.SetChannelReplyRule(ctx, testTeamID, testChannelID, &domain.ReplyRule{Policy: tt.policy}); err != nil {
+					t.Fatalf("SetChannelReplyRule() error = %v", err)
+				}
+			}
+
+			ev := &MessageEvent{
+				TeamID:    testTeamID,
+				ChannelID: testChannelID,
+				MessageTS: tt.messageTS,
+				ThreadTS:  tt.threadTS,
+				UserID:    tt.userID,
+				Text:      tt.text,
+				NowUnix:   1_700_000_100,
+			}
+			if err := rs.OnMessage(ctx, ev); err != nil {
+				t.Fatalf("OnMessage() error = %v", err)
+			}
+
+			m := findTestMention(t, repo)
+			if replied := m.Status == domain.MentionStatusReplied; replied != tt.wantReplied {
+				t.Errorf("Status = %s, want replied = %v", m.Status, tt.wantReplied)
+			}
+			if tt.wantReplied && m.RepliedAt != ev.NowUnix {
+				t.Errorf("RepliedAt = %d, want %d", m.RepliedAt, ev.NowUnix)
+			}
+		})
+	}
+}
+
+func TestRunStepScheduling(t *testing.T) {
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name         string
+		createdAt    int64
+		mentionStep  int
+		payloadStep  int
+		postErr      error
+		enqueueErr   error
+		wantErr      bool
+		wantPosts    int
+		wantStep     int
+		wantReminded bool
+		wantTasks    func(createdAt int64) []enqueued
+	}{
+		{
+			name:        "予定より早いタスクは予定時刻に再予約する",
+			createdAt:   now - 5*60,
+			payloadStep: 0,
+			wantStep:    0,
+			wantTasks: func(createdAt int64) []enqueued {
+				return []enqueued{{kind: "remind", runAt: createdAt + 600, step: 0}}
+			},
+		},
+		{
+			name:         "予定時刻を過ぎたリマインドを投稿し次のステップを予約する",
+			createdAt:    now - 11*60,
+			payloadStep:  0,
+			wantPosts:    1,
+			wantStep:     1,
+			wantReminded: true,
+			wantTasks: func(createdAt int64) []enqueued {
+				return []enqueued{{kind: "escalate", runAt: createdAt + 1800, step: 1}}
+			},
+		},
+		{
+			// 完了を記録しないことで、再試行したタスクが次のステップを予約し直せる
+			name:        "次のステップの予約に失敗した場合は完了を記録せずエラーを返す",
+			createdAt:   now - 11*60,
+			payloadStep: 0,
+			enqueueErr:  errors.New("cloudtasks: unavailable"),
+			wantErr:     true,
+			wantPosts:   1,
+			wantStep:    0,
+			wantTasks:   func(int64) []enqueued { return nil },
+		},
+		{
+			// 書き込みの 5xx（投稿済みか不明）などは再試行すると重複投稿になり得るため、通知なしで完了として先に進む
+			name:        "恒久的な Slack API エラーのステップは通知なしで完了とし次のステップを予約する",
+			createdAt:   now - 11*60,
+			payloadStep: 0,
+			postErr:     fmt.Errorf("%w: slack: 書き込みメソッドのサーバーエラー", domain.ErrSlackAPIFailed),
+			wantStep:    1,
+			wantTasks: func(createdAt int64) []enqueued {
+				return []enqueued{{kind: "escalate", runAt: createdAt + 1800, step: 1}}
+			},
+		},
+		{
+			name:        "一時的な Slack API エラーは再試行のためエラーを返す",
+			createdAt:   now - 11*60,
+			payloadStep: 0,
+			postErr:     fmt.Errorf("%w: slack: rate limited", domain.ErrTemporary),
+			wantErr:     true,
+			wantStep:    0,
+			wantTasks:   func(int64) []enqueued { return nil },
+		},
+		{
+			name:        "実行済みのステップはスキップする",
+			createdAt:   now - 11*60,
+			mentionStep: 1,
+			payloadStep: 0,
+			wantStep:    1,
+			wantTasks:   func(int64) []enqueued { return nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewRepo()
+			sp := &fakeSlack{failThread: tt.postErr}
+			tp := &fakeTasks{err: tt.enqueueErr}
+			rs := newTestReminderService(repo, sp, tp)
+			saveTestMention(t, repo, tt.createdAt, tt.mentionStep)
+
+			err := rs.CheckRemind(context.Background(), testPayload(tt.payloadStep))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckRemind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(sp.threadPosts) != tt.wantPosts {
+				t.Errorf("thread posts = %d, want %d", len(sp.threadPosts), tt.wantPosts)
+			}
+			m := findTestMention(t, repo)
+			if m.Step != tt.wantStep {
+				t.Errorf("Step = %d, want %d", m.Step, tt.wantStep)
+			}
+			if (m.RemindedAt != 0) != tt.wantReminded {
+				t.Errorf("RemindedAt = %d, want set = %v", m.RemindedAt, tt.wantReminded)
+			}
+			if want := tt.wantTasks(tt.createdAt); !slices.Equal(tp.tasks, want) {
+				t.Errorf("tasks = %+v, want %+v", tp.tasks, want)
+			}
+		})
+	}
+}
+
+func TestCheckEscalateLegacyTask(t *testing.T) {
+	repo := memory.NewRepo()
+	sp := &fakeSlack{}
+	tp := &fakeTasks{}
+	rs := newTestReminderService(repo, sp, tp)
+
+	// 旧形式のリマインド済みメンション（読み取り時に次のステップ1へ読み替え済み）
+	saveTestMention(t, repo, time.Now().Add(-31*time.Minute).Unix(), 1)
+
+	// ステップを持たない旧形式のエスカレーションタスクは、最初のリマインドをやり直さず2番目のステップを実行する
+	if err := rs.CheckEscalate(context.Background(), testPayload(0)); err != nil {
+		t.Fatalf("CheckEscalate() error = %v", err)
+	}
+
+	if len(sp.threadPosts) != 1 {
+		t.Errorf("thread posts = %d, want 1", len(sp.threadPosts))
+	}
+	if m := findTestMention(t, repo); m.Step != 2 {
+		t.Errorf("Step = %d, want 2", m.Step)
+	}
+	if len(tp.tasks) != 1 || tp.tasks[0].kind != "escalate" || tp.tasks[0].step != 2 {
+		t.Errorf("tasks = %+v, want one escalate task for step 2", tp.tasks)
+	}
+}
+
+func TestRunStepManagerDM(t *testing.T) {
+	errSlack := errors.New("slack: channel_not_found")
+
+	tests := []struct {
+		name          string
+		managers      bool             // U1 のメンバー上長 M1・C1 のチャンネル上長 M2 を設定する
+		failDM        map[string]error // 上長ごとの DM 送信エラー
+		wantErr       bool
+		wantDMs       []string
+		wantStep      int
+		wantEscalated bool
+	}{
+		{
+			name:     "テナント未登録は上長DMを送らずステップを完了する",
+			wantStep: 3,
+		},
+		{
+			name:          "全ての上長にDMする",
+			managers:      true,
+			wantDMs:       []string{"M1", "M2"},
+			wantStep:      3,
+			wantEscalated: true,
+		},
+		{
+			name:          "一部の上長への送信失敗でも他の上長に送信してステップを完了する",
+			managers:      true,
+			failDM:        map[string]error{"M1": errSlack},
+			wantDMs:       []string{"M2"},
+			wantStep:      3,
+			wantEscalated: true,
+		},
+		{
+			name:     "全ての上長への送信に失敗した場合は再試行のためエラーを返す",
+			managers: true,
+			failDM:   map[string]error{"M1": errSlack, "M2": errSlack},
+			wantErr:  true,
+			wantStep: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewRepo()
+			sp := &fakeSlack{failDM: tt.failDM}
+			tp := &fakeTasks{}
+			rs := newTestReminderService(repo, sp, tp)
+
+			if tt.managers {
+				if err := repo.UpsertBotTokenSecret(ctx, testTeamID, "slack_token_T1"); err != nil {
+					t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+				}
+				m1, m2 := "M1", "M2"
+				if err := repo.SetUserManager(ctx, testTeamID, testUserID, &m1); err != nil {
+					t.Fatalf("SetUserManager() error = %v", err)
+				}
+				if err := repo.SetChannelManager(ctx, testTeamID, testChannelID, &m2); err != nil {
+					t.Fatalf("SetChannelManager() error = %v", err)
+				}
+			}
+
+			// デフォルト手順の3番目（上長DM）のステップ
+			createdAt := time.Now().Add(-31 * time.Minute).Unix()
+			saveTestMention(t, repo, createdAt, 2)
+
+			err := rs.CheckEscalate(ctx, testPayload(2))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckEscalate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(sp.dms, tt.wantDMs) {
+				t.Errorf("DMs = %v, want %v", sp.dms, tt.wantDMs)
+			}
+			m := findTestMention(t, repo)
+			if m.Step != tt.wantStep {
+				t.Errorf("Step = %d, want %d", m.Step, tt.wantStep)
+			}
+			if (m.EscalatedAt != 0) != tt.wantEscalated {
+				t.Errorf("EscalatedAt = %d, want set = %v", m.EscalatedAt, tt.wantEscalated)
+			}
+
+			// 全ステップの実行後は期限切れ判定（メンションから1週間後）を予約する
+			var want []enqueued
+			if !tt.wantErr {
+				want = []enqueued{{kind: "escalate", runAt: createdAt + 7*24*3600, step: 3}}
+			}
+			if !slices.Equal(tp.tasks, want) {
+				t.Errorf("tasks = %+v, want %+v", tp.tasks, want)
+			}
+		})
+	}
+}
+
+func TestSnoozeScheduling(t *testing.T) {
+	now := time.Now().Unix()
+	createdAt := now - 11*60
+
+	tests := []struct {
+		name      string
+		step      int
+		snooze    time.Duration
+		actor     string
+		wantErr   error
+		wantUntil int64
+		wantTask  enqueued
+	}{
+		{
+			name:      "短いスヌーズでもステップ本来の予定より前には予約しない",
+			step:      1,
+			snooze:    5 * time.Minute,
+			actor:     testUserID,
+			wantUntil: now + 5*60,
+			wantTask:  enqueued{kind: "escalate", runAt: createdAt + 1800, step: 1},
+		},
+		{
+			name:      "予定より後のスヌーズは保留期限に予約する",
+			step:      1,
+			snooze:    time.Hour,
+			actor:     testUserID,
+			wantUntil: now + 3600,
+			wantTask:  enqueued{kind: "escalate", runAt: now + 3600, step: 1},
+		},
+		{
+			name:      "最初のステップはリマインドのキューに予約する",
+			step:      0,
+			snooze:    time.Hour,
+			actor:     testUserID,
+			wantUntil: now + 3600,
+			wantTask:  enqueued{kind: "remind", runAt: now + 3600, step: 0},
+		},
+		{
+			name:    "対象者以外はスヌーズできない",
+			step:    1,
+			snooze:  time.Hour,
+			actor:   testParentID,
+			wantErr: domain.ErrInsufficientPermission,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewRepo()
+			tp := &fakeTasks{}
+			rs := newTestReminderService(repo, &fakeSlack{}, tp)
+			saveTestMention(t, repo, createdAt, tt.step)
+
+			ref := ReminderRef{TeamID: testTeamID, ChannelID: testChannelID, MessageTS: testMessageTS, UserID: testUserID}
+			until, err := rs.Snooze(context.Background(), ref, tt.actor, tt.snooze, now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Snooze() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(tp.tasks) != 0 {
+					t.Errorf("tasks = %+v, want none", tp.tasks)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Snooze() error = %v", err)
+			}
+
+			if until != tt.wantUntil {
+				t.Errorf("until = %d, want %d", until, tt.wantUntil)
+			}
+			if m := findTestMention(t, repo); m.SnoozedUntil != tt.wantUntil {
+				t.Errorf("SnoozedUntil = %d, want %d", m.SnoozedUntil, tt.wantUntil)
+			}
+			if want := []enqueued{tt.wantTask}; !slices.Equal(tp.tasks, want) {
+				t.Errorf("tasks = %+v, want %+v", tp.tasks, want)
+			}
+		})
+	}
+}
+
+func TestOnMessageChannelPost(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      domain.ReplyPolicy // C1 の返信判定ルール（空の場合は未設定＝デフォルト）
+		channelID   string
+		messageTS   string
+		userID      string
+		wantReplied bool
+	}{
+		{
+			name:        "channel ルールではメンション以降のチャンネルへの投稿で返信完了",
+			policy:      domain.ReplyPolicyChannel,
+			channelID:   testChannelID,
+			messageTS:   "1700000100.000100",
+			userID:      testUserID,
+			wantReplied: true,
+		},
+		{
+			name:      "メンションより前の投稿は返信としない",
+			policy:    domain.ReplyPolicyChannel,
+			channelID: testChannelID,
+			messageTS: "1699999900.000100",
+			userID:    testUserID,
+		},
+		{
+			name:      "別のチャンネルへの投稿は返信としない",
+			policy:    domain.ReplyPolicyChannel,
+			channelID: "C2",
+			messageTS: "1700000100.000100",
+			userID:    testUserID,
+		},
+		{
+			name:      "対象者以外の投稿は返信としない",
+			policy:    domain.ReplyPolicyChannel,
+			channelID: testChannelID,
+			messageTS: "1700000100.000100",
+			userID:    testParentID,
+		},
+		{
+			name:      "デフォルトのルールではチャンネルへの投稿を返信としない",
+			channelID: testChannelID,
+			messageTS: "1700000100.000100",
+			userID:    testUserID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewRepo()
+			rs := newTestReminderService(repo, &fakeSlack{}, &fakeTasks{})
+			saveTestMention(t, repo, 1_700_000_000, 1)
+
+			if tt.policy != "" {
+				if err := repo.UpsertBotTokenSecret(ctx, testTeamID, "slack_token_T1"); err != nil {
+					t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+				}
+				if err := repo.SetChannelReplyRule(ctx, testTeamID, testChannelID, &domain.ReplyRule{Policy: tt.policy}); err != nil {
+					t.Fatalf("SetChannelReplyRule() error = %v", err)
+				}
+			}
+
+			ev := &MessageEvent{
+				TeamID:    testTeamID,
+				ChannelID: tt.channelID,
+				MessageTS: tt.messageTS,
+				UserID:    tt.userID,
+				Text:      "確認しました",
+				NowUnix:   1_700_000_100,
+			}
+			if err := rs.OnMessage(ctx, ev); err != nil {
+				t.Fatalf("OnMessage() error = %v", err)
+			}
+
+			m := findTestMention(t, repo)
+			if replied := m.Status == domain.MentionStatusReplied; replied != tt.wantReplied {
+				t.Errorf("Status = %s, want replied = %v", m.Status, tt.wantReplied)
+			}
+		})
+	}
+}
+
+func TestOnReaction(t *testing.T) {
+	type reaction struct {
+		userID   string
+		reaction string
+		removed  bool
+	}
+
+	tests := []struct {
+		name        string
+		policy      domain.ReplyPolicy // C1 の返信判定ルール（空の場合は未設定＝デフォルト）
+		deactivated bool
+		reactions   []reaction
+		wantStatus  domain.MentionStatus
+		wantAck     bool
+		wantTasks   int
+	}{
+		{
+			name:       "対象者の対応済みリアクションで監視を終了",
+			reactions:  []reaction{{testUserID, "white_check_mark", false}},
+			wantStatus: domain.MentionStatusDone,
+		},
+		{
+			name:       "送信者の対応済みリアクションでも監視を終了",
+			reactions:  []reaction{{testParentID, "white_check_mark", false}},
+			wantStatus: domain.MentionStatusDone,
+		},
+		{
+			name:       "対応済みの取り消しで監視を再開し未実行のステップを予約し直す",
+			reactions:  []reaction{{testUserID, "white_check_mark", false}, {testUserID, "white_check_mark", true}},
+			wantStatus: domain.MentionStatusOpen,
+			wantTasks:  1,
+		},
+		{
+			name:       "確認中のリアクションは監視を続けて上長DMを抑止する",
+			reactions:  []reaction{{testUserID, "eyes", false}},
+			wantStatus: domain.MentionStatusOpen,
+			wantAck:    true,
+		},
+		{
+			name:       "確認中の取り消しで上長DMの抑止を解除する",
+			reactions:  []reaction{{testUserID, "eyes", false}, {testUserID, "eyes", true}},
+			wantStatus: domain.MentionStatusOpen,
+		},
+		{
+			name:       "対象者・送信者以外のリアクションは無視する",
+			reactions:  []reaction{{"U2", "white_check_mark", false}},
+			wantStatus: domain.MentionStatusOpen,
+		},
+		{
+			name:       "reaction ルールでは対象者の指定リアクションで返信完了",
+			policy:     domain.ReplyPolicyReaction,
+			reactions:  []reaction{{testUserID, domain.DefaultReplyReaction, false}},
+			wantStatus: domain.MentionStatusReplied,
+		},
+		{
+			name:       "reaction ルールでも送信者のリアクションは返信としない",
+			policy:     domain.ReplyPolicyReaction,
+			reactions:  []reaction{{testParentID, domain.DefaultReplyReaction, false}},
+			wantStatus: domain.MentionStatusOpen,
+		},
+		{
+			name:        "無効なワークスペースではリアクションを無視する",
+			deactivated: true,
+			reactions:   []reaction{{testUserID, "white_check_mark", false}},
+			wantStatus:  domain.MentionStatusOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewRepo()
+			tp := &fakeTasks{}
+			rs := newTestReminderService(repo, &fakeSlack{}, tp)
+			saveTestMention(t, repo, time.Now().Add(-5*time.Minute).Unix(), 0)
+
+			if err := repo.UpsertBotTokenSecret(ctx, testTeamID, "slack_token_T1"); err != nil {
+				t.Fatalf("UpsertBotTokenSecret() error = %v", err)
+			}
+			if tt.policy != "" {
+				if err := repo.SetChannelReplyRule(ctx, testTeamID, testChannelID, &domain.ReplyRule{Policy: tt.policy}); err != nil {
+					t.Fatalf("SetChannelReplyRule() error = %v", err)
+				}
+			}
+			if tt.deactivated {
+				if err := repo.Deactivate(ctx, testTeamID, time.Now().Unix()); err != nil {
+					t.Fatalf("Deactivate() error = %v", err)
+				}
+			}
+
+			for _, r := range tt.reactions {
+				ev := &ReactionEvent{
+					TeamID:    testTeamID,
+					ChannelID: testChannelID,
+					MessageTS: testMessageTS,
+					UserID:    r.userID,
+					Reaction:  r.reaction,
+					Removed:   r.removed,
+					NowUnix:   time.Now().Unix(),
+				}
+				if err := rs.OnReaction(ctx, ev); err != nil {
+					t.Fatalf("OnReaction() error = %v", err)
+				}
+			}
+
+			m := findTestMention(t, repo)
+			if m.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", m.Status, tt.wantStatus)
+			}
+			if m.IsAcknowledged() != tt.wantAck {
+				t.Errorf("IsAcknowledged() = %v, want %v", m.IsAcknowledged(), tt.wantAck)
+			}
+			if len(tp.tasks) != tt.wantTasks {
+				t.Errorf("tasks = %+v, want %d", tp.tasks, tt.wantTasks)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"slack-bot/project/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer はサービス層のスパンを記録します
+var tracer = otel.Tracer("slack-bot/project/service")
+
+// tracedReminderService は ReminderService の各メソッドをスパンで囲むデコレーターです
+// Slack API・Firestore・Cloud Tasks の呼び出しはこのスパンの子として記録されます
+type tracedReminderService struct {
+	next ReminderService
+}
+
+// startSpan は ReminderService のメソッドのスパンを開始します
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "ReminderService."+name, trace.WithAttributes(attrs...))
+}
+
+// payloadAttrs はタスクの対象メンションを表すスパン属性を返します
+func payloadAttrs(p *TaskPayload) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("slack.team_id", p.TeamID),
+		attribute.String("slack.channel_id", p.ChannelID),
+		attribute.String("slack.message_ts", p.MessageTS),
+		attribute.String("slack.user_id", p.UserID),
+		attribute.Int("reminder.step", p.Step),
+	}
+}
+
+// refAttrs はボタン操作の対象メンションを表すスパン属性を返します
+func refAttrs(ref ReminderRef, actorUserID string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("slack.team_id", ref.TeamID),
+		attribute.String("slack.channel_id", ref.ChannelID),
+		attribute.String("slack.message_ts", ref.MessageTS),
+		attribute.String("slack.user_id", ref.UserID),
+		attribute.String("slack.actor_user_id", actorUserID),
+	}
+}
+
+// OnMention はメンション検知をスパンで囲みます
+func (t *tracedReminderService) OnMention(ctx context.Context, ev *MentionEvent) (err error) {
+	ctx, span := startSpan(ctx, "OnMention",
+		attribute.String("slack.team_id", ev.TeamID),
+		attribute.String("slack.channel_id", ev.ChannelID),
+		attribute.String("slack.message_ts", ev.MessageTS))
+	defer func() { tracing.End(span, err) }()
+	return t.next.OnMention(ctx, ev)
+}
+
+// OnMessage はスレッド返信の照合をスパンで囲みます
+func (t *tracedReminderService) OnMessage(ctx context.Context, ev *MessageEvent) (err error) {
+	ctx, span := startSpan(ctx, "OnMessage",
+		attribute.String("slack.team_id", ev.TeamID),
+		attribute.String("slack.channel_id", ev.ChannelID),
+		attribute.String("slack.message_ts", ev.MessageTS),
+		attribute.String("slack.thread_ts", ev.ThreadTS))
+	defer func() { tracing.End(span, err) }()
+	return t.next.OnMessage(ctx, ev)
+}
+
+// OnReaction はリアクションの反映をスパンで囲みます
+func (t *tracedReminderService) OnReaction(ctx context.Context, ev *ReactionEvent) (err error) {
+	ctx, span := startSpan(ctx, "OnReaction",
+		attribute.String("slack.team_id", ev.TeamID),
+		attribute.String("slack.channel_id", ev.ChannelID),
+		attribute.String("slack.message_ts", ev.MessageTS),
+		attribute.Bool("slack.reaction_removed", ev.Removed))
+	defer func() { tracing.End(span, err) }()
+	return t.next.OnReaction(ctx, ev)
+}
+
+// CheckRemind は最初のステップの実行をスパンで囲みます
+func (t *tracedReminderService) CheckRemind(ctx context.Context, p *TaskPayload) (err error) {
+	ctx, span := startSpan(ctx, "CheckRemind", payloadAttrs(p)...)
+	defer func() { tracing.End(span, err) }()
+	return t.next.CheckRemind(ctx, p)
+}
+
+// CheckEscalate は2番目以降のステップの実行をスパンで囲みます
+func (t *tracedReminderService) CheckEscalate(ctx context.Context, p *TaskPayload) (err error) {
+	ctx, span := startSpan(ctx, "CheckEscalate", payloadAttrs(p)...)
+	defer func() { tracing.End(span, err) }()
+	return t.next.CheckEscalate(ctx, p)
+}
+
+// Acknowledge は「確認しました」操作をスパンで囲みます
+func (t *tracedReminderService) Acknowledge(ctx context.Context, ref ReminderRef, actorUserID string, nowUnix int64) (err error) {
+	ctx, span := startSpan(ctx, "Acknowledge", refAttrs(ref, actorUserID)...)
+	defer func() { tracing.End(span, err) }()
+	return t.next.Acknowledge(ctx, ref, actorUserID, nowUnix)
+}
+
+// Snooze はスヌーズ操作をスパンで囲みます
+func (t *tracedReminderService) Snooze(ctx context.Context, ref ReminderRef, actorUserID string, d time.Duration, nowUnix int64) (until int64, err error) {
+	ctx, span := startSpan(ctx, "Snooze", append(refAttrs(ref, actorUserID), attribute.String("reminder.snooze", d.String()))...)
+	defer func() { tracing.End(span, err) }()
+	return t.next.Snooze(ctx, ref, actorUserID, d, nowUnix)
+}
+
+// Decline は「担当外」操作をスパンで囲みます
+func (t *tracedReminderService) Decline(ctx context.Context, ref ReminderRef, actorUserID string, nowUnix int64) (err error) {
+	ctx, span := startSpan(ctx, "Decline", refAttrs(ref, actorUserID)...)
+	defer func() { tracing.End(span, err) }()
+	return t.next.Decline(ctx, ref, actorUserID, nowUnix)
+}
+
+// ListMine は自分の監視中メンションの一覧をスパンで囲みます
+func (t *tracedReminderService) ListMine(ctx context.Context, teamID, userID string, nowUnix int64) (mine *MyMentions, err error) {
+	ctx, span := startSpan(ctx, "ListMine",
+		attribute.String("slack.team_id", teamID),
+		attribute.String("slack.user_id", userID))
+	defer func() { tracing.End(span, err) }()
+	return t.next.ListMine(ctx, teamID, userID, nowUnix)
+}
+
+// ListPending は未返信メンションの一覧をスパンで囲みます
+func (t *tracedReminderService) ListPending(ctx context.Context, teamID, viewerUserID string, q PendingQuery, nowUnix int64) (page *PendingPage, err error) {
+	ctx, span := startSpan(ctx, "ListPending",
+		attribute.String("slack.team_id", teamID),
+		attribute.String("slack.user_id", viewerUserID),
+		attribute.Int("pending.page", q.Page))
+	defer func() { tracing.End(span, err) }()
+	return t.next.ListPending(ctx, teamID, viewerUserID, q, nowUnix)
+}
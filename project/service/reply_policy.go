@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+
+	"slack-bot/project/domain"
+)
+
+// replyChecker は返信判定ルールに応じて対象者の返信完了を判定します
+type replyChecker interface {
+	// Check はタスク実行時に Slack API で返信を確認します（イベント取りこぼし分の検知）
+	Check(ctx context.Context, sp SlackPort, m *domain.Mention) (ReplyCheck, error)
+
+	// MatchesThreadReply は対象者のスレッド返信（message イベント）が返信条件を満たすか判定します
+	MatchesThreadReply(m *domain.Mention, ev *MessageEvent) bool
+
+	// MatchesChannelPost は対象者のスレッド外の投稿（message イベント）を返信とみなすか判定します
+	MatchesChannelPost(ev *MessageEvent) bool
+}
+
+// newReplyChecker は返信判定ルールに対応する replyChecker を返します
+func newReplyChecker(rule domain.ReplyRule) replyChecker {
+	switch rule.Policy {
+	case domain.ReplyPolicyThreadReply:
+		return threadReplyChecker{}
+	case domain.ReplyPolicyChannel:
+		return channelReplyChecker{}
+	case domain.ReplyPolicyReaction:
+		return reactionReplyChecker{reaction: rule.ReactionName()}
+	default:
+		return mentionReplyChecker{}
+	}
+}
+
+// replyCheckerFor はテナント・チャンネルに適用する replyChecker を返します（テナント未登録ならデフォルト）
+func replyCheckerFor(tenant *domain.Tenant, channelID string) replyChecker {
+	if tenant == nil {
+		return newReplyChecker(domain.DefaultReplyRule())
+	}
+	return newReplyChecker(tenant.ReplyRuleFor(channelID))
+}
+
+// mentionReplyChecker はメンション送信者へのメンション付きスレッド返信で判定します
+type mentionReplyChecker struct{}
+
+// Check はスレッド内のメンション付き返信を確認します
+func (mentionReplyChecker) Check(ctx context.Context, sp SlackPort, m *domain.Mention) (ReplyCheck, error) {
+	return sp.HasUserRepliedWithMention(ctx, m.TeamID, m.ChannelID, m.ThreadRootTS(), m.MessageTS, m.MentionedUserID, m.ParentUserID)
+}
+
+// MatchesThreadReply は返信にメンション送信者へのメンションが含まれるか判定します
+func (mentionReplyChecker) MatchesThreadReply(m *domain.Mention, ev *MessageEvent) bool {
+	return isReplyToParent(ev.Text, m.ParentUserID)
+}
+
+// MatchesChannelPost はスレッド外の投稿では返信完了としません
+func (mentionReplyChecker) MatchesChannelPost(ev *MessageEvent) bool {
+	return false
+}
+
+// threadReplyChecker はメンションの有無を問わずスレッド返信で判定します
+type threadReplyChecker struct{}
+
+// Check はスレッド内の対象者の投稿を確認します
+func (threadReplyChecker) Check(ctx context.Context, sp SlackPort, m *domain.Mention) (ReplyCheck, error) {
+	return sp.HasUserRepliedWithMention(ctx, m.TeamID, m.ChannelID, m.ThreadRootTS(), m.MessageTS, m.MentionedUserID, "")
+}
+
+// MatchesThreadReply は対象者のスレッド返信であれば常に返信完了とします
+func (threadReplyChecker) MatchesThreadReply(m *domain.Mention, ev *MessageEvent) bool {
+	return true
+}
+
+// MatchesChannelPost はスレッド外の投稿では返信完了としません
+func (threadReplyChecker) MatchesChannelPost(ev *MessageEvent) bool {
+	return false
+}
+
+// channelReplyChecker はメンション以降のスレッド返信またはチャンネルへの投稿で判定します
+type channelReplyChecker struct{}
+
+// Check はスレッド内、次にチャンネル（スレッド外）の対象者の投稿を確認します
+func (channelReplyChecker) Check(ctx context.Context, sp SlackPort, m *domain.Mention) (ReplyCheck, error) {
+	check, err := sp.HasUserRepliedWithMention(ctx, m.TeamID, m.ChannelID, m.ThreadRootTS(), m.MessageTS, m.MentionedUserID, "")
+	if err != nil || check.Replied || !check.Complete {
+		return check, err
+	}
+
+	channelCheck, err := sp.HasUserPostedInChannel(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID)
+	if err != nil {
+		return ReplyCheck{}, err
+	}
+	channelCheck.PagesScanned += check.PagesScanned
+	return channelCheck, nil
+}
+
+// MatchesThreadReply は対象者のスレッド返信であれば常に返信完了とします
+func (channelReplyChecker) MatchesThreadReply(m *domain.Mention, ev *MessageEvent) bool {
+	return true
+}
+
+// MatchesChannelPost は対象者のチャンネルへの投稿であれば常に返信完了とします
+func (channelReplyChecker) MatchesChannelPost(ev *MessageEvent) bool {
+	return true
+}
+
+// reactionReplyChecker はメンションを含むメッセージへの指定リアクションで判定します
+type reactionReplyChecker struct {
+	reaction string
+}
+
+// Check はメンションを含むメッセージに対象者が指定のリアクションをつけているか確認します
+func (c reactionReplyChecker) Check(ctx context.Context, sp SlackPort, m *domain.Mention) (ReplyCheck, error) {
+	return sp.HasUserReacted(ctx, m.TeamID, m.ChannelID, m.MessageTS, m.MentionedUserID, c.reaction)
+}
+
+// MatchesThreadReply はリアクションでのみ判定するため、スレッド返信では返信完了としません
+func (reactionReplyChecker) MatchesThreadReply(m *domain.Mention, ev *MessageEvent) bool {
+	return false
+}
+
+// MatchesChannelPost はリアクションでのみ判定するため、チャンネルへの投稿では返信完了としません
+func (reactionReplyChecker) MatchesChannelPost(ev *MessageEvent) bool {
+	return false
+}
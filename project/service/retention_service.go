@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"slack-bot/project/domain"
+	"slack-bot/project/infrastructure/config"
+	"slack-bot/project/infrastructure/metrics"
+)
+
+const (
+	// sweepBatchSize は1回の削除で扱うメンションの件数です（Firestore の書き込み上限 500 件より小さくする）
+	sweepBatchSize = 200
+
+	// sweepMaxBatches は1回の定期実行でワークスペースごとに削除するバッチ数の上限です
+	// 残りは次回の定期実行で削除します
+	sweepMaxBatches = 50
+)
+
+// RetentionService はメンション記録の保持期間の管理を担当します
+type RetentionService interface {
+	// Sweep は有効な全ワークスペースについて、保持期間を過ぎた監視終了済みのメンション記録をバッチで削除します
+	// 保持期間はワークスペースの設定（なければ RETENTION_PERIOD）で、メンションからの期間です
+	// 削除した件数と、失敗したワークスペースのエラーをまとめて返します
+	Sweep(ctx context.Context, nowUnix int64) (int, error)
+}
+
+// retentionService は RetentionService の実装です
+type retentionService struct {
+	cfg *config.Config
+	mr  domain.MentionRepository
+	tr  domain.TenantRepository
+}
+
+// NewRetentionService は RetentionService のインスタンスを作成します
+func NewRetentionService(cfg *config.Config, mr domain.MentionRepository, tr domain.TenantRepository) RetentionService {
+	return &retentionService{
+		cfg: cfg,
+		mr:  mr,
+		tr:  tr,
+	}
+}
+
+// Sweep は保持期間を過ぎたメンション記録を削除します
+func (rs *retentionService) Sweep(ctx context.Context, nowUnix int64) (int, error) {
+	tenants, err := rs.tr.ListActive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Sweep: テナント一覧取得失敗: %w", err)
+	}
+
+	deleted := 0
+	var errs []error
+	for _, tenant := range tenants {
+		period := tenant.RetentionPeriod(rs.cfg.RetentionPeriod)
+		if period <= 0 {
+			continue // 保持期間が無効（削除しない）
+		}
+
+		n, err := rs.sweepTeam(ctx, tenant.TeamID, nowUnix-int64(period/time.Second))
+		deleted += n
+		metrics.MentionsDeleted(tenant.TeamID, n)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Sweep (team=%s): %w", tenant.TeamID, err))
+		}
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// sweepTeam は before より前に作成された監視終了済みのメンションを、対象がなくなるか上限に達するまでバッチで削除します
+func (rs *retentionService) sweepTeam(ctx context.Context, teamID string, before int64) (int, error) {
+	deleted := 0
+	for range sweepMaxBatches {
+		n, err := rs.mr.DeleteClosedCreatedBefore(ctx, teamID, before, sweepBatchSize)
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("メンション削除失敗: %w", err)
+		}
+		if n < sweepBatchSize {
+			break
+		}
+	}
+	return deleted, nil
+}